@@ -0,0 +1,77 @@
+package aoi
+
+// 视野变化的回调, watcher看到或看不到target
+type Listener interface {
+	EnterView(watcher, target uint64)
+	LeaveView(watcher, target uint64)
+}
+
+// 所有实体使用相同的视野半径, 视野是对称的
+type AOI interface {
+	Enter(id uint64, x, z float32)
+	Leave(id uint64)
+	Move(id uint64, x, z float32)
+	// 查询圆形范围内的实体
+	Query(x, z, radius float32, f func(id uint64))
+	// 实体视野内的实体
+	View(id uint64, f func(id uint64))
+}
+
+type entity struct {
+	id    uint64
+	x, z  float32
+	view  map[*entity]struct{}
+	stamp uint64
+
+	// 网格
+	cell  cellKey
+	index int
+
+	// 十字链表
+	xprev, xnext *entity
+	zprev, znext *entity
+}
+
+func inRange(x1, z1, x2, z2, radius float32) bool {
+	dx, dz := x1-x2, z1-z2
+	return dx*dx+dz*dz <= radius*radius
+}
+
+// 视野的维护, 根据新的可见实体计算进入和离开
+type views struct {
+	listener   Listener
+	stamp      uint64
+	candidates []*entity
+}
+
+func (v *views) update(e *entity, candidates []*entity) {
+	v.stamp++
+	for _, c := range candidates {
+		c.stamp = v.stamp
+		if _, ok := e.view[c]; ok {
+			continue
+		}
+		e.view[c] = struct{}{}
+		c.view[e] = struct{}{}
+		v.listener.EnterView(e.id, c.id)
+		v.listener.EnterView(c.id, e.id)
+	}
+	for c := range e.view {
+		if c.stamp == v.stamp {
+			continue
+		}
+		delete(e.view, c)
+		delete(c.view, e)
+		v.listener.LeaveView(e.id, c.id)
+		v.listener.LeaveView(c.id, e.id)
+	}
+}
+
+func (v *views) leave(e *entity) {
+	for c := range e.view {
+		delete(c.view, e)
+		v.listener.LeaveView(e.id, c.id)
+		v.listener.LeaveView(c.id, e.id)
+	}
+	e.view = nil
+}
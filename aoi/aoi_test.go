@@ -0,0 +1,197 @@
+package aoi
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+const kRadius = 10
+
+type pair struct {
+	watcher, target uint64
+}
+
+// 记录回调得到的视野
+type recorder struct {
+	t     *testing.T
+	views map[pair]bool
+}
+
+func newRecorder(t *testing.T) *recorder {
+	return &recorder{t: t, views: make(map[pair]bool)}
+}
+
+func (r *recorder) EnterView(watcher, target uint64) {
+	p := pair{watcher, target}
+	if r.views[p] {
+		r.t.Errorf("duplicate enter %v", p)
+	}
+	r.views[p] = true
+}
+
+func (r *recorder) LeaveView(watcher, target uint64) {
+	p := pair{watcher, target}
+	if !r.views[p] {
+		r.t.Errorf("leave without enter %v", p)
+	}
+	delete(r.views, p)
+}
+
+type position struct {
+	x, z float32
+}
+
+func bruteForce(positions map[uint64]position) map[pair]bool {
+	views := make(map[pair]bool)
+	for a, pa := range positions {
+		for b, pb := range positions {
+			if a != b && inRange(pa.x, pa.z, pb.x, pb.z, kRadius) {
+				views[pair{a, b}] = true
+			}
+		}
+	}
+	return views
+}
+
+func sortedIds(f func(f func(id uint64))) []uint64 {
+	var ids []uint64
+	f(func(id uint64) { ids = append(ids, id) })
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+func equalIds(a, b []uint64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func testAOI(t *testing.T, newAOI func(listener Listener) AOI) {
+	r := newRecorder(t)
+	aoi := newAOI(r)
+	positions := make(map[uint64]position)
+	random := rand.New(rand.NewSource(1))
+	randomPos := func() position {
+		return position{random.Float32()*200 - 100, random.Float32()*200 - 100}
+	}
+	for i := 0; i < 5000; i++ {
+		id := uint64(random.Intn(100))
+		switch p, ok := positions[id]; {
+		case !ok:
+			p = randomPos()
+			positions[id] = p
+			aoi.Enter(id, p.x, p.z)
+		case random.Intn(10) == 0:
+			delete(positions, id)
+			aoi.Leave(id)
+		case random.Intn(2) == 0:
+			// 小范围移动
+			p.x += random.Float32()*8 - 4
+			p.z += random.Float32()*8 - 4
+			positions[id] = p
+			aoi.Move(id, p.x, p.z)
+		default:
+			p = randomPos()
+			positions[id] = p
+			aoi.Move(id, p.x, p.z)
+		}
+		if i%100 != 0 {
+			continue
+		}
+		expected := bruteForce(positions)
+		if len(expected) != len(r.views) {
+			t.Fatalf("step %v: views %v, expected %v", i, len(r.views), len(expected))
+		}
+		for p := range expected {
+			if !r.views[p] {
+				t.Fatalf("step %v: missing view %v", i, p)
+			}
+		}
+		for id := range positions {
+			var want []uint64
+			for p := range expected {
+				if p.watcher == id {
+					want = append(want, p.target)
+				}
+			}
+			sort.Slice(want, func(i, j int) bool { return want[i] < want[j] })
+			got := sortedIds(func(f func(id uint64)) { aoi.View(id, f) })
+			if !equalIds(got, want) {
+				t.Fatalf("step %v: view of %v got %v, want %v", i, id, got, want)
+			}
+		}
+		q := randomPos()
+		radius := random.Float32() * 30
+		var want []uint64
+		for id, p := range positions {
+			if inRange(q.x, q.z, p.x, p.z, radius) {
+				want = append(want, id)
+			}
+		}
+		sort.Slice(want, func(i, j int) bool { return want[i] < want[j] })
+		got := sortedIds(func(f func(id uint64)) { aoi.Query(q.x, q.z, radius, f) })
+		if !equalIds(got, want) {
+			t.Fatalf("step %v: query got %v, want %v", i, got, want)
+		}
+	}
+	for id := range positions {
+		aoi.Leave(id)
+	}
+	if len(r.views) != 0 {
+		t.Fatalf("views %v after leave all", len(r.views))
+	}
+}
+
+func TestGrid(t *testing.T) {
+	testAOI(t, func(listener Listener) AOI { return NewGrid(kRadius, listener) })
+}
+
+func TestList(t *testing.T) {
+	testAOI(t, func(listener Listener) AOI { return NewList(kRadius, listener) })
+}
+
+type nopListener struct{}
+
+func (nopListener) EnterView(watcher, target uint64) {}
+func (nopListener) LeaveView(watcher, target uint64) {}
+
+// n个实体在1000x1000的场景中随机游走
+func benchmarkMove(b *testing.B, aoi AOI, n int) {
+	random := rand.New(rand.NewSource(1))
+	positions := make([]position, n)
+	for i := range positions {
+		positions[i] = position{random.Float32() * 1000, random.Float32() * 1000}
+		aoi.Enter(uint64(i), positions[i].x, positions[i].z)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		id := i % n
+		p := &positions[id]
+		p.x = min(max(p.x+random.Float32()*4-2, 0), 1000)
+		p.z = min(max(p.z+random.Float32()*4-2, 0), 1000)
+		aoi.Move(uint64(id), p.x, p.z)
+	}
+}
+
+func BenchmarkGridMove1000(b *testing.B) {
+	benchmarkMove(b, NewGrid(kRadius*2, nopListener{}), 1000)
+}
+
+func BenchmarkGridMove5000(b *testing.B) {
+	benchmarkMove(b, NewGrid(kRadius*2, nopListener{}), 5000)
+}
+
+func BenchmarkListMove1000(b *testing.B) {
+	benchmarkMove(b, NewList(kRadius*2, nopListener{}), 1000)
+}
+
+func BenchmarkListMove5000(b *testing.B) {
+	benchmarkMove(b, NewList(kRadius*2, nopListener{}), 5000)
+}
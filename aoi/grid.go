@@ -0,0 +1,126 @@
+package aoi
+
+import (
+	"math"
+)
+
+type cellKey struct {
+	x, z int32
+}
+
+// 网格实现, 格子边长等于视野半径, 只需要检查周围3x3个格子
+type Grid struct {
+	views
+	radius   float32
+	cells    map[cellKey][]*entity
+	entities map[uint64]*entity
+}
+
+func NewGrid(radius float32, listener Listener) *Grid {
+	return &Grid{
+		views:    views{listener: listener},
+		radius:   radius,
+		cells:    make(map[cellKey][]*entity),
+		entities: make(map[uint64]*entity),
+	}
+}
+
+func (g *Grid) cellOf(x, z float32) cellKey {
+	return cellKey{int32(math.Floor(float64(x / g.radius))), int32(math.Floor(float64(z / g.radius)))}
+}
+
+func (g *Grid) add(e *entity) {
+	e.cell = g.cellOf(e.x, e.z)
+	cell := g.cells[e.cell]
+	e.index = len(cell)
+	g.cells[e.cell] = append(cell, e)
+}
+
+func (g *Grid) remove(e *entity) {
+	cell := g.cells[e.cell]
+	last := cell[len(cell)-1]
+	cell[e.index] = last
+	last.index = e.index
+	cell[len(cell)-1] = nil
+	cell = cell[:len(cell)-1]
+	if len(cell) == 0 {
+		delete(g.cells, e.cell)
+	} else {
+		g.cells[e.cell] = cell
+	}
+}
+
+// 遍历覆盖圆形范围的格子中的实体
+func (g *Grid) each(x, z, radius float32, f func(e *entity)) {
+	min := g.cellOf(x-radius, z-radius)
+	max := g.cellOf(x+radius, z+radius)
+	for cx := min.x; cx <= max.x; cx++ {
+		for cz := min.z; cz <= max.z; cz++ {
+			for _, e := range g.cells[cellKey{cx, cz}] {
+				if inRange(x, z, e.x, e.z, radius) {
+					f(e)
+				}
+			}
+		}
+	}
+}
+
+func (g *Grid) updateView(e *entity) {
+	candidates := g.candidates[:0]
+	g.each(e.x, e.z, g.radius, func(c *entity) {
+		if c != e {
+			candidates = append(candidates, c)
+		}
+	})
+	g.update(e, candidates)
+	clear(candidates)
+	g.candidates = candidates[:0]
+}
+
+func (g *Grid) Enter(id uint64, x, z float32) {
+	if _, ok := g.entities[id]; ok {
+		g.Move(id, x, z)
+		return
+	}
+	e := &entity{id: id, x: x, z: z, view: make(map[*entity]struct{})}
+	g.entities[id] = e
+	g.add(e)
+	g.updateView(e)
+}
+
+func (g *Grid) Leave(id uint64) {
+	e, ok := g.entities[id]
+	if !ok {
+		return
+	}
+	delete(g.entities, id)
+	g.remove(e)
+	g.leave(e)
+}
+
+func (g *Grid) Move(id uint64, x, z float32) {
+	e, ok := g.entities[id]
+	if !ok {
+		return
+	}
+	e.x, e.z = x, z
+	if cell := g.cellOf(x, z); cell != e.cell {
+		g.remove(e)
+		g.add(e)
+	}
+	g.updateView(e)
+}
+
+func (g *Grid) Query(x, z, radius float32, f func(id uint64)) {
+	g.each(x, z, radius, func(e *entity) {
+		f(e.id)
+	})
+}
+
+func (g *Grid) View(id uint64, f func(id uint64)) {
+	if e, ok := g.entities[id]; ok {
+		for c := range e.view {
+			f(c.id)
+		}
+	}
+}
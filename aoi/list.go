@@ -0,0 +1,191 @@
+package aoi
+
+// 十字链表实现, 实体按x和z分别排序, 移动时只需在链表中向相邻位置调整
+type List struct {
+	views
+	radius   float32
+	xhead    *entity
+	zhead    *entity
+	entities map[uint64]*entity
+}
+
+func NewList(radius float32, listener Listener) *List {
+	return &List{
+		views:    views{listener: listener},
+		radius:   radius,
+		entities: make(map[uint64]*entity),
+	}
+}
+
+// 链表的一个轴
+type axis struct {
+	head  func(l *List) **entity
+	value func(e *entity) float32
+	prev  func(e *entity) **entity
+	next  func(e *entity) **entity
+}
+
+var xaxis = axis{
+	head:  func(l *List) **entity { return &l.xhead },
+	value: func(e *entity) float32 { return e.x },
+	prev:  func(e *entity) **entity { return &e.xprev },
+	next:  func(e *entity) **entity { return &e.xnext },
+}
+
+var zaxis = axis{
+	head:  func(l *List) **entity { return &l.zhead },
+	value: func(e *entity) float32 { return e.z },
+	prev:  func(e *entity) **entity { return &e.zprev },
+	next:  func(e *entity) **entity { return &e.znext },
+}
+
+// 插入到after之后, after为nil时插入到头部
+func (l *List) link(a *axis, e, after *entity) {
+	head := a.head(l)
+	var next *entity
+	if after == nil {
+		next = *head
+		*head = e
+	} else {
+		next = *a.next(after)
+		*a.next(after) = e
+	}
+	*a.prev(e) = after
+	*a.next(e) = next
+	if next != nil {
+		*a.prev(next) = e
+	}
+}
+
+func (l *List) unlink(a *axis, e *entity) {
+	prev, next := *a.prev(e), *a.next(e)
+	if prev == nil {
+		*a.head(l) = next
+	} else {
+		*a.next(prev) = next
+	}
+	if next != nil {
+		*a.prev(next) = prev
+	}
+	*a.prev(e), *a.next(e) = nil, nil
+}
+
+func (l *List) insert(a *axis, e *entity) {
+	var after *entity
+	for n := *a.head(l); n != nil && a.value(n) < a.value(e); n = *a.next(n) {
+		after = n
+	}
+	l.link(a, e, after)
+}
+
+// 坐标改变后向前或向后移动到有序的位置
+func (l *List) reorder(a *axis, e *entity) {
+	v := a.value(e)
+	if prev := *a.prev(e); prev != nil && a.value(prev) > v {
+		after := *a.prev(prev)
+		for after != nil && a.value(after) > v {
+			after = *a.prev(after)
+		}
+		l.unlink(a, e)
+		l.link(a, e, after)
+		return
+	}
+	if next := *a.next(e); next != nil && a.value(next) < v {
+		after := next
+		for n := *a.next(next); n != nil && a.value(n) < v; n = *a.next(n) {
+			after = n
+		}
+		l.unlink(a, e)
+		l.link(a, e, after)
+	}
+}
+
+// 轴上与e的距离不超过radius的实体数量
+func (l *List) band(a *axis, e *entity, radius float32) int {
+	count := 0
+	v := a.value(e)
+	for n := *a.prev(e); n != nil && v-a.value(n) <= radius; n = *a.prev(n) {
+		count++
+	}
+	for n := *a.next(e); n != nil && a.value(n)-v <= radius; n = *a.next(n) {
+		count++
+	}
+	return count
+}
+
+func (l *List) updateView(e *entity) {
+	// 选择范围内实体较少的轴遍历
+	a := &xaxis
+	if l.band(&zaxis, e, l.radius) < l.band(&xaxis, e, l.radius) {
+		a = &zaxis
+	}
+	candidates := l.candidates[:0]
+	v := a.value(e)
+	for n := *a.prev(e); n != nil && v-a.value(n) <= l.radius; n = *a.prev(n) {
+		if inRange(e.x, e.z, n.x, n.z, l.radius) {
+			candidates = append(candidates, n)
+		}
+	}
+	for n := *a.next(e); n != nil && a.value(n)-v <= l.radius; n = *a.next(n) {
+		if inRange(e.x, e.z, n.x, n.z, l.radius) {
+			candidates = append(candidates, n)
+		}
+	}
+	l.update(e, candidates)
+	clear(candidates)
+	l.candidates = candidates[:0]
+}
+
+func (l *List) Enter(id uint64, x, z float32) {
+	if _, ok := l.entities[id]; ok {
+		l.Move(id, x, z)
+		return
+	}
+	e := &entity{id: id, x: x, z: z, view: make(map[*entity]struct{})}
+	l.entities[id] = e
+	l.insert(&xaxis, e)
+	l.insert(&zaxis, e)
+	l.updateView(e)
+}
+
+func (l *List) Leave(id uint64) {
+	e, ok := l.entities[id]
+	if !ok {
+		return
+	}
+	delete(l.entities, id)
+	l.unlink(&xaxis, e)
+	l.unlink(&zaxis, e)
+	l.leave(e)
+}
+
+func (l *List) Move(id uint64, x, z float32) {
+	e, ok := l.entities[id]
+	if !ok {
+		return
+	}
+	e.x, e.z = x, z
+	l.reorder(&xaxis, e)
+	l.reorder(&zaxis, e)
+	l.updateView(e)
+}
+
+func (l *List) Query(x, z, radius float32, f func(id uint64)) {
+	n := l.xhead
+	for n != nil && n.x < x-radius {
+		n = n.xnext
+	}
+	for ; n != nil && n.x <= x+radius; n = n.xnext {
+		if inRange(x, z, n.x, n.z, radius) {
+			f(n.id)
+		}
+	}
+}
+
+func (l *List) View(id uint64, f func(id uint64)) {
+	if e, ok := l.entities[id]; ok {
+		for c := range e.view {
+			f(c.id)
+		}
+	}
+}
@@ -83,5 +83,5 @@ func reconstructPath[Node comparable](cameFrom map[Node]Node, current Node) []No
 	for i := 0; i < len(nodes); i++ {
 		reversedNodes[i] = nodes[length-i-1]
 	}
-	return nodes
+	return reversedNodes
 }
@@ -0,0 +1,26 @@
+package astar
+
+import (
+	"image"
+	"reflect"
+	"testing"
+)
+
+func TestFindPath(t *testing.T) {
+	p1, p2, p3 := image.Pt(0, 0), image.Pt(1, 0), image.Pt(2, 0)
+	p4 := image.Pt(1, 1)
+	g := newGraph[image.Point]().link(p1, p2).link(p2, p3).link(p1, p4).link(p4, p3)
+
+	// 路径从起点到终点
+	path := FindPath[image.Point](g, p1, p3, nodeDist, nodeDist)
+	if !reflect.DeepEqual(path, []image.Point{p1, p2, p3}) {
+		t.Fatalf("unexpected path %v", path)
+	}
+	path = FindPath[image.Point](g, p3, p1, nodeDist, nodeDist)
+	if !reflect.DeepEqual(path, []image.Point{p3, p2, p1}) {
+		t.Fatalf("unexpected path %v", path)
+	}
+	if path := FindPath[image.Point](g, p1, image.Pt(5, 5), nodeDist, nodeDist); path != nil {
+		t.Fatalf("unexpected path %v", path)
+	}
+}
@@ -144,19 +144,30 @@ func (g *graph) Neighbors(n, p, goal image.Point) []image.Point {
 			continue
 		}
 		v := directionVectors[d]
-		to := n.Add(v.Mul(abs(distance)))
-		// 点在线上
-		if (goal.Y - n.Y) * v.X == (goal.X - n.X) * v.Y &&
-			(n.X < goal.X && goal.X <= to.X || to.X <= goal.X && goal.X < n.X) &&
-			(n.Y < goal.Y && goal.Y <= to.Y || to.Y <= goal.Y && goal.Y < n.Y) {
-			jumpNodes = append(jumpNodes, goal)
-		} else {
-			jumpNodes = append(jumpNodes, to)
-		}
+		jumpNodes = append(jumpNodes, target(n, goal, v, abs(distance)))
 	}
 	return jumpNodes
 }
 
+// 目标点在跳跃范围内时, 停在目标点或者与目标点同行同列的位置
+func target(n, goal, v image.Point, distance int) image.Point {
+	diff := goal.Sub(n)
+	if v.X == 0 || v.Y == 0 {
+		// 直线方向上
+		if k := diff.X*v.X + diff.Y*v.Y; diff.X*v.Y == diff.Y*v.X && k > 0 && k <= distance {
+			return goal
+		}
+		return n.Add(v.Mul(distance))
+	}
+	// 斜线方向的象限内
+	if diff.X*v.X > 0 && diff.Y*v.Y > 0 {
+		if k := min(abs(diff.X), abs(diff.Y)); k <= distance {
+			return n.Add(v.Mul(k))
+		}
+	}
+	return n.Add(v.Mul(distance))
+}
+
 type graph struct {
 	size image.Point
 	nodes []node
@@ -210,12 +221,10 @@ func diagonalDistance(g Grid, size image.Point, jg jumpGrid, p image.Point, d di
 	v := directionVectors[d]
 	jump := jumpDirections[d]
 
-	// is near by obstacle
-	if !g.IsFreeAt(p.Add(image.Pt(v.X, 0))) || !g.IsFreeAt(p.Add(image.Pt(0, v.Y))) {
-		return 0
-	}
 	var distance int = 0
-	for n := p.Add(v); g.IsFreeAt(n); n = n.Add(v) {
+	// 每一步都不能穿过障碍的拐角
+	for n := p; g.IsFreeAt(n.Add(image.Pt(v.X, 0))) && g.IsFreeAt(n.Add(image.Pt(0, v.Y))) && g.IsFreeAt(n.Add(v)); {
+		n = n.Add(v)
 		distance++
 		if jg[n.Y*size.X + n.X]&jump != 0 {
 			return distance
@@ -5,6 +5,8 @@ import (
 	"image"
 	"log"
 	"math"
+	"math/rand"
+	"reflect"
 	"testing"
 
 	"github.com/iakud/plumeserver/astar"
@@ -105,3 +107,97 @@ func distance(p, q image.Point) float64 {
 	d := q.Sub(p)
 	return math.Sqrt(float64(d.X*d.X + d.Y*d.Y))
 }
+
+func findPath(g grid, start, goal image.Point) []image.Point {
+	graph := NewGraph(g, image.Pt(len(g[0]), len(g)))
+	return astar.FindPath(graph, start, goal, distance, distance)
+}
+
+func TestFindPath(t *testing.T) {
+	open := grid{
+		"          ",
+		"          ",
+		"          ",
+		"          ",
+		"          ",
+		"          ",
+		"          ",
+		"          ",
+	}
+	tests := []struct {
+		g     grid
+		start image.Point
+		goal  image.Point
+		path  []image.Point
+	}{
+		// 目标在直线上
+		{open, image.Pt(0, 0), image.Pt(0, 5), []image.Point{image.Pt(0, 0), image.Pt(0, 5)}},
+		// 目标在斜线方向的象限内, 斜线停在与目标同列的位置
+		{open, image.Pt(0, 0), image.Pt(3, 7), []image.Point{image.Pt(0, 0), image.Pt(3, 3), image.Pt(3, 7)}},
+		{open, image.Pt(9, 7), image.Pt(2, 4), []image.Point{image.Pt(9, 7), image.Pt(6, 4), image.Pt(2, 4)}},
+		// 不能穿过障碍的拐角
+		{grid{"  ", "# "}, image.Pt(0, 0), image.Pt(1, 1), []image.Point{image.Pt(0, 0), image.Pt(1, 0), image.Pt(1, 1)}},
+		{grid{"  ", " #"}, image.Pt(0, 1), image.Pt(1, 0), []image.Point{image.Pt(0, 1), image.Pt(0, 0), image.Pt(1, 0)}},
+	}
+	for _, test := range tests {
+		if path := findPath(test.g, test.start, test.goal); !reflect.DeepEqual(path, test.path) {
+			t.Fatalf("%v -> %v: unexpected path %v", test.start, test.goal, path)
+		}
+	}
+}
+
+func TestFindPathRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for n := 0; n < 200; n++ {
+		g := make(grid, 12)
+		for y := range g {
+			row := []byte("            ")
+			for x := range row {
+				if r.Intn(4) == 0 {
+					row[x] = '#'
+				}
+			}
+			g[y] = string(row)
+		}
+		start := image.Pt(r.Intn(12), r.Intn(12))
+		goal := image.Pt(r.Intn(12), r.Intn(12))
+		if !g.IsFreeAt(start) || !g.IsFreeAt(goal) || start == goal {
+			continue
+		}
+		path := findPath(g, start, goal)
+		if path == nil {
+			continue
+		}
+		if path[0] != start || path[len(path)-1] != goal {
+			t.Fatalf("unexpected path %v -> %v: %v", start, goal, path)
+		}
+		checkPath(t, g, path)
+	}
+}
+
+// 相邻跳点之间是直线或对角线, 经过的格子可行走且不穿过拐角
+func checkPath(t *testing.T, g grid, path []image.Point) {
+	t.Helper()
+	for i := 1; i < len(path); i++ {
+		d := path[i].Sub(path[i-1])
+		if d.X != 0 && d.Y != 0 && abs(d.X) != abs(d.Y) {
+			t.Fatalf("segment %v -> %v", path[i-1], path[i])
+		}
+		v := image.Pt(sign(d.X), sign(d.Y))
+		for p := path[i-1]; p != path[i]; p = p.Add(v) {
+			if !g.IsFreeAt(p.Add(v)) || !g.IsFreeAt(p.Add(image.Pt(v.X, 0))) || !g.IsFreeAt(p.Add(image.Pt(0, v.Y))) {
+				t.Fatalf("blocked %v in %v", p, path)
+			}
+		}
+	}
+}
+
+func sign(x int) int {
+	switch {
+	case x > 0:
+		return 1
+	case x < 0:
+		return -1
+	}
+	return 0
+}
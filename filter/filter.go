@@ -0,0 +1,148 @@
+package filter
+
+import (
+	"bufio"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// Aho-Corasick自动机, 按字符匹配, 忽略大小写
+// 构建后只读, 可以并发使用
+type Filter struct {
+	nodes []node
+}
+
+type node struct {
+	next   map[rune]int32
+	fail   int32
+	length int32 // 在此结束的最长敏感词的长度, 包括fail链上的
+}
+
+func New(words []string) *Filter {
+	f := &Filter{nodes: []node{{}}}
+	for _, word := range words {
+		f.insert(word)
+	}
+	f.build()
+	return f
+}
+
+// 每行一个词, 忽略空行和#开头的注释
+func Load(filename string) (*Filter, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var words []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		word := strings.TrimSpace(scanner.Text())
+		if word == "" || strings.HasPrefix(word, "#") {
+			continue
+		}
+		words = append(words, word)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return New(words), nil
+}
+
+func (f *Filter) insert(word string) {
+	var cur int32
+	var length int32
+	for _, r := range word {
+		r = unicode.ToLower(r)
+		length++
+		n := &f.nodes[cur]
+		next, ok := n.next[r]
+		if !ok {
+			if n.next == nil {
+				n.next = make(map[rune]int32)
+			}
+			next = int32(len(f.nodes))
+			n.next[r] = next
+			f.nodes = append(f.nodes, node{})
+		}
+		cur = next
+	}
+	if length > f.nodes[cur].length {
+		f.nodes[cur].length = length
+	}
+}
+
+// 按层次计算失败指针
+func (f *Filter) build() {
+	queue := make([]int32, 0, len(f.nodes))
+	for _, child := range f.nodes[0].next {
+		queue = append(queue, child)
+	}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for r, child := range f.nodes[cur].next {
+			fail := f.nodes[cur].fail
+			for fail != 0 && !f.has(fail, r) {
+				fail = f.nodes[fail].fail
+			}
+			if next, ok := f.nodes[fail].next[r]; ok && next != child {
+				fail = next
+			} else {
+				fail = 0
+			}
+			f.nodes[child].fail = fail
+			f.nodes[child].length = max(f.nodes[child].length, f.nodes[fail].length)
+			queue = append(queue, child)
+		}
+	}
+}
+
+func (f *Filter) has(n int32, r rune) bool {
+	_, ok := f.nodes[n].next[r]
+	return ok
+}
+
+func (f *Filter) step(cur int32, r rune) int32 {
+	r = unicode.ToLower(r)
+	for {
+		if next, ok := f.nodes[cur].next[r]; ok {
+			return next
+		}
+		if cur == 0 {
+			return 0
+		}
+		cur = f.nodes[cur].fail
+	}
+}
+
+func (f *Filter) Contains(text string) bool {
+	var cur int32
+	for _, r := range text {
+		cur = f.step(cur, r)
+		if f.nodes[cur].length > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// 把敏感词的每个字符替换为mask, 没有敏感词时返回原字符串
+func (f *Filter) Replace(text string, mask rune) string {
+	runes := []rune(text)
+	replaced := false
+	var cur int32
+	for i, r := range runes {
+		cur = f.step(cur, r)
+		// 在此结束的较短的词是最长词的后缀, 替换最长的即可
+		for j := int32(0); j < f.nodes[cur].length; j++ {
+			runes[i-int(j)] = mask
+			replaced = true
+		}
+	}
+	if !replaced {
+		return text
+	}
+	return string(runes)
+}
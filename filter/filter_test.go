@@ -0,0 +1,86 @@
+package filter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReplace(t *testing.T) {
+	f := New([]string{"bad", "badly", "ly", "坏蛋", "he", "she", "hers"})
+	tests := []struct {
+		text   string
+		expect string
+	}{
+		{"", ""},
+		{"hello world", "**llo world"},
+		{"you are BAD", "you are ***"},
+		{"badly done", "***** done"},
+		{"ushers", "u*****"},
+		{"你是坏蛋吗", "你是**吗"},
+		{"bab", "bab"},
+		{"bbadd", "b***d"},
+	}
+	for _, test := range tests {
+		if result := f.Replace(test.text, '*'); result != test.expect {
+			t.Errorf("replace %q: got %q, expected %q", test.text, result, test.expect)
+		}
+	}
+}
+
+func TestContains(t *testing.T) {
+	f := New([]string{"abcd", "bc"})
+	for text, expect := range map[string]bool{
+		"":     false,
+		"abd":  false,
+		"abce": true,
+		"xBCx": true,
+		"ab c": false,
+	} {
+		if f.Contains(text) != expect {
+			t.Errorf("contains %q: expected %v", text, expect)
+		}
+	}
+	if New(nil).Contains("anything") {
+		t.Error("empty filter matched")
+	}
+}
+
+// 和逐个位置查找的结果比较
+func TestReplaceBrute(t *testing.T) {
+	words := []string{"a", "ab", "bab", "bc", "bca", "c", "caa"}
+	f := New(words)
+	texts := []string{"abccab", "bcabab", "cacaab", "bbbbca", "xaxbxcx"}
+	for _, text := range texts {
+		expect := []rune(text)
+		for i := range text {
+			for _, word := range words {
+				if len(text[i:]) >= len(word) && text[i:i+len(word)] == word {
+					for j := i; j < i+len(word); j++ {
+						expect[j] = '*'
+					}
+				}
+			}
+		}
+		if result := f.Replace(text, '*'); result != string(expect) {
+			t.Errorf("replace %q: got %q, expected %q", text, result, string(expect))
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "words.txt")
+	if err := os.WriteFile(filename, []byte("# comment\nfoo\n\n  bar  \n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := Load(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result := f.Replace("foo # bar", '*'); result != "*** # ***" {
+		t.Fatalf("unexpected result %q", result)
+	}
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Fatal("expected error")
+	}
+}
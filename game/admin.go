@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/iakud/plumeserver/gm"
+	"github.com/iakud/plumeserver/internal/adminhttp"
+)
+
+// 管理后台, 以管理员权限执行GM命令
+type adminServer struct {
+	*adminhttp.Server
+	game *GameApp
+}
+
+func newAdminServer(game *GameApp, token string) *adminServer {
+	admin := &adminServer{game: game}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/gm", admin.handleGM)
+	admin.Server = adminhttp.NewServer("game", token, mux)
+	return admin
+}
+
+// POST /gm?line=additem 1001 1 10&operator=, 命令执行失败时返回400
+func (admin *adminServer) handleGM(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		adminhttp.WriteError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	operator := r.FormValue("operator")
+	if operator == "" {
+		operator = "admin"
+	}
+	output, err := admin.game.gm.ExecuteWait("http:"+operator, gm.LevelAdmin, r.FormValue("line"))
+	if err != nil {
+		adminhttp.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	adminhttp.WriteJSON(w, map[string]string{"output": output})
+}
+
+// 本地控制台, 每行一条命令, 以管理员权限执行
+func runConsole(game *GameApp, r io.Reader, w io.Writer) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		output, err := game.gm.ExecuteWait("console", gm.LevelAdmin, line)
+		if err != nil {
+			fmt.Fprintln(w, "error:", err)
+			continue
+		}
+		fmt.Fprintln(w, output)
+	}
+}
@@ -0,0 +1,368 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/iakud/plume/log"
+	"github.com/iakud/plumeserver/internal/auditlog"
+	"github.com/iakud/plumeserver/service/pb"
+	"github.com/iakud/plumeserver/table"
+)
+
+const (
+	kCmdBagList   int16 = 1101
+	kCmdBagChange int16 = 1102
+)
+
+var (
+	ErrItemUnknown   = errors.New("game: unknown item")
+	ErrItemCount     = errors.New("game: invalid item count")
+	ErrItemNotEnough = errors.New("game: item not enough")
+	ErrBagFull       = errors.New("game: bag full")
+	ErrTxDone        = errors.New("game: transaction done")
+)
+
+// 物品配置表中的一项
+type ItemConfig struct {
+	Id       int32  `json:"id"`
+	Name     string `json:"name"`
+	MaxStack int64  `json:"max_stack"` // 每格的堆叠上限, 0为不限
+	Currency bool   `json:"currency"`  // 货币不占格子
+}
+
+func (c *ItemConfig) Key() int32 {
+	return c.Id
+}
+
+// 从json文件加载物品配置
+func LoadItems(filename string) (map[int32]*ItemConfig, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var configs []*ItemConfig
+	if err := json.Unmarshal(data, &configs); err != nil {
+		return nil, err
+	}
+	items := make(map[int32]*ItemConfig)
+	if err := table.SliceToMap(configs, items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+type ItemStack struct {
+	Id    int32 `json:"id"`
+	Count int64 `json:"count"`
+}
+
+// 玩家的背包, 保存在玩家数据中
+type Bag struct {
+	Slots      []ItemStack     `json:"slots"`
+	Currencies map[int32]int64 `json:"currencies"`
+}
+
+func (b *Bag) clone() *Bag {
+	bag := &Bag{
+		Slots:      append([]ItemStack(nil), b.Slots...),
+		Currencies: make(map[int32]int64, len(b.Currencies)),
+	}
+	for id, count := range b.Currencies {
+		bag.Currencies[id] = count
+	}
+	return bag
+}
+
+// 物品的总数
+func (b *Bag) Count(id int32) int64 {
+	if count, ok := b.Currencies[id]; ok {
+		return count
+	}
+	var count int64
+	for _, stack := range b.Slots {
+		if stack.Id == id {
+			count += stack.Count
+		}
+	}
+	return count
+}
+
+// 先放入未满的格子, 再占用新的格子
+func (b *Bag) grant(c *ItemConfig, count int64, capacity int) error {
+	if c.Currency {
+		b.Currencies[c.Id] += count
+		return nil
+	}
+	for i := range b.Slots {
+		stack := &b.Slots[i]
+		if stack.Id != c.Id {
+			continue
+		}
+		add := count
+		if c.MaxStack > 0 {
+			add = min(count, c.MaxStack-stack.Count)
+		}
+		if add > 0 {
+			stack.Count += add
+			count -= add
+		}
+		if count == 0 {
+			return nil
+		}
+	}
+	for count > 0 {
+		if len(b.Slots) >= capacity {
+			return ErrBagFull
+		}
+		add := count
+		if c.MaxStack > 0 {
+			add = min(count, c.MaxStack)
+		}
+		b.Slots = append(b.Slots, ItemStack{Id: c.Id, Count: add})
+		count -= add
+	}
+	return nil
+}
+
+// 从后面的格子开始扣除, 空的格子删除
+func (b *Bag) consume(c *ItemConfig, count int64) error {
+	if b.Count(c.Id) < count {
+		return fmt.Errorf("%w: item %v", ErrItemNotEnough, c.Id)
+	}
+	if c.Currency {
+		b.Currencies[c.Id] -= count
+		return nil
+	}
+	for i := len(b.Slots) - 1; i >= 0 && count > 0; i-- {
+		stack := &b.Slots[i]
+		if stack.Id != c.Id {
+			continue
+		}
+		sub := min(count, stack.Count)
+		stack.Count -= sub
+		count -= sub
+	}
+	slots := b.Slots[:0]
+	for _, stack := range b.Slots {
+		if stack.Count > 0 {
+			slots = append(slots, stack)
+		}
+	}
+	b.Slots = slots
+	return nil
+}
+
+// 物品变化的审计记录
+type AuditRecord struct {
+	Time   time.Time `json:"time"`
+	Player uint64    `json:"player"`
+	Tx     uint64    `json:"tx"`
+	Reason string    `json:"reason"`
+	Item   int32     `json:"item"`
+	Delta  int64     `json:"delta"`
+	Count  int64     `json:"count"` // 变化后的总数
+}
+
+// 在逻辑线程调用, 不能阻塞
+type AuditLog interface {
+	Write(record *AuditRecord)
+	Close() error
+}
+
+// 写入日志
+type logAuditLog struct{}
+
+func (logAuditLog) Write(r *AuditRecord) {
+	log.Infof("audit: player %v tx %v reason %v item %v delta %v count %v", r.Player, r.Tx, r.Reason, r.Item, r.Delta, r.Count)
+}
+
+func (logAuditLog) Close() error {
+	return nil
+}
+
+// 每条记录一行json, 追加写入文件, 队列满或关闭后改为写入服务器日志
+func OpenFileAuditLog(filename string) (AuditLog, error) {
+	return auditlog.Open(filename, 1024, logAuditLog{}.Write)
+}
+
+type txOp struct {
+	id      int32
+	count   int64
+	consume bool
+}
+
+func (op *txOp) delta() int64 {
+	if op.consume {
+		return -op.count
+	}
+	return op.count
+}
+
+// 物品事务, 所有操作都成功才生效, 否则背包不变
+type Tx struct {
+	module *BagModule
+	player *Player
+	reason string
+	ops    []txOp
+	done   bool
+}
+
+func (tx *Tx) Grant(id int32, count int64) *Tx {
+	tx.ops = append(tx.ops, txOp{id, count, false})
+	return tx
+}
+
+func (tx *Tx) Consume(id int32, count int64) *Tx {
+	tx.ops = append(tx.ops, txOp{id, count, true})
+	return tx
+}
+
+// 按添加的顺序执行, 先扣除的物品腾出的格子可以用于之后发放的物品
+func (tx *Tx) Commit() error {
+	if tx.done {
+		return ErrTxDone
+	}
+	tx.done = true
+	m := tx.module
+	bag := tx.player.Data.Bag.clone()
+	for _, op := range tx.ops {
+		c, ok := m.items[op.id]
+		if !ok {
+			return fmt.Errorf("%w: %v", ErrItemUnknown, op.id)
+		}
+		if op.count <= 0 {
+			return fmt.Errorf("%w: item %v", ErrItemCount, op.id)
+		}
+		var err error
+		if op.consume {
+			err = bag.consume(c, op.count)
+		} else {
+			err = bag.grant(c, op.count, m.capacity)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	tx.player.Data.Bag = *bag
+	tx.player.MarkDirty()
+	m.commit(tx)
+	return nil
+}
+
+// 背包模块, 物品变化都通过事务
+type BagModule struct {
+	game     *GameApp
+	items    map[int32]*ItemConfig
+	capacity int
+	audit    AuditLog
+	nextTx   uint64
+}
+
+func NewBagModule(items map[int32]*ItemConfig, capacity int, audit AuditLog) *BagModule {
+	return &BagModule{items: items, capacity: capacity, audit: audit, nextTx: 1}
+}
+
+func (m *BagModule) Name() string {
+	return "bag"
+}
+
+func (m *BagModule) Depends() []string {
+	return []string{"player"}
+}
+
+func (m *BagModule) Init(game *GameApp) error {
+	m.game = game
+	game.hub.Register(kCmdBagList, m.handleList)
+	return nil
+}
+
+func (m *BagModule) Start() error {
+	return nil
+}
+
+func (m *BagModule) Stop() {
+	if err := m.audit.Close(); err != nil {
+		log.Errorf("game: audit close error: %v", err)
+	}
+}
+
+func (m *BagModule) Item(id int32) *ItemConfig {
+	return m.items[id]
+}
+
+// 重新加载物品配置, 背包中已有的物品不变
+func (m *BagModule) SetItems(items map[int32]*ItemConfig) {
+	m.items = items
+}
+
+// 开始事务, reason记录在审计日志中
+func (m *BagModule) Begin(p *Player, reason string) *Tx {
+	return &Tx{module: m, player: p, reason: reason}
+}
+
+// 发放邮件附件
+func (m *BagModule) GrantMail(p *Player, items []MailItem) error {
+	tx := m.Begin(p, "mail")
+	for _, item := range items {
+		tx.Grant(item.Id, int64(item.Count))
+	}
+	return tx.Commit()
+}
+
+// 按物品合并变化, 写审计日志并通知客户端
+func (m *BagModule) commit(tx *Tx) {
+	id := m.nextTx
+	m.nextTx++
+	now := time.Now()
+	p := tx.player
+	var items []int32
+	deltas := make(map[int32]int64)
+	for _, op := range tx.ops {
+		if _, ok := deltas[op.id]; !ok {
+			items = append(items, op.id)
+		}
+		deltas[op.id] += op.delta()
+	}
+	notify := &pb.BagChangeNotify{Reason: proto.String(tx.reason)}
+	for _, item := range items {
+		count := p.Data.Bag.Count(item)
+		m.audit.Write(&AuditRecord{
+			Time:   now,
+			Player: p.Id,
+			Tx:     id,
+			Reason: tx.reason,
+			Item:   item,
+			Delta:  deltas[item],
+			Count:  count,
+		})
+		notify.Changes = append(notify.Changes, &pb.BagChange{
+			Id:    proto.Int32(item),
+			Delta: proto.Int64(deltas[item]),
+			Count: proto.Int64(count),
+		})
+	}
+	if p.Online() {
+		p.Session.Send(kCmdBagChange, notify)
+	}
+}
+
+func (m *BagModule) handleList(ctx context.Context, cmd int16, req *pb.BagListRequest) {
+	p, ok := FromPlayerContext(ctx)
+	if !ok {
+		return
+	}
+	resp := &pb.BagListResponse{Capacity: proto.Int32(int32(m.capacity))}
+	for _, stack := range p.Data.Bag.Slots {
+		resp.Items = append(resp.Items, &pb.BagItem{Id: proto.Int32(stack.Id), Count: proto.Int64(stack.Count)})
+	}
+	for id, count := range p.Data.Bag.Currencies {
+		resp.Currencies = append(resp.Currencies, &pb.BagItem{Id: proto.Int32(id), Count: proto.Int64(count)})
+	}
+	p.Session.Send(cmd, resp)
+}
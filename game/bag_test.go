@@ -0,0 +1,215 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/iakud/plumeserver/internal/auditlog"
+	"github.com/iakud/plumeserver/service/pb"
+)
+
+const (
+	kItemOre   = 1
+	kItemSword = 2
+	kItemGold  = 3
+)
+
+var testItems = map[int32]*ItemConfig{
+	kItemOre:   {Id: kItemOre, Name: "ore", MaxStack: 10},
+	kItemSword: {Id: kItemSword, Name: "sword", MaxStack: 1},
+	kItemGold:  {Id: kItemGold, Name: "gold", Currency: true},
+}
+
+// 记录在内存中的审计日志
+type memoryAuditLog struct {
+	records []*AuditRecord
+}
+
+func (a *memoryAuditLog) Write(record *AuditRecord) {
+	a.records = append(a.records, record)
+}
+
+func (a *memoryAuditLog) Close() error {
+	return nil
+}
+
+func newTestBag(t *testing.T, capacity int) (*GameApp, *memoryAuditLog) {
+	game := newTestGame(t)
+	audit := &memoryAuditLog{}
+	game.players = NewPlayerManager(newMemoryPlayerStore(), time.Hour, time.Hour)
+	game.bag = NewBagModule(testItems, capacity, audit)
+	game.Register(game.players)
+	game.Register(game.bag)
+	if err := game.modules.init(game); err != nil {
+		t.Fatal(err)
+	}
+	runInLoop(game, func() {
+		if err := game.modules.start(); err != nil {
+			t.Error(err)
+		}
+	})
+	t.Cleanup(func() { runInLoop(game, game.modules.stop) })
+	return game, audit
+}
+
+func TestBagStack(t *testing.T) {
+	game, _ := newTestBag(t, 4)
+	gate := newTestGate(t)
+	p := login(t, game, game.players, gate.session(1), 1001)
+	runInLoop(game, func() {
+		if err := game.bag.Begin(p, "test").Grant(kItemOre, 25).Commit(); err != nil {
+			t.Error(err)
+		}
+	})
+	notify := &pb.BagChangeNotify{}
+	gate.expect(t, 1, kCmdBagChange, notify)
+	if len(notify.Changes) != 1 || notify.Changes[0].GetCount() != 25 || notify.GetReason() != "test" {
+		t.Fatalf("unexpected notify %v", notify)
+	}
+	runInLoop(game, func() {
+		expect := []ItemStack{{kItemOre, 10}, {kItemOre, 10}, {kItemOre, 5}}
+		if !reflect.DeepEqual(p.Data.Bag.Slots, expect) {
+			t.Errorf("unexpected slots %v", p.Data.Bag.Slots)
+		}
+		// 先填满未满的格子
+		game.bag.Begin(p, "test").Grant(kItemOre, 5).Commit()
+		if len(p.Data.Bag.Slots) != 3 || p.Data.Bag.Count(kItemOre) != 30 {
+			t.Errorf("unexpected slots %v", p.Data.Bag.Slots)
+		}
+		// 不可堆叠的物品每个占一格
+		if err := game.bag.Begin(p, "test").Grant(kItemSword, 2).Commit(); !errors.Is(err, ErrBagFull) {
+			t.Errorf("unexpected error %v", err)
+		}
+		if err := game.bag.Begin(p, "test").Consume(kItemOre, 12).Grant(kItemSword, 2).Commit(); err != nil {
+			t.Error(err)
+		}
+		expect = []ItemStack{{kItemOre, 10}, {kItemOre, 8}, {kItemSword, 1}, {kItemSword, 1}}
+		if !reflect.DeepEqual(p.Data.Bag.Slots, expect) {
+			t.Errorf("unexpected slots %v", p.Data.Bag.Slots)
+		}
+	})
+}
+
+func TestBagTransaction(t *testing.T) {
+	game, audit := newTestBag(t, 10)
+	gate := newTestGate(t)
+	p := login(t, game, game.players, gate.session(1), 1001)
+	runInLoop(game, func() {
+		game.bag.Begin(p, "init").Grant(kItemOre, 5).Grant(kItemGold, 50).Commit()
+		before := p.Data.Bag.clone()
+		records := len(audit.records)
+
+		// 任何一项失败都不改变背包
+		failures := []struct {
+			tx  *Tx
+			err error
+		}{
+			{game.bag.Begin(p, "craft").Consume(kItemOre, 3).Grant(kItemSword, 1).Consume(kItemGold, 100), ErrItemNotEnough},
+			{game.bag.Begin(p, "craft").Consume(kItemOre, 3).Grant(99, 1), ErrItemUnknown},
+			{game.bag.Begin(p, "craft").Consume(kItemOre, 3).Grant(kItemSword, 0), ErrItemCount},
+			{game.bag.Begin(p, "craft").Grant(kItemSword, -1), ErrItemCount},
+		}
+		for _, failure := range failures {
+			if err := failure.tx.Commit(); !errors.Is(err, failure.err) {
+				t.Errorf("unexpected error %v, expected %v", err, failure.err)
+			}
+			if !reflect.DeepEqual(p.Data.Bag, *before) {
+				t.Errorf("bag changed %v", p.Data.Bag)
+			}
+		}
+		if len(audit.records) != records {
+			t.Errorf("unexpected audit records %v", audit.records[records:])
+		}
+
+		tx := game.bag.Begin(p, "craft").Consume(kItemOre, 3).Grant(kItemSword, 1).Consume(kItemGold, 20)
+		if err := tx.Commit(); err != nil {
+			t.Error(err)
+		}
+		if err := tx.Commit(); err != ErrTxDone {
+			t.Errorf("unexpected error %v", err)
+		}
+		bag := &p.Data.Bag
+		if bag.Count(kItemOre) != 2 || bag.Count(kItemSword) != 1 || bag.Count(kItemGold) != 30 {
+			t.Errorf("unexpected bag %v", bag)
+		}
+		// 同一个事务的记录使用相同的事务id
+		records2 := audit.records[records:]
+		if len(records2) != 3 || records2[0].Tx != records2[2].Tx || records2[2].Item != kItemGold || records2[2].Delta != -20 || records2[2].Count != 30 {
+			t.Errorf("unexpected audit records %v", records2)
+		}
+	})
+}
+
+func TestBagGrantMail(t *testing.T) {
+	game, _ := newTestBag(t, 10)
+	gate := newTestGate(t)
+	p := login(t, game, game.players, gate.session(1), 1001)
+	runInLoop(game, func() {
+		if err := game.bag.GrantMail(p, []MailItem{{kItemOre, 3}, {kItemGold, 100}}); err != nil {
+			t.Error(err)
+		}
+		if p.Data.Bag.Count(kItemOre) != 3 || p.Data.Bag.Count(kItemGold) != 100 {
+			t.Errorf("unexpected bag %v", p.Data.Bag)
+		}
+	})
+}
+
+func TestLoadItems(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "items.json")
+	data, _ := json.Marshal([]*ItemConfig{testItems[kItemOre], testItems[kItemGold]})
+	if err := os.WriteFile(filename, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	items, err := LoadItems(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 || !reflect.DeepEqual(items[kItemGold], testItems[kItemGold]) {
+		t.Fatalf("unexpected items %v", items)
+	}
+}
+
+func TestFileAuditLog(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "audit.log")
+	audit, err := OpenFileAuditLog(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100; i++ {
+		audit.Write(&AuditRecord{Player: 1001, Tx: uint64(i), Item: kItemOre, Delta: 1, Count: int64(i + 1)})
+	}
+	if err := audit.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var n int
+	for scanner := bufio.NewScanner(f); scanner.Scan(); n++ {
+		var record AuditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatal(err)
+		}
+		if record.Tx != uint64(n) {
+			t.Fatalf("unexpected record %v", record)
+		}
+	}
+	if n != 100 {
+		t.Fatalf("unexpected records %v", n)
+	}
+
+	// 关闭后改为写入服务器日志
+	audit.Write(&AuditRecord{Player: 1001})
+	if dropped := audit.(*auditlog.File[*AuditRecord]).Dropped(); dropped != 1 || audit.Close() == nil {
+		t.Fatalf("unexpected dropped %v", dropped)
+	}
+}
@@ -0,0 +1,455 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/iakud/plume/log"
+	"github.com/iakud/plumeserver/filter"
+	"github.com/iakud/plumeserver/service/pb"
+	"github.com/iakud/plumeserver/store"
+	"github.com/iakud/plumeserver/timer"
+)
+
+const (
+	kCmdChat       int16 = 1301
+	kCmdChatNotify int16 = 1302
+)
+
+// 聊天的结果
+const (
+	kChatOK = iota
+	kChatChannel
+	kChatText
+	kChatMuted
+	kChatRateLimited
+	kChatSensitive
+	kChatTarget
+	kChatNotInGuild
+	kChatNotInScene
+	kChatFailed
+)
+
+// 频道, ChannelAll只用于禁言所有频道
+const (
+	ChannelAll int32 = iota
+	ChannelWorld
+	ChannelGuild
+	ChannelPrivate
+	ChannelScene
+)
+
+const (
+	kMaxChatText    = 256
+	kChatViolations = 5 // 连续超出频率限制的次数, 达到时自动禁言
+	kChatMask       = '*'
+
+	kSceneGroup   uint64 = 2 << 56 // 场景聊天的组id前缀
+	kWorldGroup   uint64 = 3 << 56 // 世界聊天的组, 登录后加入, 未登录的会话收不到
+	kChatMutesKey        = "chat/mutes"
+)
+
+var (
+	ErrChatChannel     = errors.New("game: invalid chat channel")
+	ErrChatText        = errors.New("game: invalid chat text")
+	ErrChatMuted       = errors.New("game: chat muted")
+	ErrChatRateLimited = errors.New("game: chat rate limited")
+	ErrChatSensitive   = errors.New("game: chat text contains sensitive words")
+	ErrChatTarget      = errors.New("game: chat target not online")
+	ErrNotInScene      = errors.New("game: not in scene")
+)
+
+var chatResults = map[error]int32{
+	ErrChatChannel:     kChatChannel,
+	ErrChatText:        kChatText,
+	ErrChatMuted:       kChatMuted,
+	ErrChatRateLimited: kChatRateLimited,
+	ErrChatSensitive:   kChatSensitive,
+	ErrChatTarget:      kChatTarget,
+	ErrNotInGuild:      kChatNotInGuild,
+	ErrNotInScene:      kChatNotInScene,
+}
+
+func chatResult(err error) int32 {
+	if err == nil {
+		return kChatOK
+	}
+	if result, ok := chatResults[err]; ok {
+		return result
+	}
+	return kChatFailed
+}
+
+// 敏感词的处理方式
+type FilterMode int
+
+const (
+	FilterReplace FilterMode = iota // 替换为*后发送
+	FilterReject                    // 拒绝发送
+)
+
+// 频道的发言限制
+type ChatChannel struct {
+	Rate  float64       // 每秒恢复的发言次数
+	Burst int           // 最多连续发言的次数
+	Mute  time.Duration // 频繁超出限制时自动禁言的时长, 为零时不禁言
+}
+
+var defaultChatChannels = map[int32]ChatChannel{
+	ChannelWorld:   {Rate: 0.1, Burst: 3, Mute: time.Minute * 10},
+	ChannelGuild:   {Rate: 1, Burst: 5, Mute: time.Minute * 5},
+	ChannelPrivate: {Rate: 1, Burst: 5, Mute: time.Minute * 5},
+	ChannelScene:   {Rate: 0.5, Burst: 5, Mute: time.Minute * 5},
+}
+
+type chatOptions struct {
+	filter   *filter.Filter
+	mode     FilterMode
+	channels map[int32]ChatChannel
+}
+
+type ChatOption func(o *chatOptions)
+
+func WithChatFilter(f *filter.Filter, mode FilterMode) ChatOption {
+	return func(o *chatOptions) {
+		o.filter = f
+		o.mode = mode
+	}
+}
+
+func WithChatChannel(channel int32, c ChatChannel) ChatOption {
+	return func(o *chatOptions) {
+		o.channels[channel] = c
+	}
+}
+
+// 令牌桶, 和gate的连接限速相同
+type chatBucket struct {
+	tokens     float64
+	last       time.Time
+	violations int
+}
+
+func (b *chatBucket) take(c ChatChannel, now time.Time) bool {
+	b.tokens = min(float64(c.Burst), b.tokens+now.Sub(b.last).Seconds()*c.Rate)
+	b.last = now
+	if b.tokens < 1 {
+		b.violations++
+		return false
+	}
+	b.tokens--
+	b.violations = 0
+	return true
+}
+
+// 聊天模块, 禁言保存在store中, 发言频率只在内存中记录
+type ChatModule struct {
+	game  *GameApp
+	store store.Store
+	opts  chatOptions
+
+	mutes   map[uint64]map[int32]time.Time // 玩家在各频道的解禁时间
+	buckets map[uint64]map[int32]*chatBucket
+	scenes  map[uint64]uint64 // 玩家所在的场景
+	ticker  *timer.Timer
+
+	saves chan []byte
+	wg    sync.WaitGroup
+}
+
+func NewChatModule(s store.Store, opt ...ChatOption) *ChatModule {
+	opts := chatOptions{channels: make(map[int32]ChatChannel)}
+	for channel, c := range defaultChatChannels {
+		opts.channels[channel] = c
+	}
+	for _, o := range opt {
+		o(&opts)
+	}
+	return &ChatModule{store: s, opts: opts}
+}
+
+func (m *ChatModule) Name() string {
+	return "chat"
+}
+
+func (m *ChatModule) Depends() []string {
+	return []string{"player", "guild"}
+}
+
+func (m *ChatModule) Init(game *GameApp) error {
+	m.game = game
+	game.players.OnLogin(m.login)
+	game.players.OnLogout(m.logout)
+	game.players.OnSceneChange(m.changeScene)
+	game.hub.Register(kCmdChat, m.handleChat)
+	return nil
+}
+
+func (m *ChatModule) Start() error {
+	m.mutes = make(map[uint64]map[int32]time.Time)
+	m.buckets = make(map[uint64]map[int32]*chatBucket)
+	m.scenes = make(map[uint64]uint64)
+	data, _, err := m.store.Get(kChatMutesKey)
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+	case err != nil:
+		return err
+	default:
+		if err := json.Unmarshal(data, &m.mutes); err != nil {
+			return err
+		}
+	}
+	m.saves = make(chan []byte, 64)
+	m.wg.Add(1)
+	go m.saveLoop()
+	m.ticker = m.game.timers.EveryFunc(time.Minute, m.expire)
+	return nil
+}
+
+func (m *ChatModule) Stop() {
+	m.ticker.Stop()
+	close(m.saves)
+	m.wg.Wait()
+}
+
+func (m *ChatModule) saveLoop() {
+	defer m.wg.Done()
+	for data := range m.saves {
+		if _, err := m.store.Put(kChatMutesKey, data, store.AnyVersion); err != nil {
+			log.Errorf("game: chat mutes save error: %v", err)
+		}
+	}
+}
+
+func (m *ChatModule) save() {
+	data, err := json.Marshal(m.mutes)
+	if err != nil {
+		log.Errorf("game: chat mutes marshal error: %v", err)
+		return
+	}
+	m.saves <- data
+}
+
+// 清理到期的禁言和已恢复满的令牌桶
+func (m *ChatModule) expire() {
+	now := time.Now()
+	expired := false
+	for playerId, mutes := range m.mutes {
+		for channel, until := range mutes {
+			if !now.Before(until) {
+				delete(mutes, channel)
+				expired = true
+			}
+		}
+		if len(mutes) == 0 {
+			delete(m.mutes, playerId)
+		}
+	}
+	if expired {
+		m.save()
+	}
+	for playerId, buckets := range m.buckets {
+		for channel, b := range buckets {
+			c := m.opts.channels[channel]
+			if b.tokens+now.Sub(b.last).Seconds()*c.Rate >= float64(c.Burst) {
+				delete(buckets, channel)
+			}
+		}
+		if len(buckets) == 0 {
+			delete(m.buckets, playerId)
+		}
+	}
+}
+
+// 重新加载敏感词, 处理方式不变
+func (m *ChatModule) SetFilter(f *filter.Filter) {
+	m.opts.filter = f
+}
+
+// 加入世界频道和保存的场景的频道
+func (m *ChatModule) login(p *Player) {
+	p.Session.JoinGroup(kWorldGroup)
+	m.enterScene(p, p.Data.Position.Scene)
+}
+
+// 会话可能继续登录其他玩家
+func (m *ChatModule) logout(p *Player) {
+	p.Session.LeaveGroup(kWorldGroup)
+	m.leaveScene(p)
+}
+
+// 场景频道跟随玩家的位置, 离线时切换场景在下次登录时加入
+func (m *ChatModule) changeScene(p *Player, from uint64) {
+	if p.Online() {
+		m.enterScene(p, p.Data.Position.Scene)
+	}
+}
+
+// 禁言玩家的频道, ChannelAll禁言所有频道, duration不大于零时解除禁言
+func (m *ChatModule) Mute(playerId uint64, channel int32, duration time.Duration) error {
+	if _, ok := m.opts.channels[channel]; !ok && channel != ChannelAll {
+		return ErrChatChannel
+	}
+	mutes, ok := m.mutes[playerId]
+	if duration <= 0 {
+		if _, muted := mutes[channel]; !muted {
+			return nil
+		}
+		delete(mutes, channel)
+		if len(mutes) == 0 {
+			delete(m.mutes, playerId)
+		}
+	} else {
+		if !ok {
+			mutes = make(map[int32]time.Time)
+			m.mutes[playerId] = mutes
+		}
+		mutes[channel] = time.Now().Add(duration)
+	}
+	m.save()
+	log.Infof("game: player %v chat channel %v muted for %v", playerId, channel, duration)
+	return nil
+}
+
+// 返回频道的解禁时间, 包括禁言所有频道
+func (m *ChatModule) Muted(playerId uint64, channel int32) (time.Time, bool) {
+	now := time.Now()
+	var until time.Time
+	for _, c := range []int32{ChannelAll, channel} {
+		if t, ok := m.mutes[playerId][c]; ok && t.After(now) && t.After(until) {
+			until = t
+		}
+	}
+	return until, !until.IsZero()
+}
+
+// 进入场景后收到场景频道的消息, sceneId为0时只离开之前的场景
+func (m *ChatModule) enterScene(p *Player, sceneId uint64) {
+	m.leaveScene(p)
+	if sceneId == 0 {
+		return
+	}
+	m.scenes[p.Id] = sceneId
+	p.Session.JoinGroup(kSceneGroup | sceneId)
+}
+
+func (m *ChatModule) leaveScene(p *Player) {
+	sceneId, ok := m.scenes[p.Id]
+	if !ok {
+		return
+	}
+	delete(m.scenes, p.Id)
+	p.Session.LeaveGroup(kSceneGroup | sceneId)
+}
+
+func (m *ChatModule) allow(playerId uint64, channel int32) error {
+	c := m.opts.channels[channel]
+	buckets, ok := m.buckets[playerId]
+	if !ok {
+		buckets = make(map[int32]*chatBucket)
+		m.buckets[playerId] = buckets
+	}
+	now := time.Now()
+	b, ok := buckets[channel]
+	if !ok {
+		b = &chatBucket{tokens: float64(c.Burst), last: now}
+		buckets[channel] = b
+	}
+	if b.take(c, now) {
+		return nil
+	}
+	if b.violations >= kChatViolations && c.Mute > 0 {
+		b.violations = 0
+		m.Mute(playerId, channel, c.Mute)
+	}
+	return ErrChatRateLimited
+}
+
+// 发送到频道, 私聊时target为接收的玩家
+func (m *ChatModule) Send(p *Player, channel int32, target uint64, text string) error {
+	if _, ok := m.opts.channels[channel]; !ok {
+		return ErrChatChannel
+	}
+	if len(text) == 0 || len(text) > kMaxChatText || !utf8.ValidString(text) {
+		return ErrChatText
+	}
+	if _, muted := m.Muted(p.Id, channel); muted {
+		return ErrChatMuted
+	}
+	// 先检查接收者, 发送失败时不计入频率
+	var group uint64
+	var receiver *Player
+	switch channel {
+	case ChannelGuild:
+		g := m.game.guild.PlayerGuild(p.Id)
+		if g == nil {
+			return ErrNotInGuild
+		}
+		group = g.Group()
+	case ChannelScene:
+		sceneId, ok := m.scenes[p.Id]
+		if !ok {
+			return ErrNotInScene
+		}
+		group = kSceneGroup | sceneId
+	case ChannelPrivate:
+		if receiver = m.game.players.Player(target); receiver == nil || !receiver.Online() || target == p.Id {
+			return ErrChatTarget
+		}
+	}
+	if err := m.allow(p.Id, channel); err != nil {
+		return err
+	}
+	if f := m.opts.filter; f != nil {
+		if m.opts.mode == FilterReject {
+			if f.Contains(text) {
+				return ErrChatSensitive
+			}
+		} else {
+			text = f.Replace(text, kChatMask)
+		}
+	}
+
+	notify := &pb.ChatNotify{
+		Channel: proto.Int32(channel),
+		Sender:  proto.Uint64(p.Id),
+		Name:    proto.String(p.Data.Name),
+		Text:    proto.String(text),
+		Time:    proto.Int64(time.Now().Unix()),
+	}
+	switch channel {
+	case ChannelWorld:
+		return m.game.Groupcast(kWorldGroup, kCmdChatNotify, notify)
+	case ChannelPrivate:
+		// 发送者也收到一份, 显示过滤后的内容
+		notify.Target = proto.Uint64(target)
+		receiver.Session.Send(kCmdChatNotify, notify)
+		if p.Online() {
+			p.Session.Send(kCmdChatNotify, notify)
+		}
+		return nil
+	default:
+		return m.game.Groupcast(group, kCmdChatNotify, notify)
+	}
+}
+
+func (m *ChatModule) handleChat(ctx context.Context, cmd int16, req *pb.ChatRequest) {
+	p, ok := FromPlayerContext(ctx)
+	if !ok {
+		return
+	}
+	err := m.Send(p, req.GetChannel(), req.GetTarget(), req.GetText())
+	resp := &pb.ChatResponse{Result: proto.Int32(chatResult(err))}
+	if err != nil {
+		log.Debugf("game: player %v chat error: %v", p.Id, err)
+		if until, muted := m.Muted(p.Id, req.GetChannel()); muted {
+			resp.MuteUntil = proto.Int64(until.Unix())
+		}
+	}
+	p.Session.Send(cmd, resp)
+}
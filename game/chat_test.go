@@ -0,0 +1,286 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/iakud/plumeserver/filter"
+	"github.com/iakud/plumeserver/service"
+	"github.com/iakud/plumeserver/service/pb"
+	"github.com/iakud/plumeserver/store"
+)
+
+func newTestChat(t *testing.T, s store.Store, opt ...ChatOption) *GameApp {
+	game := newTestGame(t)
+	game.players = NewPlayerManager(newStorePlayerStore(s), time.Hour, time.Hour)
+	game.guild = NewGuildModule(s)
+	game.chat = NewChatModule(s, opt...)
+	game.Register(game.players)
+	game.Register(game.guild)
+	game.Register(game.chat)
+	if err := game.modules.init(game); err != nil {
+		t.Fatal(err)
+	}
+	runInLoop(game, func() {
+		if err := game.modules.start(); err != nil {
+			t.Error(err)
+		}
+	})
+	return game
+}
+
+func readChat(t *testing.T, gate *testGate) (*service.BackendPacket, *pb.ChatNotify) {
+	t.Helper()
+	packet := readCmd(t, gate, kCmdChatNotify)
+	notify := &pb.ChatNotify{}
+	if err := proto.Unmarshal(packet.Payload, notify); err != nil {
+		t.Fatal(err)
+	}
+	return packet, notify
+}
+
+func TestChatChannels(t *testing.T) {
+	s, err := store.OpenFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	game := newTestChat(t, s)
+	gate := newTestGate(t)
+	gate.attach(game)
+	p1 := login(t, game, game.players, gate.session(1), 1001)
+	p2 := login(t, game, game.players, gate.session(2), 1002)
+	check := func(err, expect error) {
+		t.Helper()
+		if !errors.Is(err, expect) {
+			t.Errorf("unexpected error %v, expected %v", err, expect)
+		}
+	}
+
+	runInLoop(game, func() {
+		p1.Data.Name = "iakud"
+		check(game.chat.Send(p1, ChannelWorld, 0, "hello"), nil)
+	})
+	packet, notify := readChat(t, gate)
+	if packet.Type != service.BackendGroupcast || packet.Session != kWorldGroup || notify.GetChannel() != ChannelWorld || notify.GetSender() != 1001 || notify.GetName() != "iakud" || notify.GetText() != "hello" {
+		t.Fatalf("unexpected world chat %v", notify)
+	}
+
+	var guildId uint64
+	runInLoop(game, func() {
+		check(game.chat.Send(p1, ChannelGuild, 0, "hi"), ErrNotInGuild)
+		g, _ := game.guild.Create(p1, "dragon")
+		guildId = g.Id
+		check(game.chat.Send(p1, ChannelGuild, 0, "hi"), nil)
+	})
+	packet, notify = readChat(t, gate)
+	if packet.Type != service.BackendGroupcast || packet.Session != kGuildGroup|guildId || notify.GetText() != "hi" {
+		t.Fatalf("unexpected guild chat %v", notify)
+	}
+
+	runInLoop(game, func() {
+		check(game.chat.Send(p1, ChannelScene, 0, "hi"), ErrNotInScene)
+		game.players.SetPosition(p1, Position{Scene: 7})
+		check(game.chat.Send(p1, ChannelScene, 0, "here"), nil)
+	})
+	packet, notify = readChat(t, gate)
+	if packet.Type != service.BackendGroupcast || packet.Session != kSceneGroup|7 || notify.GetText() != "here" {
+		t.Fatalf("unexpected scene chat %v", notify)
+	}
+
+	// 私聊发送给双方
+	runInLoop(game, func() {
+		check(game.chat.Send(p1, ChannelPrivate, 1003, "hi"), ErrChatTarget)
+		check(game.chat.Send(p1, ChannelPrivate, 1001, "hi"), ErrChatTarget)
+		check(game.chat.Send(p1, ChannelPrivate, 1002, "psst"), nil)
+	})
+	for _, session := range []uint64{p2.Session.Id, p1.Session.Id} {
+		packet, notify = readChat(t, gate)
+		if packet.Type != service.BackendData || packet.Session != session || notify.GetTarget() != 1002 || notify.GetText() != "psst" {
+			t.Fatalf("unexpected private chat %v to %v", notify, packet.Session)
+		}
+	}
+
+	runInLoop(game, func() {
+		check(game.chat.Send(p1, 99, 0, "hi"), ErrChatChannel)
+		check(game.chat.Send(p1, ChannelWorld, 0, ""), ErrChatText)
+		check(game.chat.Send(p1, ChannelWorld, 0, "\xff"), ErrChatText)
+	})
+	runInLoop(game, game.modules.stop)
+}
+
+// 读取会话加入或离开的组, 跳过其他的包
+func readGroups(t *testing.T, gate *testGate, typ uint8, session uint64, n int) map[uint64]bool {
+	t.Helper()
+	groups := make(map[uint64]bool)
+	for len(groups) < n {
+		packet := gate.read(t)
+		if packet.Type != typ || packet.Session != session {
+			continue
+		}
+		group, err := packet.Group()
+		if err != nil {
+			t.Fatal(err)
+		}
+		groups[group] = true
+	}
+	return groups
+}
+
+// 登录后加入世界频道和保存的场景的频道, 登出时离开
+func TestChatGroups(t *testing.T) {
+	s, err := store.OpenFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	game := newTestChat(t, s)
+	gate := newTestGate(t)
+	gate.attach(game)
+	session := gate.session(1)
+	p := login(t, game, game.players, session, 1001)
+	if groups := readGroups(t, gate, service.BackendJoinGroup, 1, 1); !groups[kWorldGroup] {
+		t.Fatalf("unexpected groups %v", groups)
+	}
+	runInLoop(game, func() {
+		if err := game.chat.Send(p, ChannelScene, 0, "hi"); err != ErrNotInScene {
+			t.Errorf("unexpected error %v", err)
+		}
+		game.players.Logout(session)
+		// 离线时切换场景, 登录后加入
+		game.players.SetPosition(p, Position{Scene: 7})
+	})
+	if groups := readGroups(t, gate, service.BackendLeaveGroup, 1, 1); !groups[kWorldGroup] {
+		t.Fatalf("unexpected groups %v", groups)
+	}
+
+	session = gate.session(2)
+	login(t, game, game.players, session, 1001)
+	if groups := readGroups(t, gate, service.BackendJoinGroup, 2, 2); !groups[kWorldGroup] || !groups[kSceneGroup|7] {
+		t.Fatalf("unexpected groups %v", groups)
+	}
+	// 切换场景
+	runInLoop(game, func() {
+		game.players.SetPosition(p, Position{Scene: 8, X: 1})
+		if err := game.chat.Send(p, ChannelScene, 0, "hi"); err != nil {
+			t.Error(err)
+		}
+	})
+	if groups := readGroups(t, gate, service.BackendLeaveGroup, 2, 1); !groups[kSceneGroup|7] {
+		t.Fatalf("unexpected groups %v", groups)
+	}
+	if groups := readGroups(t, gate, service.BackendJoinGroup, 2, 1); !groups[kSceneGroup|8] {
+		t.Fatalf("unexpected groups %v", groups)
+	}
+	if packet, _ := readChat(t, gate); packet.Type != service.BackendGroupcast || packet.Session != kSceneGroup|8 {
+		t.Fatalf("unexpected scene chat to %v", packet.Session)
+	}
+	// 离开场景
+	runInLoop(game, func() {
+		game.players.SetPosition(p, Position{})
+		if err := game.chat.Send(p, ChannelScene, 0, "hi"); err != ErrNotInScene {
+			t.Errorf("unexpected error %v", err)
+		}
+	})
+	if groups := readGroups(t, gate, service.BackendLeaveGroup, 2, 1); !groups[kSceneGroup|8] {
+		t.Fatalf("unexpected groups %v", groups)
+	}
+	runInLoop(game, func() { game.players.Logout(session) })
+	if groups := readGroups(t, gate, service.BackendLeaveGroup, 2, 1); !groups[kWorldGroup] {
+		t.Fatalf("unexpected groups %v", groups)
+	}
+	runInLoop(game, game.modules.stop)
+}
+
+func TestChatMute(t *testing.T) {
+	s, err := store.OpenFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	channel := WithChatChannel(ChannelWorld, ChatChannel{Rate: 0.001, Burst: 2, Mute: time.Hour})
+	game := newTestChat(t, s, channel)
+	gate := newTestGate(t)
+	gate.attach(game)
+	p := login(t, game, game.players, gate.session(1), 1001)
+	runInLoop(game, func() {
+		for i := 0; i < 2; i++ {
+			if err := game.chat.Send(p, ChannelWorld, 0, "spam"); err != nil {
+				t.Error(err)
+			}
+		}
+		// 连续超出限制后自动禁言
+		for i := 0; i < kChatViolations; i++ {
+			if err := game.chat.Send(p, ChannelWorld, 0, "spam"); err != ErrChatRateLimited {
+				t.Errorf("unexpected error %v", err)
+			}
+		}
+		if err := game.chat.Send(p, ChannelWorld, 0, "spam"); err != ErrChatMuted {
+			t.Errorf("unexpected error %v", err)
+		}
+		if until, muted := game.chat.Muted(1001, ChannelWorld); !muted || until.Before(time.Now().Add(time.Minute*59)) {
+			t.Errorf("unexpected mute %v", until)
+		}
+		// 其他频道不受影响
+		if err := game.chat.Send(p, ChannelPrivate, 1001, "hi"); err != ErrChatTarget {
+			t.Errorf("unexpected error %v", err)
+		}
+		game.chat.Mute(1002, ChannelAll, time.Hour)
+	})
+	runInLoop(game, game.modules.stop)
+
+	// 禁言在重启后保留
+	game = newTestChat(t, s, channel)
+	runInLoop(game, func() {
+		if _, muted := game.chat.Muted(1001, ChannelWorld); !muted {
+			t.Error("mute not saved")
+		}
+		if _, muted := game.chat.Muted(1002, ChannelScene); !muted {
+			t.Error("mute all not saved")
+		}
+		game.chat.Mute(1001, ChannelWorld, 0)
+		if _, muted := game.chat.Muted(1001, ChannelWorld); muted {
+			t.Error("mute not removed")
+		}
+		if err := game.chat.Mute(1001, 99, time.Hour); err != ErrChatChannel {
+			t.Errorf("unexpected error %v", err)
+		}
+	})
+	runInLoop(game, game.modules.stop)
+}
+
+func TestChatFilter(t *testing.T) {
+	s, err := store.OpenFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	words := filter.New([]string{"bad"})
+	game := newTestChat(t, s, WithChatFilter(words, FilterReplace))
+	gate := newTestGate(t)
+	gate.attach(game)
+	session := gate.session(1)
+	login(t, game, game.players, session, 1001)
+	dispatch(t, game, session, kCmdChat, &pb.ChatRequest{Channel: proto.Int32(ChannelWorld), Text: proto.String("so bad")})
+	if _, notify := readChat(t, gate); notify.GetText() != "so ***" {
+		t.Fatalf("unexpected text %v", notify.GetText())
+	}
+	resp := &pb.ChatResponse{}
+	proto.Unmarshal(readCmd(t, gate, kCmdChat).Payload, resp)
+	if resp.GetResult() != kChatOK {
+		t.Fatalf("unexpected result %v", resp.GetResult())
+	}
+	runInLoop(game, game.modules.stop)
+
+	game = newTestChat(t, s, WithChatFilter(words, FilterReject))
+	session = gate.session(2)
+	login(t, game, game.players, session, 1001)
+	dispatch(t, game, session, kCmdChat, &pb.ChatRequest{Channel: proto.Int32(ChannelWorld), Text: proto.String("so bad")})
+	proto.Unmarshal(readCmd(t, gate, kCmdChat).Payload, resp)
+	if resp.GetResult() != kChatSensitive {
+		t.Fatalf("unexpected result %v", resp.GetResult())
+	}
+	runInLoop(game, game.modules.stop)
+}
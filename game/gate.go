@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/iakud/plume/log"
+	"github.com/iakud/plume/network"
+	"github.com/iakud/plumeserver/service"
+)
+
+// 接收gate的连接, 消息投递到逻辑线程处理
+type gateServer struct {
+	server *network.TCPServer
+	game   *GameApp
+	links  map[*network.TCPConnection]*gateLink // 只在逻辑线程访问
+
+	mutex  sync.Mutex
+	closed bool
+	conns  map[*network.TCPConnection]struct{}
+	wg     sync.WaitGroup // 连接断开在逻辑线程处理完成
+}
+
+// 每个gate连接上的会话, 只在逻辑线程访问
+type gateLink struct {
+	sessions map[uint64]*service.Session
+}
+
+func newGateServer(addr string, game *GameApp) *gateServer {
+	srv := &gateServer{
+		server: network.NewTCPServer(addr),
+		game:   game,
+		links:  make(map[*network.TCPConnection]*gateLink),
+		conns:  make(map[*network.TCPConnection]struct{}),
+	}
+	return srv
+}
+
+func (srv *gateServer) ListenAndServe() {
+	if err := srv.server.ListenAndServe(srv, service.BackendCodec); err != nil {
+		if err == network.ErrServerClosed {
+			return
+		}
+		log.Error("game: gate server", err)
+	}
+}
+
+// 关闭后不再接受gate连接, 已有的连接断开
+func (srv *gateServer) Close() {
+	srv.mutex.Lock()
+	srv.closed = true
+	srv.mutex.Unlock()
+	srv.server.Close()
+}
+
+// 在Close之后调用, 等待所有连接的会话在逻辑线程登出
+func (srv *gateServer) Wait() {
+	srv.wg.Wait()
+}
+
+func (srv *gateServer) Connect(connection *network.TCPConnection, connected bool) {
+	if connected {
+		srv.mutex.Lock()
+		closed := srv.closed
+		if !closed {
+			srv.conns[connection] = struct{}{}
+			srv.wg.Add(1)
+		}
+		srv.mutex.Unlock()
+		if closed {
+			connection.Close()
+			return
+		}
+		log.Infof("game: gate %v connected", connection.RemoteAddr())
+		link := &gateLink{sessions: make(map[uint64]*service.Session)}
+		srv.game.loop.RunInLoop(func() {
+			srv.links[connection] = link
+		})
+		return
+	}
+	srv.mutex.Lock()
+	_, ok := srv.conns[connection]
+	delete(srv.conns, connection)
+	srv.mutex.Unlock()
+	if !ok {
+		return
+	}
+	log.Infof("game: gate %v disconnected", connection.RemoteAddr())
+	ok = srv.game.loop.RunInLoop(func() {
+		defer srv.wg.Done()
+		link, ok := srv.links[connection]
+		if !ok {
+			return
+		}
+		// gate断开, 所有会话都断开
+		for _, session := range link.sessions {
+			srv.game.disconnect(session, service.ReasonClosed)
+		}
+		delete(srv.links, connection)
+	})
+	if !ok {
+		srv.wg.Done()
+	}
+}
+
+func (srv *gateServer) Receive(connection *network.TCPConnection, buf []byte) {
+	packet := &service.BackendPacket{}
+	if err := packet.Unmarshal(buf); err != nil {
+		log.Warning("game: gate packet", err)
+		return
+	}
+	srv.game.loop.RunInLoop(func() {
+		link, ok := srv.links[connection]
+		if !ok {
+			return
+		}
+		srv.handlePacket(connection, link, packet)
+	})
+}
+
+func (srv *gateServer) handlePacket(connection *network.TCPConnection, link *gateLink, packet *service.BackendPacket) {
+	switch packet.Type {
+	case service.BackendConnect:
+		version, build, remoteAddr, err := packet.Connect()
+		if err != nil {
+			log.Warning("game: gate packet", err)
+			return
+		}
+		session := service.NewSession(packet.Session, version, build, remoteAddr, connection)
+		link.sessions[session.Id] = session
+		srv.game.connect(session)
+	case service.BackendDisconnect:
+		session, ok := link.sessions[packet.Session]
+		if !ok {
+			return
+		}
+		delete(link.sessions, packet.Session)
+		srv.game.disconnect(session, packet.Reason())
+	case service.BackendData:
+		session, ok := link.sessions[packet.Session]
+		if !ok {
+			return
+		}
+		ctx := service.NewSessionContext(context.Background(), session)
+		ctx = srv.game.players.context(ctx, session)
+		if err := srv.game.hub.Dispatch(ctx, packet.Cmd, packet.Payload); err != nil {
+			log.Warningf("game: session %v dispatch cmd %v error: %v", session.Id, packet.Cmd, err)
+		}
+	}
+}
+
+// 发送给所有gate上的客户端
+func (srv *gateServer) broadcast(cmd int16, message proto.Message) error {
+	buf, err := proto.Marshal(message)
+	if err != nil {
+		return err
+	}
+	packet := &service.BackendPacket{Type: service.BackendBroadcast, Cmd: cmd, Payload: buf}
+	b := packet.Marshal()
+	for connection := range srv.links {
+		connection.Send(b)
+	}
+	return nil
+}
+
+// 组成员由各个gate维护, 发送给所有gate
+func (srv *gateServer) groupcast(group uint64, cmd int16, message proto.Message) error {
+	buf, err := proto.Marshal(message)
+	if err != nil {
+		return err
+	}
+	packet := &service.BackendPacket{Type: service.BackendGroupcast, Session: group, Cmd: cmd, Payload: buf}
+	b := packet.Marshal()
+	for connection := range srv.links {
+		connection.Send(b)
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/iakud/plume/network"
+	"github.com/iakud/plumeserver/service"
+	"github.com/iakud/plumeserver/store"
+)
+
+// 本地的gate连接, 记录发送给会话的消息
+type testGate struct {
+	conn       net.Conn
+	connection *network.TCPConnection
+}
+
+type testGateHandler chan *network.TCPConnection
+
+func (h testGateHandler) Connect(connection *network.TCPConnection, connected bool) {
+	if connected {
+		h <- connection
+	}
+}
+
+func (h testGateHandler) Receive(connection *network.TCPConnection, buf []byte) {}
+
+func newTestGate(t *testing.T) *testGate {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	server := network.NewTCPServer(addr)
+	handler := make(testGateHandler, 1)
+	go server.ListenAndServe(handler, service.BackendCodec)
+	t.Cleanup(server.Close)
+
+	var conn net.Conn
+	for i := 0; ; i++ {
+		if conn, err = net.Dial("tcp", addr); err == nil {
+			break
+		}
+		if i == 50 {
+			t.Fatal(err)
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return &testGate{conn: conn, connection: <-handler}
+}
+
+func (g *testGate) session(id uint64) *service.Session {
+	return service.NewSession(id, 1, 0, "", g.connection)
+}
+
+// 作为game的gate连接, 用于广播和组播
+func (g *testGate) attach(game *GameApp) {
+	game.gate = &gateServer{
+		game:  game,
+		links: map[*network.TCPConnection]*gateLink{g.connection: {sessions: make(map[uint64]*service.Session)}},
+	}
+}
+
+func (g *testGate) read(t *testing.T) *service.BackendPacket {
+	t.Helper()
+	g.conn.SetReadDeadline(time.Now().Add(time.Second * 5))
+	buf, err := service.BackendCodec.Read(g.conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	packet := &service.BackendPacket{}
+	if err := packet.Unmarshal(buf); err != nil {
+		t.Fatal(err)
+	}
+	return packet
+}
+
+// 会话状态和分组的通知, 不是发给客户端的消息
+var controlPackets = map[uint8]bool{
+	service.BackendAccount:    true,
+	service.BackendState:      true,
+	service.BackendJoinGroup:  true,
+	service.BackendLeaveGroup: true,
+}
+
+// 读取下一个数据包, 跳过控制包, 检查命令并解析消息
+func (g *testGate) expect(t *testing.T, session uint64, cmd int16, message proto.Message) {
+	t.Helper()
+	packet := g.read(t)
+	for controlPackets[packet.Type] {
+		packet = g.read(t)
+	}
+	if packet.Type != service.BackendData || packet.Session != session || packet.Cmd != cmd {
+		t.Fatalf("unexpected packet type %v session %v cmd %v", packet.Type, packet.Session, packet.Cmd)
+	}
+	if err := proto.Unmarshal(packet.Payload, message); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// 在线玩家在关闭时登出并保存, gate断开的处理不会晚于模块停止
+func TestGameShutdown(t *testing.T) {
+	dir := t.TempDir()
+	s, err := store.OpenFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	game := newGameApp(s, testItems, &memoryAuditLog{}, &memoryGMAuditLog{})
+	game.loop = newTickLoop(time.Millisecond*10, time.Now())
+	game.timers = game.loop.timers
+	game.hub = service.NewMessageHub()
+	if err := game.modules.init(game); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	game.cancel = cancel
+	go game.Run(ctx)
+	runInLoop(game, func() {
+		if err := game.modules.start(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	game.gate = newGateServer(addr, game)
+	go game.gate.ListenAndServe()
+	var conn net.Conn
+	for i := 0; ; i++ {
+		if conn, err = net.Dial("tcp", addr); err == nil {
+			break
+		}
+		if i == 50 {
+			t.Fatal(err)
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+	defer conn.Close()
+	if err := service.BackendCodec.Write(conn, service.NewConnectPacket(1, 1, 0, "127.0.0.1").Marshal()); err != nil {
+		t.Fatal(err)
+	}
+	var session *service.Session
+	for i := 0; session == nil; i++ {
+		if i == 500 {
+			t.Fatal("session not connected")
+		}
+		time.Sleep(time.Millisecond * 10)
+		runInLoop(game, func() {
+			for _, link := range game.gate.links {
+				session = link.sessions[1]
+			}
+		})
+	}
+	login(t, game, game.players, session, 1001)
+
+	game.Shutdown()
+	if game.loop.RunInLoop(func() {}) {
+		t.Fatal("loop accepts functors after shutdown")
+	}
+	s, err = store.OpenFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	b, err := newStorePlayerStore(s).Load(1001)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := &PlayerData{}
+	if err := json.Unmarshal(b, data); err != nil {
+		t.Fatal(err)
+	}
+	if data.LogoutAt.IsZero() {
+		t.Fatalf("logout not saved %+v", data)
+	}
+}
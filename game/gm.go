@@ -0,0 +1,313 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/iakud/plume/log"
+	"github.com/iakud/plumeserver/filter"
+	"github.com/iakud/plumeserver/gm"
+	"github.com/iakud/plumeserver/service"
+	"github.com/iakud/plumeserver/service/pb"
+)
+
+const kCmdGM int16 = 1401
+
+// GM命令的结果
+const (
+	kGMOK = iota
+	kGMUnknown
+	kGMPermission
+	kGMArgs
+	kGMFailed
+)
+
+var (
+	ErrGMPlayer = errors.New("game: gm target player not loaded")
+	ErrGMConfig = errors.New("game: gm unknown config")
+	ErrGMLevel  = errors.New("game: gm invalid level")
+)
+
+var gmResults = map[error]int32{
+	gm.ErrUnknownCommand: kGMUnknown,
+	gm.ErrPermission:     kGMPermission,
+	gm.ErrArgs:           kGMArgs,
+	gm.ErrQuote:          kGMArgs,
+}
+
+// gm包的错误带有命令信息
+func gmResult(err error) int32 {
+	if err == nil {
+		return kGMOK
+	}
+	for e, result := range gmResults {
+		if errors.Is(err, e) {
+			return result
+		}
+	}
+	return kGMFailed
+}
+
+// GM命令模块, 所有命令都在逻辑线程执行
+type GMModule struct {
+	game     *GameApp
+	audit    gm.AuditLog
+	registry *gm.Registry
+}
+
+func NewGMModule(audit gm.AuditLog) *GMModule {
+	return &GMModule{audit: audit, registry: gm.NewRegistry(gm.WithAuditLog(audit))}
+}
+
+func (m *GMModule) Name() string {
+	return "gm"
+}
+
+func (m *GMModule) Depends() []string {
+	return []string{"player", "bag", "chat"}
+}
+
+func (m *GMModule) Init(game *GameApp) error {
+	m.game = game
+	game.players.OnLogin(m.login)
+	game.hub.Register(kCmdGM, m.handleGM)
+	commands := []*gm.Command{
+		{
+			Name:  "additem",
+			Level: gm.LevelDesigner,
+			Args:  []gm.Arg{{Name: "player", Type: gm.Uint}, {Name: "item", Type: gm.Int}, {Name: "count", Type: gm.Int, Optional: true}},
+			Help:  "grant items to a loaded player, negative count to consume",
+			Run:   m.addItem,
+		},
+		{
+			Name:  "setlevel",
+			Level: gm.LevelDesigner,
+			Args:  []gm.Arg{{Name: "player", Type: gm.Uint}, {Name: "level", Type: gm.Int}},
+			Help:  "set the level of a loaded player",
+			Run:   m.setLevel,
+		},
+		{
+			Name:  "teleport",
+			Level: gm.LevelDesigner,
+			Args:  []gm.Arg{{Name: "player", Type: gm.Uint}, {Name: "scene", Type: gm.Uint}, {Name: "x", Type: gm.Float, Optional: true}, {Name: "z", Type: gm.Float, Optional: true}},
+			Help:  "move a loaded player to a scene position",
+			Run:   m.teleport,
+		},
+		{
+			Name:  "mute",
+			Level: gm.LevelSupport,
+			Args:  []gm.Arg{{Name: "player", Type: gm.Uint}, {Name: "duration", Type: gm.Duration}, {Name: "channel", Type: gm.Int, Optional: true}},
+			Help:  "mute a player in a chat channel, all channels by default, 0s to unmute",
+			Run:   m.mute,
+		},
+		{
+			Name:  "setgm",
+			Level: gm.LevelAdmin,
+			Args:  []gm.Arg{{Name: "player", Type: gm.Uint}, {Name: "level", Type: gm.Int}},
+			Help:  "set the gm level of a loaded player, 0 to revoke",
+			Run:   m.setGM,
+		},
+		{
+			Name:  "reload",
+			Level: gm.LevelAdmin,
+			Args:  []gm.Arg{{Name: "config", Type: gm.String}},
+			Help:  "reload config files: items, words",
+			Run:   m.reload,
+		},
+	}
+	for _, c := range commands {
+		if err := m.registry.Register(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *GMModule) Start() error {
+	return nil
+}
+
+func (m *GMModule) Stop() {
+	if err := m.audit.Close(); err != nil {
+		log.Errorf("game: gm audit close error: %v", err)
+	}
+}
+
+// 其他模块在Init中注册自己的命令
+func (m *GMModule) Register(c *gm.Command) error {
+	return m.registry.Register(c)
+}
+
+// 只在逻辑线程调用
+func (m *GMModule) Execute(operator string, level gm.Level, line string) (string, error) {
+	return m.registry.Execute(operator, level, line)
+}
+
+// 在其他协程调用, 在逻辑线程执行并等待结果
+func (m *GMModule) ExecuteWait(operator string, level gm.Level, line string) (string, error) {
+	var output string
+	var err error
+	done := make(chan struct{})
+	ok := m.game.loop.RunInLoop(func() {
+		output, err = m.Execute(operator, level, line)
+		close(done)
+	})
+	if !ok {
+		return "", ErrGameStopped
+	}
+	select {
+	case <-done:
+		return output, err
+	case <-m.game.loop.Done():
+	}
+	// 停止前可能已经执行完成
+	select {
+	case <-done:
+		return output, err
+	default:
+		return "", ErrGameStopped
+	}
+}
+
+func (m *GMModule) login(p *Player) {
+	if p.Data.GM > gm.LevelPlayer {
+		p.Session.SetState(service.StateGM)
+	}
+}
+
+func (m *GMModule) player(c *gm.Context) (*Player, error) {
+	p := m.game.players.Player(c.Uint("player"))
+	if p == nil {
+		return nil, fmt.Errorf("%w: %v", ErrGMPlayer, c.Uint("player"))
+	}
+	return p, nil
+}
+
+func (m *GMModule) addItem(c *gm.Context) (string, error) {
+	p, err := m.player(c)
+	if err != nil {
+		return "", err
+	}
+	item, count := int32(c.Int("item")), c.Int("count")
+	if !c.Has("count") {
+		count = 1
+	}
+	tx := m.game.bag.Begin(p, "gm:"+c.Operator)
+	if count < 0 {
+		tx.Consume(item, -count)
+	} else {
+		tx.Grant(item, count)
+	}
+	if err := tx.Commit(); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("player %v item %v count %v", p.Id, item, p.Data.Bag.Count(item)), nil
+}
+
+func (m *GMModule) setLevel(c *gm.Context) (string, error) {
+	p, err := m.player(c)
+	if err != nil {
+		return "", err
+	}
+	p.Data.Level = int32(c.Int("level"))
+	p.MarkDirty()
+	return fmt.Sprintf("player %v level %v", p.Id, p.Data.Level), nil
+}
+
+func (m *GMModule) teleport(c *gm.Context) (string, error) {
+	p, err := m.player(c)
+	if err != nil {
+		return "", err
+	}
+	m.game.players.SetPosition(p, Position{Scene: c.Uint("scene"), X: float32(c.Float("x")), Z: float32(c.Float("z"))})
+	return fmt.Sprintf("player %v position %+v", p.Id, p.Data.Position), nil
+}
+
+func (m *GMModule) mute(c *gm.Context) (string, error) {
+	channel := ChannelAll
+	if c.Has("channel") {
+		channel = int32(c.Int("channel"))
+	}
+	if err := m.game.chat.Mute(c.Uint("player"), channel, c.Duration("duration")); err != nil {
+		return "", err
+	}
+	until, muted := m.game.chat.Muted(c.Uint("player"), channel)
+	if !muted {
+		return fmt.Sprintf("player %v channel %v unmuted", c.Uint("player"), channel), nil
+	}
+	return fmt.Sprintf("player %v channel %v muted until %v", c.Uint("player"), channel, until.Format("2006-01-02 15:04:05")), nil
+}
+
+// 不能授予比自己高的等级
+func (m *GMModule) setGM(c *gm.Context) (string, error) {
+	p, err := m.player(c)
+	if err != nil {
+		return "", err
+	}
+	level := gm.Level(c.Int("level"))
+	if level < gm.LevelPlayer || level > c.Level {
+		return "", fmt.Errorf("%w: %v", ErrGMLevel, level)
+	}
+	p.Data.GM = level
+	p.MarkDirty()
+	if p.Online() {
+		state := service.StatePlayer
+		if level > gm.LevelPlayer {
+			state = service.StateGM
+		}
+		p.Session.SetState(state)
+	}
+	return fmt.Sprintf("player %v gm level %v", p.Id, level), nil
+}
+
+// 在逻辑线程读取配置文件, 只用于少量的GM操作
+func (m *GMModule) reload(c *gm.Context) (string, error) {
+	switch config := c.String("config"); config {
+	case "items":
+		if *itemFile == "" {
+			return "", fmt.Errorf("%w: item file not set", ErrGMConfig)
+		}
+		items, err := LoadItems(*itemFile)
+		if err != nil {
+			return "", err
+		}
+		m.game.bag.SetItems(items)
+		return strconv.Itoa(len(items)) + " items reloaded", nil
+	case "words":
+		if *chatWords == "" {
+			return "", fmt.Errorf("%w: chat words file not set", ErrGMConfig)
+		}
+		words, err := filter.Load(*chatWords)
+		if err != nil {
+			return "", err
+		}
+		m.game.chat.SetFilter(words)
+		return "chat words reloaded", nil
+	default:
+		return "", fmt.Errorf("%w: %v", ErrGMConfig, config)
+	}
+}
+
+// 只有GM玩家可以发送, gate也会按会话状态过滤
+func (m *GMModule) handleGM(ctx context.Context, cmd int16, req *pb.GMRequest) {
+	p, ok := FromPlayerContext(ctx)
+	if !ok {
+		return
+	}
+	resp := &pb.GMResponse{}
+	if p.Data.GM <= gm.LevelPlayer {
+		resp.Result = proto.Int32(kGMPermission)
+		p.Session.Send(cmd, resp)
+		return
+	}
+	output, err := m.Execute("player:"+strconv.FormatUint(p.Id, 10), p.Data.GM, req.GetLine())
+	resp.Result = proto.Int32(gmResult(err))
+	if err != nil {
+		output = err.Error()
+	}
+	resp.Output = proto.String(output)
+	p.Session.Send(cmd, resp)
+}
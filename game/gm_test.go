@@ -0,0 +1,286 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/iakud/plumeserver/gm"
+	"github.com/iakud/plumeserver/service"
+	"github.com/iakud/plumeserver/service/pb"
+	"github.com/iakud/plumeserver/store"
+)
+
+type memoryGMAuditLog struct {
+	records []*gm.Record
+}
+
+func (a *memoryGMAuditLog) Write(record *gm.Record) {
+	a.records = append(a.records, record)
+}
+
+func (a *memoryGMAuditLog) Close() error {
+	return nil
+}
+
+func newTestGM(t *testing.T) (*GameApp, *memoryGMAuditLog) {
+	s, err := store.OpenFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	game := newTestGame(t)
+	audit := &memoryGMAuditLog{}
+	game.players = NewPlayerManager(newStorePlayerStore(s), time.Hour, time.Hour)
+	game.bag = NewBagModule(testItems, 10, &memoryAuditLog{})
+	game.guild = NewGuildModule(s)
+	game.chat = NewChatModule(s)
+	game.gm = NewGMModule(audit)
+	for _, m := range []Module{game.players, game.bag, game.guild, game.chat, game.gm} {
+		game.Register(m)
+	}
+	if err := game.modules.init(game); err != nil {
+		t.Fatal(err)
+	}
+	runInLoop(game, func() {
+		if err := game.modules.start(); err != nil {
+			t.Error(err)
+		}
+	})
+	t.Cleanup(func() { runInLoop(game, game.modules.stop) })
+	return game, audit
+}
+
+func TestGMCommands(t *testing.T) {
+	game, audit := newTestGM(t)
+	gate := newTestGate(t)
+	p := login(t, game, game.players, gate.session(1), 1001)
+	execute := func(line string) string {
+		t.Helper()
+		output, err := game.gm.ExecuteWait("tester", gm.LevelAdmin, line)
+		if err != nil {
+			t.Fatalf("execute %q: %v", line, err)
+		}
+		return output
+	}
+
+	execute("additem 1001 1 15")
+	execute("additem 1001 1 -5")
+	execute("additem 1001 2")
+	execute("setlevel 1001 30")
+	execute("teleport 1001 7 1.5 -2")
+	runInLoop(game, func() {
+		if p.Data.Bag.Count(kItemOre) != 10 || p.Data.Bag.Count(kItemSword) != 1 || p.Data.Level != 30 {
+			t.Errorf("unexpected player %+v", p.Data)
+		}
+		if p.Data.Position != (Position{Scene: 7, X: 1.5, Z: -2}) || game.chat.scenes[p.Id] != 7 {
+			t.Errorf("unexpected position %+v", p.Data.Position)
+		}
+	})
+	// 禁言不需要玩家在线
+	execute("mute 1002 10m")
+	execute("mute 1001 1h 1")
+	runInLoop(game, func() {
+		if _, muted := game.chat.Muted(1002, ChannelScene); !muted {
+			t.Error("player 1002 not muted")
+		}
+		if _, muted := game.chat.Muted(1001, ChannelGuild); muted {
+			t.Error("player 1001 muted in guild channel")
+		}
+	})
+	execute("mute 1002 0s")
+
+	failures := []struct {
+		line string
+		err  error
+	}{
+		{"additem 1002 1 1", ErrGMPlayer},
+		{"additem 1001 99 1", ErrItemUnknown},
+		{"setgm 1001 9", ErrGMLevel},
+		{"reload rank", ErrGMConfig},
+		{"mute 1001 1h 99", ErrChatChannel},
+		{"teleport 1001", gm.ErrArgs},
+	}
+	for _, failure := range failures {
+		if _, err := game.gm.ExecuteWait("tester", gm.LevelAdmin, failure.line); !errors.Is(err, failure.err) {
+			t.Errorf("execute %q: unexpected error %v, expected %v", failure.line, err, failure.err)
+		}
+	}
+	if len(audit.records) != 8+len(failures) || audit.records[0].Line != "additem 1001 1 15" {
+		t.Fatalf("unexpected audit records %v", len(audit.records))
+	}
+}
+
+func TestGMReload(t *testing.T) {
+	game, _ := newTestGM(t)
+	dir := t.TempDir()
+	items, words := filepath.Join(dir, "items.json"), filepath.Join(dir, "words.txt")
+	data, _ := json.Marshal([]*ItemConfig{{Id: 10, Name: "gem", MaxStack: 99}})
+	os.WriteFile(items, data, 0644)
+	os.WriteFile(words, []byte("bad\n"), 0644)
+	defer func(items, words string) { *itemFile, *chatWords = items, words }(*itemFile, *chatWords)
+
+	*itemFile, *chatWords = "", ""
+	if _, err := game.gm.ExecuteWait("tester", gm.LevelAdmin, "reload items"); !errors.Is(err, ErrGMConfig) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	*itemFile, *chatWords = items, words
+	if output, err := game.gm.ExecuteWait("tester", gm.LevelAdmin, "reload items"); err != nil || output != "1 items reloaded" {
+		t.Fatalf("unexpected result %q %v", output, err)
+	}
+	if _, err := game.gm.ExecuteWait("tester", gm.LevelAdmin, "reload words"); err != nil {
+		t.Fatal(err)
+	}
+	runInLoop(game, func() {
+		if game.bag.Item(10) == nil || game.bag.Item(kItemOre) != nil {
+			t.Error("items not reloaded")
+		}
+		if game.chat.opts.filter == nil || !game.chat.opts.filter.Contains("so bad") {
+			t.Error("words not reloaded")
+		}
+	})
+}
+
+// 投递后逻辑线程停止, 消息被丢弃时不会一直等待
+func TestGMExecuteStopped(t *testing.T) {
+	game := newTestGame(t)
+	m := NewGMModule(&memoryGMAuditLog{})
+	m.game = game
+	running, block := make(chan struct{}), make(chan struct{})
+	game.loop.RunInLoop(func() {
+		close(running)
+		<-block
+		game.loop.Stop()
+	})
+	<-running
+	result := make(chan error, 1)
+	go func() {
+		_, err := m.ExecuteWait("tester", gm.LevelAdmin, "help")
+		result <- err
+	}()
+	for queued := 0; queued == 0; time.Sleep(time.Millisecond) {
+		game.loop.mutex.Lock()
+		queued = len(game.loop.functors)
+		game.loop.mutex.Unlock()
+	}
+	close(block)
+	select {
+	case err := <-result:
+		if err != ErrGameStopped {
+			t.Fatalf("unexpected error %v", err)
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatal("execute blocked after stop")
+	}
+	if _, err := m.ExecuteWait("tester", gm.LevelAdmin, "help"); err != ErrGameStopped {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+// 读取下一个会话状态包
+func readState(t *testing.T, gate *testGate) uint8 {
+	t.Helper()
+	for {
+		if packet := gate.read(t); packet.Type == service.BackendState {
+			return packet.Payload[0]
+		}
+	}
+}
+
+func TestGMHandler(t *testing.T) {
+	game, _ := newTestGM(t)
+	gate := newTestGate(t)
+	session := gate.session(1)
+	login(t, game, game.players, session, 1001)
+	resp := &pb.GMResponse{}
+	send := func(line string) {
+		t.Helper()
+		dispatch(t, game, session, kCmdGM, &pb.GMRequest{Line: proto.String(line)})
+		if err := proto.Unmarshal(readCmd(t, gate, kCmdGM).Payload, resp); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 普通玩家不能执行
+	send("help")
+	if resp.GetResult() != kGMPermission {
+		t.Fatalf("unexpected result %v", resp.GetResult())
+	}
+	if _, err := game.gm.ExecuteWait("tester", gm.LevelAdmin, "setgm 1001 2"); err != nil {
+		t.Fatal(err)
+	}
+	if state := readState(t, gate); state != service.StateGM {
+		t.Fatalf("unexpected state %v", state)
+	}
+	send("setlevel 1001 5")
+	if resp.GetResult() != kGMOK || resp.GetOutput() != "player 1001 level 5" {
+		t.Fatalf("unexpected response %v", resp)
+	}
+	send("setgm 1001 3")
+	if resp.GetResult() != kGMPermission {
+		t.Fatalf("unexpected response %v", resp)
+	}
+	send("setlevel 1001")
+	if resp.GetResult() != kGMArgs || !strings.Contains(resp.GetOutput(), "usage") {
+		t.Fatalf("unexpected response %v", resp)
+	}
+
+	// 重新登录时先切换为玩家状态, 再恢复GM状态
+	login(t, game, game.players, gate.session(2), 1001)
+	if state := readState(t, gate); state != service.StatePlayer {
+		t.Fatalf("unexpected state %v", state)
+	}
+	if state := readState(t, gate); state != service.StateGM {
+		t.Fatalf("unexpected state %v", state)
+	}
+}
+
+func TestGMAdmin(t *testing.T) {
+	game, audit := newTestGM(t)
+	gate := newTestGate(t)
+	login(t, game, game.players, gate.session(1), 1001)
+	const token = "secret"
+	admin := httptest.NewServer(newAdminServer(game, token).Handler())
+	defer admin.Close()
+	post := func(token, line string) (int, map[string]string) {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodPost, admin.URL+"/gm?"+url.Values{"line": {line}, "operator": {"ops"}}.Encode(), nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		result := make(map[string]string)
+		json.NewDecoder(resp.Body).Decode(&result)
+		return resp.StatusCode, result
+	}
+
+	if code, _ := post("wrong", "setlevel 1001 9"); code != http.StatusUnauthorized {
+		t.Fatalf("unexpected status %v", code)
+	}
+	if code, result := post(token, "setlevel 1001 9"); code != http.StatusOK || result["output"] != "player 1001 level 9" {
+		t.Fatalf("unexpected response %v %v", code, result)
+	}
+	if code, result := post(token, "setlevel 1002 9"); code != http.StatusBadRequest || result["error"] == "" {
+		t.Fatalf("unexpected response %v %v", code, result)
+	}
+	if operator := audit.records[len(audit.records)-1].Operator; operator != "http:ops" {
+		t.Fatalf("unexpected operator %v", operator)
+	}
+
+	var out bytes.Buffer
+	runConsole(game, strings.NewReader("setlevel 1001 12\n\nsetlevel\n"), &out)
+	if lines := strings.Split(strings.TrimSpace(out.String()), "\n"); len(lines) != 2 || lines[0] != "player 1001 level 12" || !strings.HasPrefix(lines[1], "error:") {
+		t.Fatalf("unexpected console output %q", out.String())
+	}
+}
@@ -0,0 +1,726 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/iakud/plume/log"
+	"github.com/iakud/plumeserver/service/pb"
+	"github.com/iakud/plumeserver/store"
+	"github.com/iakud/plumeserver/timer"
+)
+
+const (
+	kCmdGuildCreate  int16 = 1201
+	kCmdGuildInfo    int16 = 1202
+	kCmdGuildApply   int16 = 1203
+	kCmdGuildApprove int16 = 1204
+	kCmdGuildInvite  int16 = 1205
+	kCmdGuildAccept  int16 = 1206
+	kCmdGuildLeave   int16 = 1207
+	kCmdGuildKick    int16 = 1208
+	kCmdGuildSetRole int16 = 1209
+	kCmdGuildNotice  int16 = 1210
+	kCmdGuildDisband int16 = 1211
+
+	kCmdGuildInviteNotify int16 = 1220
+	kCmdGuildNotify       int16 = 1221
+)
+
+// 公会操作的结果
+const (
+	kGuildOK = iota
+	kGuildNotFound
+	kGuildName
+	kGuildNameExists
+	kGuildInGuild
+	kGuildNotInGuild
+	kGuildFull
+	kGuildPermission
+	kGuildRole
+	kGuildRequest
+	kGuildInvite
+	kGuildLeader
+	kGuildText
+	kGuildFailed
+	kGuildInviteFull
+)
+
+const (
+	kMaxGuildMembers  = 50
+	kMaxGuildRequests = 50
+	kMaxGuildInvites  = 50 // 每个公会未处理的邀请
+	kMaxGuildName     = 16 // 字符数
+	kMaxGuildText     = 256
+	kGuildInviteTTL   = time.Minute * 10
+
+	kGuildGroup    uint64 = 1 << 56 // 公会聊天的组id前缀
+	kGuildIndexKey        = "guild/index"
+)
+
+// 职位, 数值越大权限越高
+const (
+	RoleMember int32 = iota
+	RoleElite
+	RoleOfficer
+	RoleLeader
+)
+
+// 权限
+const (
+	PermInvite = 1 << iota
+	PermApprove
+	PermKick
+	PermSetRole
+	PermNotice
+	PermDisband
+)
+
+var rolePerms = map[int32]uint32{
+	RoleMember:  0,
+	RoleElite:   PermInvite,
+	RoleOfficer: PermInvite | PermApprove | PermKick | PermSetRole | PermNotice,
+	RoleLeader:  PermInvite | PermApprove | PermKick | PermSetRole | PermNotice | PermDisband,
+}
+
+var (
+	ErrGuildNotFound   = errors.New("game: guild not found")
+	ErrGuildName       = errors.New("game: invalid guild name")
+	ErrGuildNameExists = errors.New("game: guild name exists")
+	ErrInGuild         = errors.New("game: already in guild")
+	ErrNotInGuild      = errors.New("game: not in guild")
+	ErrGuildFull       = errors.New("game: guild full")
+	ErrGuildPermission = errors.New("game: guild permission denied")
+	ErrGuildRole       = errors.New("game: invalid guild role")
+	ErrGuildRequest    = errors.New("game: guild request not found")
+	ErrGuildInvite     = errors.New("game: guild invite not found")
+	ErrGuildLeader     = errors.New("game: guild leader can not leave")
+	ErrGuildText       = errors.New("game: invalid guild text")
+	ErrGuildInviteFull = errors.New("game: too many guild invites")
+)
+
+var guildResults = map[error]int32{
+	ErrGuildNotFound:   kGuildNotFound,
+	ErrGuildName:       kGuildName,
+	ErrGuildNameExists: kGuildNameExists,
+	ErrInGuild:         kGuildInGuild,
+	ErrNotInGuild:      kGuildNotInGuild,
+	ErrGuildFull:       kGuildFull,
+	ErrGuildPermission: kGuildPermission,
+	ErrGuildRole:       kGuildRole,
+	ErrGuildRequest:    kGuildRequest,
+	ErrGuildInvite:     kGuildInvite,
+	ErrGuildLeader:     kGuildLeader,
+	ErrGuildText:       kGuildText,
+	ErrGuildInviteFull: kGuildInviteFull,
+}
+
+func guildResult(err error) int32 {
+	if err == nil {
+		return kGuildOK
+	}
+	if result, ok := guildResults[err]; ok {
+		return result
+	}
+	return kGuildFailed
+}
+
+type GuildMember struct {
+	Id     uint64    `json:"id"`
+	Name   string    `json:"name"`
+	Role   int32     `json:"role"`
+	JoinAt time.Time `json:"join_at"` // 申请时为申请时间
+}
+
+func (m *GuildMember) proto() *pb.GuildMember {
+	return &pb.GuildMember{
+		Id:       proto.Uint64(m.Id),
+		Name:     proto.String(m.Name),
+		Role:     proto.Int32(m.Role),
+		JoinTime: proto.Int64(m.JoinAt.Unix()),
+	}
+}
+
+type Guild struct {
+	Id        uint64                  `json:"id"`
+	Name      string                  `json:"name"`
+	Notice    string                  `json:"notice"`
+	Leader    uint64                  `json:"leader"`
+	Members   map[uint64]*GuildMember `json:"members"`
+	Requests  map[uint64]*GuildMember `json:"requests"` // 加入申请
+	CreatedAt time.Time               `json:"created_at"`
+}
+
+func (g *Guild) Group() uint64 {
+	return kGuildGroup | g.Id
+}
+
+func (g *Guild) proto() *pb.GuildInfo {
+	info := &pb.GuildInfo{
+		Id:     proto.Uint64(g.Id),
+		Name:   proto.String(g.Name),
+		Notice: proto.String(g.Notice),
+	}
+	for _, m := range g.Members {
+		info.Members = append(info.Members, m.proto())
+	}
+	for _, r := range g.Requests {
+		info.Requests = append(info.Requests, r.proto())
+	}
+	// 按职位和加入时间排序
+	sort.Slice(info.Members, func(i, j int) bool {
+		a, b := info.Members[i], info.Members[j]
+		if a.GetRole() != b.GetRole() {
+			return a.GetRole() > b.GetRole()
+		}
+		return a.GetJoinTime() < b.GetJoinTime()
+	})
+	sort.Slice(info.Requests, func(i, j int) bool {
+		return info.Requests[i].GetJoinTime() < info.Requests[j].GetJoinTime()
+	})
+	return info
+}
+
+type guildIndex struct {
+	NextId uint64   `json:"next_id"`
+	Guilds []uint64 `json:"guilds"`
+}
+
+// 公会模块, 每个公会保存为一个key, 公会列表保存在索引中
+type GuildModule struct {
+	game  *GameApp
+	store store.Store
+
+	nextId  uint64
+	guilds  map[uint64]*Guild
+	names   map[string]*Guild
+	members map[uint64]*Guild               // 玩家所在的公会
+	invites map[uint64]map[uint64]time.Time // 公会发出的邀请, 不保存
+
+	saves  chan []store.Op
+	wg     sync.WaitGroup
+	ticker *timer.Timer
+}
+
+func NewGuildModule(s store.Store) *GuildModule {
+	return &GuildModule{store: s}
+}
+
+func (m *GuildModule) Name() string {
+	return "guild"
+}
+
+func (m *GuildModule) Depends() []string {
+	return []string{"player"}
+}
+
+func (m *GuildModule) Init(game *GameApp) error {
+	m.game = game
+	game.players.OnLogin(m.login)
+	game.players.OnLogout(m.logout)
+	game.hub.Register(kCmdGuildCreate, m.handleCreate)
+	game.hub.Register(kCmdGuildInfo, m.handleInfo)
+	game.hub.Register(kCmdGuildApply, m.handleApply)
+	game.hub.Register(kCmdGuildApprove, m.handleApprove)
+	game.hub.Register(kCmdGuildInvite, m.handleInvite)
+	game.hub.Register(kCmdGuildAccept, m.handleAccept)
+	game.hub.Register(kCmdGuildLeave, m.handleLeave)
+	game.hub.Register(kCmdGuildKick, m.handleKick)
+	game.hub.Register(kCmdGuildSetRole, m.handleSetRole)
+	game.hub.Register(kCmdGuildNotice, m.handleNotice)
+	game.hub.Register(kCmdGuildDisband, m.handleDisband)
+	return nil
+}
+
+func guildKey(id uint64) string {
+	return "guild/" + strconv.FormatUint(id, 10)
+}
+
+// 启动时加载所有公会
+func (m *GuildModule) Start() error {
+	m.nextId = 1
+	m.guilds = make(map[uint64]*Guild)
+	m.names = make(map[string]*Guild)
+	m.members = make(map[uint64]*Guild)
+	m.invites = make(map[uint64]map[uint64]time.Time)
+	data, _, err := m.store.Get(kGuildIndexKey)
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+	case err != nil:
+		return err
+	default:
+		var index guildIndex
+		if err := json.Unmarshal(data, &index); err != nil {
+			return err
+		}
+		m.nextId = index.NextId
+		for _, id := range index.Guilds {
+			data, _, err := m.store.Get(guildKey(id))
+			if err != nil {
+				return err
+			}
+			g := &Guild{}
+			if err := json.Unmarshal(data, g); err != nil {
+				return err
+			}
+			m.add(g)
+		}
+	}
+	m.saves = make(chan []store.Op, 64)
+	m.wg.Add(1)
+	go m.saveLoop()
+	m.ticker = m.game.timers.EveryFunc(time.Minute, m.expire)
+	return nil
+}
+
+func (m *GuildModule) Stop() {
+	m.ticker.Stop()
+	close(m.saves)
+	m.wg.Wait()
+}
+
+func (m *GuildModule) saveLoop() {
+	defer m.wg.Done()
+	for ops := range m.saves {
+		if _, err := m.store.Batch(ops); err != nil {
+			log.Errorf("game: guild save error: %v", err)
+		}
+	}
+}
+
+func (m *GuildModule) indexOp() store.Op {
+	index := guildIndex{NextId: m.nextId}
+	for id := range m.guilds {
+		index.Guilds = append(index.Guilds, id)
+	}
+	data, _ := json.Marshal(&index)
+	return store.Op{Key: kGuildIndexKey, Value: data, Version: store.AnyVersion}
+}
+
+func (m *GuildModule) guildOp(g *Guild) store.Op {
+	data, _ := json.Marshal(g)
+	return store.Op{Key: guildKey(g.Id), Value: data, Version: store.AnyVersion}
+}
+
+func (m *GuildModule) save(g *Guild) {
+	m.saves <- []store.Op{m.guildOp(g)}
+}
+
+func (m *GuildModule) add(g *Guild) {
+	m.guilds[g.Id] = g
+	m.names[g.Name] = g
+	for id := range g.Members {
+		m.members[id] = g
+	}
+}
+
+func (m *GuildModule) Guild(id uint64) *Guild {
+	return m.guilds[id]
+}
+
+// 玩家所在的公会
+func (m *GuildModule) PlayerGuild(playerId uint64) *Guild {
+	return m.members[playerId]
+}
+
+func (m *GuildModule) login(p *Player) {
+	if g, ok := m.members[p.Id]; ok {
+		p.Session.JoinGroup(g.Group())
+	}
+}
+
+// 会话可能继续登录其他玩家
+func (m *GuildModule) logout(p *Player) {
+	if g, ok := m.members[p.Id]; ok {
+		p.Session.LeaveGroup(g.Group())
+	}
+}
+
+// 推送给在线玩家
+func (m *GuildModule) send(playerId uint64, cmd int16, message proto.Message) {
+	if p := m.game.players.Player(playerId); p != nil && p.Online() {
+		p.Session.Send(cmd, message)
+	}
+}
+
+// 加入公会, 删除在其他公会的申请
+func (m *GuildModule) join(g *Guild, member *GuildMember) {
+	member.Role = RoleMember
+	member.JoinAt = time.Now()
+	g.Members[member.Id] = member
+	m.members[member.Id] = g
+	m.clearInvites(member.Id)
+	for _, other := range m.guilds {
+		if _, ok := other.Requests[member.Id]; ok {
+			delete(other.Requests, member.Id)
+			if other != g {
+				m.save(other)
+			}
+		}
+	}
+	m.save(g)
+	if p := m.game.players.Player(member.Id); p != nil && p.Online() {
+		p.Session.JoinGroup(g.Group())
+		p.Session.Send(kCmdGuildNotify, &pb.GuildNotify{Guild: g.proto()})
+	}
+}
+
+func (m *GuildModule) remove(g *Guild, playerId uint64) {
+	delete(g.Members, playerId)
+	delete(m.members, playerId)
+	if p := m.game.players.Player(playerId); p != nil && p.Online() {
+		p.Session.LeaveGroup(g.Group())
+		p.Session.Send(kCmdGuildNotify, &pb.GuildNotify{})
+	}
+}
+
+// 操作者所在的公会和成员信息, 检查权限
+func (m *GuildModule) actor(p *Player, perm uint32) (*Guild, *GuildMember, error) {
+	g, ok := m.members[p.Id]
+	if !ok {
+		return nil, nil, ErrNotInGuild
+	}
+	member := g.Members[p.Id]
+	if rolePerms[member.Role]&perm != perm {
+		return nil, nil, ErrGuildPermission
+	}
+	return g, member, nil
+}
+
+func (m *GuildModule) Create(p *Player, name string) (*Guild, error) {
+	if _, ok := m.members[p.Id]; ok {
+		return nil, ErrInGuild
+	}
+	if n := utf8.RuneCountInString(name); n == 0 || n > kMaxGuildName || !utf8.ValidString(name) {
+		return nil, ErrGuildName
+	}
+	if _, ok := m.names[name]; ok {
+		return nil, ErrGuildNameExists
+	}
+	now := time.Now()
+	g := &Guild{
+		Id:        m.nextId,
+		Name:      name,
+		Leader:    p.Id,
+		Members:   make(map[uint64]*GuildMember),
+		Requests:  make(map[uint64]*GuildMember),
+		CreatedAt: now,
+	}
+	m.nextId++
+	m.add(g)
+	g.Members[p.Id] = &GuildMember{Id: p.Id, Name: p.Data.Name, Role: RoleLeader, JoinAt: now}
+	m.members[p.Id] = g
+	m.clearInvites(p.Id)
+	// 公会和索引一起写入
+	m.saves <- []store.Op{m.guildOp(g), m.indexOp()}
+	if p.Online() {
+		p.Session.JoinGroup(g.Group())
+	}
+	return g, nil
+}
+
+// 申请加入, 由有审批权限的成员处理
+func (m *GuildModule) Apply(p *Player, guildId uint64) error {
+	if _, ok := m.members[p.Id]; ok {
+		return ErrInGuild
+	}
+	g, ok := m.guilds[guildId]
+	if !ok {
+		return ErrGuildNotFound
+	}
+	if _, ok := g.Requests[p.Id]; ok {
+		return nil
+	}
+	if len(g.Requests) >= kMaxGuildRequests {
+		return ErrGuildFull
+	}
+	g.Requests[p.Id] = &GuildMember{Id: p.Id, Name: p.Data.Name, JoinAt: time.Now()}
+	m.save(g)
+	return nil
+}
+
+func (m *GuildModule) Approve(p *Player, playerId uint64, accept bool) error {
+	g, _, err := m.actor(p, PermApprove)
+	if err != nil {
+		return err
+	}
+	request, ok := g.Requests[playerId]
+	if !ok {
+		return ErrGuildRequest
+	}
+	if !accept {
+		delete(g.Requests, playerId)
+		m.save(g)
+		return nil
+	}
+	if _, ok := m.members[playerId]; ok {
+		// 已经加入其他公会, 申请在加入时已经删除
+		return ErrInGuild
+	}
+	if len(g.Members) >= kMaxGuildMembers {
+		return ErrGuildFull
+	}
+	m.join(g, request)
+	return nil
+}
+
+// 邀请只保存在内存中, 一段时间后过期
+func (m *GuildModule) Invite(p *Player, playerId uint64) error {
+	g, _, err := m.actor(p, PermInvite)
+	if err != nil {
+		return err
+	}
+	if _, ok := m.members[playerId]; ok {
+		return ErrInGuild
+	}
+	now := time.Now()
+	invites, ok := m.invites[g.Id]
+	if !ok {
+		invites = make(map[uint64]time.Time)
+		m.invites[g.Id] = invites
+	}
+	if _, ok := invites[playerId]; !ok && len(invites) >= kMaxGuildInvites {
+		expireInvites(invites, now)
+		if len(invites) >= kMaxGuildInvites {
+			return ErrGuildInviteFull
+		}
+	}
+	invites[playerId] = now.Add(kGuildInviteTTL)
+	m.send(playerId, kCmdGuildInviteNotify, &pb.GuildInviteNotify{
+		GuildId:   proto.Uint64(g.Id),
+		GuildName: proto.String(g.Name),
+		Inviter:   proto.Uint64(p.Id),
+	})
+	return nil
+}
+
+func (m *GuildModule) Accept(p *Player, guildId uint64) error {
+	if _, ok := m.members[p.Id]; ok {
+		return ErrInGuild
+	}
+	expire, ok := m.invites[guildId][p.Id]
+	if !ok || !time.Now().Before(expire) {
+		return ErrGuildInvite
+	}
+	g, ok := m.guilds[guildId]
+	if !ok {
+		return ErrGuildNotFound
+	}
+	if len(g.Members) >= kMaxGuildMembers {
+		return ErrGuildFull
+	}
+	m.join(g, &GuildMember{Id: p.Id, Name: p.Data.Name})
+	return nil
+}
+
+// 会长需要先转让, 只剩会长时解散
+func (m *GuildModule) Leave(p *Player) error {
+	g, member, err := m.actor(p, 0)
+	if err != nil {
+		return err
+	}
+	if member.Role == RoleLeader {
+		if len(g.Members) > 1 {
+			return ErrGuildLeader
+		}
+		m.disband(g)
+		return nil
+	}
+	m.remove(g, p.Id)
+	m.save(g)
+	return nil
+}
+
+// 只能踢出职位更低的成员
+func (m *GuildModule) Kick(p *Player, playerId uint64) error {
+	g, actor, err := m.actor(p, PermKick)
+	if err != nil {
+		return err
+	}
+	target, ok := g.Members[playerId]
+	if !ok {
+		return ErrNotInGuild
+	}
+	if target.Role >= actor.Role {
+		return ErrGuildPermission
+	}
+	m.remove(g, playerId)
+	m.save(g)
+	return nil
+}
+
+// 只能任命比自己低的职位, 会长任命会长为转让
+func (m *GuildModule) SetRole(p *Player, playerId uint64, role int32) error {
+	g, actor, err := m.actor(p, PermSetRole)
+	if err != nil {
+		return err
+	}
+	if _, ok := rolePerms[role]; !ok || playerId == p.Id {
+		return ErrGuildRole
+	}
+	target, ok := g.Members[playerId]
+	if !ok {
+		return ErrNotInGuild
+	}
+	if target.Role >= actor.Role {
+		return ErrGuildPermission
+	}
+	switch {
+	case role == RoleLeader && actor.Role == RoleLeader:
+		actor.Role = RoleOfficer
+		g.Leader = playerId
+	case role >= actor.Role:
+		return ErrGuildPermission
+	}
+	target.Role = role
+	m.save(g)
+	return nil
+}
+
+func (m *GuildModule) SetNotice(p *Player, notice string) error {
+	g, _, err := m.actor(p, PermNotice)
+	if err != nil {
+		return err
+	}
+	if len(notice) > kMaxGuildText || !utf8.ValidString(notice) {
+		return ErrGuildText
+	}
+	g.Notice = notice
+	m.save(g)
+	return nil
+}
+
+func (m *GuildModule) Disband(p *Player) error {
+	g, _, err := m.actor(p, PermDisband)
+	if err != nil {
+		return err
+	}
+	m.disband(g)
+	return nil
+}
+
+func (m *GuildModule) disband(g *Guild) {
+	for id := range g.Members {
+		m.remove(g, id)
+	}
+	delete(m.guilds, g.Id)
+	delete(m.names, g.Name)
+	delete(m.invites, g.Id)
+	m.saves <- []store.Op{{Key: guildKey(g.Id), Version: store.AnyVersion, Delete: true}, m.indexOp()}
+	log.Infof("game: guild %v %v disbanded", g.Id, g.Name)
+}
+
+// 加入公会后其他公会的邀请失效
+func (m *GuildModule) clearInvites(playerId uint64) {
+	for guildId, invites := range m.invites {
+		delete(invites, playerId)
+		if len(invites) == 0 {
+			delete(m.invites, guildId)
+		}
+	}
+}
+
+func expireInvites(invites map[uint64]time.Time, now time.Time) {
+	for playerId, expire := range invites {
+		if !now.Before(expire) {
+			delete(invites, playerId)
+		}
+	}
+}
+
+// 定时清理过期的邀请, 对方不处理的邀请不会一直留在内存中
+func (m *GuildModule) expire() {
+	now := time.Now()
+	for guildId, invites := range m.invites {
+		expireInvites(invites, now)
+		if len(invites) == 0 {
+			delete(m.invites, guildId)
+		}
+	}
+}
+
+// 回复结果和操作后所在的公会
+func (m *GuildModule) reply(p *Player, cmd int16, err error) {
+	resp := &pb.GuildResponse{Result: proto.Int32(guildResult(err))}
+	if err != nil {
+		log.Debugf("game: player %v guild cmd %v error: %v", p.Id, cmd, err)
+	}
+	if g, ok := m.members[p.Id]; ok {
+		resp.Guild = g.proto()
+	}
+	p.Session.Send(cmd, resp)
+}
+
+func (m *GuildModule) handleCreate(ctx context.Context, cmd int16, req *pb.GuildCreateRequest) {
+	if p, ok := FromPlayerContext(ctx); ok {
+		_, err := m.Create(p, req.GetName())
+		m.reply(p, cmd, err)
+	}
+}
+
+func (m *GuildModule) handleInfo(ctx context.Context, cmd int16, req *pb.GuildInfoRequest) {
+	if p, ok := FromPlayerContext(ctx); ok {
+		m.reply(p, cmd, nil)
+	}
+}
+
+func (m *GuildModule) handleApply(ctx context.Context, cmd int16, req *pb.GuildApplyRequest) {
+	if p, ok := FromPlayerContext(ctx); ok {
+		m.reply(p, cmd, m.Apply(p, req.GetGuildId()))
+	}
+}
+
+func (m *GuildModule) handleApprove(ctx context.Context, cmd int16, req *pb.GuildApproveRequest) {
+	if p, ok := FromPlayerContext(ctx); ok {
+		m.reply(p, cmd, m.Approve(p, req.GetPlayerId(), req.GetAccept()))
+	}
+}
+
+func (m *GuildModule) handleInvite(ctx context.Context, cmd int16, req *pb.GuildInviteRequest) {
+	if p, ok := FromPlayerContext(ctx); ok {
+		m.reply(p, cmd, m.Invite(p, req.GetPlayerId()))
+	}
+}
+
+func (m *GuildModule) handleAccept(ctx context.Context, cmd int16, req *pb.GuildAcceptRequest) {
+	if p, ok := FromPlayerContext(ctx); ok {
+		m.reply(p, cmd, m.Accept(p, req.GetGuildId()))
+	}
+}
+
+func (m *GuildModule) handleLeave(ctx context.Context, cmd int16, req *pb.GuildLeaveRequest) {
+	if p, ok := FromPlayerContext(ctx); ok {
+		m.reply(p, cmd, m.Leave(p))
+	}
+}
+
+func (m *GuildModule) handleKick(ctx context.Context, cmd int16, req *pb.GuildKickRequest) {
+	if p, ok := FromPlayerContext(ctx); ok {
+		m.reply(p, cmd, m.Kick(p, req.GetPlayerId()))
+	}
+}
+
+func (m *GuildModule) handleSetRole(ctx context.Context, cmd int16, req *pb.GuildSetRoleRequest) {
+	if p, ok := FromPlayerContext(ctx); ok {
+		m.reply(p, cmd, m.SetRole(p, req.GetPlayerId(), req.GetRole()))
+	}
+}
+
+func (m *GuildModule) handleNotice(ctx context.Context, cmd int16, req *pb.GuildNoticeRequest) {
+	if p, ok := FromPlayerContext(ctx); ok {
+		m.reply(p, cmd, m.SetNotice(p, req.GetNotice()))
+	}
+}
+
+func (m *GuildModule) handleDisband(ctx context.Context, cmd int16, req *pb.GuildDisbandRequest) {
+	if p, ok := FromPlayerContext(ctx); ok {
+		m.reply(p, cmd, m.Disband(p))
+	}
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/iakud/plumeserver/service"
+	"github.com/iakud/plumeserver/service/pb"
+	"github.com/iakud/plumeserver/store"
+)
+
+func newTestGuild(t *testing.T, s store.Store) *GameApp {
+	game := newTestGame(t)
+	game.players = NewPlayerManager(newStorePlayerStore(s), time.Hour, time.Hour)
+	game.guild = NewGuildModule(s)
+	game.Register(game.players)
+	game.Register(game.guild)
+	if err := game.modules.init(game); err != nil {
+		t.Fatal(err)
+	}
+	runInLoop(game, func() {
+		if err := game.modules.start(); err != nil {
+			t.Error(err)
+		}
+	})
+	return game
+}
+
+func TestGuildRoles(t *testing.T) {
+	s, err := store.OpenFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	game := newTestGuild(t, s)
+	gate := newTestGate(t)
+	p1 := login(t, game, game.players, gate.session(1), 1001)
+	p2 := login(t, game, game.players, gate.session(2), 1002)
+	p3 := login(t, game, game.players, gate.session(3), 1003)
+	m := game.guild
+	check := func(err, expect error) {
+		t.Helper()
+		if !errors.Is(err, expect) {
+			t.Errorf("unexpected error %v, expected %v", err, expect)
+		}
+	}
+	runInLoop(game, func() {
+		g, err := m.Create(p1, "dragon")
+		check(err, nil)
+		_, err = m.Create(p2, "dragon")
+		check(err, ErrGuildNameExists)
+		_, err = m.Create(p2, "")
+		check(err, ErrGuildName)
+		_, err = m.Create(p1, "tiger")
+		check(err, ErrInGuild)
+
+		// 申请和审批
+		check(m.Apply(p2, g.Id), nil)
+		check(m.Approve(p2, 1002, true), ErrNotInGuild)
+		check(m.Approve(p1, 1003, true), ErrGuildRequest)
+		check(m.Approve(p1, 1002, true), nil)
+		if m.PlayerGuild(1002) != g || len(g.Requests) != 0 {
+			t.Error("member not joined")
+		}
+
+		// 邀请需要权限
+		check(m.Invite(p2, 1003), ErrGuildPermission)
+		check(m.SetRole(p1, 1002, RoleOfficer), nil)
+		check(m.Accept(p3, g.Id), ErrGuildInvite)
+		check(m.Invite(p2, 1003), nil)
+		check(m.Accept(p3, g.Id), nil)
+
+		// 只能任命和踢出职位更低的成员
+		check(m.SetRole(p2, 1003, RoleOfficer), ErrGuildPermission)
+		check(m.SetRole(p2, 1003, RoleElite), nil)
+		check(m.SetRole(p2, 1003, 99), ErrGuildRole)
+		check(m.Kick(p3, 1002), ErrGuildPermission)
+		check(m.Kick(p2, 1001), ErrGuildPermission)
+		check(m.Kick(p2, 1003), nil)
+		if m.PlayerGuild(1003) != nil || len(g.Members) != 2 {
+			t.Error("member not kicked")
+		}
+		check(m.SetNotice(p2, "hello"), nil)
+
+		// 会长转让后才能离开, 最后一个成员离开时解散
+		check(m.Leave(p1), ErrGuildLeader)
+		check(m.SetRole(p1, 1002, RoleLeader), nil)
+		if g.Leader != 1002 || g.Members[1001].Role != RoleOfficer {
+			t.Errorf("unexpected leader %v", g.Leader)
+		}
+		check(m.Leave(p1), nil)
+		check(m.Disband(p3), ErrNotInGuild)
+		check(m.Leave(p2), nil)
+		if m.Guild(g.Id) != nil || m.PlayerGuild(1002) != nil {
+			t.Error("guild not disbanded")
+		}
+		_, err = m.Create(p3, "dragon")
+		check(err, nil)
+	})
+	runInLoop(game, game.modules.stop)
+}
+
+func TestGuildPersist(t *testing.T) {
+	s, err := store.OpenFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	game := newTestGuild(t, s)
+	gate := newTestGate(t)
+	p1 := login(t, game, game.players, gate.session(1), 1001)
+	p2 := login(t, game, game.players, gate.session(2), 1002)
+	p3 := login(t, game, game.players, gate.session(3), 1003)
+	var dragon, tiger uint64
+	runInLoop(game, func() {
+		g, _ := game.guild.Create(p1, "dragon")
+		dragon = g.Id
+		game.guild.Apply(p2, g.Id)
+		game.guild.Approve(p1, 1002, true)
+		game.guild.SetNotice(p1, "welcome")
+		g, _ = game.guild.Create(p3, "tiger")
+		tiger = g.Id
+	})
+	runInLoop(game, game.modules.stop)
+
+	game = newTestGuild(t, s)
+	p3 = login(t, game, game.players, gate.session(4), 1003)
+	runInLoop(game, func() {
+		g := game.guild.Guild(dragon)
+		if g == nil || g.Notice != "welcome" || len(g.Members) != 2 || game.guild.PlayerGuild(1002) != g {
+			t.Errorf("unexpected guild %v", g)
+		}
+		if err := game.guild.Disband(p3); err != nil {
+			t.Error(err)
+		}
+	})
+	runInLoop(game, game.modules.stop)
+
+	game = newTestGuild(t, s)
+	runInLoop(game, func() {
+		if game.guild.Guild(tiger) != nil || game.guild.Guild(dragon) == nil {
+			t.Error("unexpected guilds")
+		}
+		// 新公会的id不重复
+		g, _ := game.guild.Create(&Player{Id: 1004, Data: &PlayerData{}}, "lion")
+		if g.Id <= tiger {
+			t.Errorf("unexpected guild id %v", g.Id)
+		}
+	})
+	runInLoop(game, game.modules.stop)
+}
+
+func TestGuildInvites(t *testing.T) {
+	s, err := store.OpenFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	game := newTestGuild(t, s)
+	gate := newTestGate(t)
+	p1 := login(t, game, game.players, gate.session(1), 1001)
+	p2 := login(t, game, game.players, gate.session(2), 1002)
+	p3 := login(t, game, game.players, gate.session(3), 1003)
+	m := game.guild
+	runInLoop(game, func() {
+		g, _ := m.Create(p1, "dragon")
+		tiger, _ := m.Create(p3, "tiger")
+		for i := 0; i < kMaxGuildInvites; i++ {
+			if err := m.Invite(p1, uint64(2000+i)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		// 达到上限后不能邀请新的玩家, 重复邀请只刷新过期时间
+		if err := m.Invite(p1, 1002); !errors.Is(err, ErrGuildInviteFull) {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if err := m.Invite(p1, 2000); err != nil {
+			t.Fatal(err)
+		}
+
+		// 过期的邀请被清理, 空出位置
+		for id := range m.invites[g.Id] {
+			m.invites[g.Id][id] = time.Now()
+		}
+		m.expire()
+		if len(m.invites) != 0 {
+			t.Fatalf("unexpected invites %v", m.invites)
+		}
+		if err := m.Invite(p1, 1002); err != nil {
+			t.Fatal(err)
+		}
+		if err := m.Invite(p3, 1002); err != nil {
+			t.Fatal(err)
+		}
+
+		// 加入公会后其他公会的邀请失效
+		if err := m.Accept(p2, tiger.Id); err != nil {
+			t.Fatal(err)
+		}
+		if len(m.invites) != 0 {
+			t.Fatalf("unexpected invites %v", m.invites)
+		}
+	})
+	runInLoop(game, game.modules.stop)
+}
+
+// 跳过组操作等其他数据包
+func readCmd(t *testing.T, gate *testGate, cmd int16) *service.BackendPacket {
+	t.Helper()
+	for {
+		if packet := gate.read(t); packet.Cmd == cmd && (packet.Type == service.BackendData || packet.Type == service.BackendBroadcast || packet.Type == service.BackendGroupcast) {
+			return packet
+		}
+	}
+}
+
+func TestGuildCommands(t *testing.T) {
+	s, err := store.OpenFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	game := newTestGuild(t, s)
+	gate := newTestGate(t)
+	gate.attach(game)
+	session := gate.session(1)
+	p := login(t, game, game.players, session, 1001)
+
+	dispatch(t, game, session, kCmdGuildCreate, &pb.GuildCreateRequest{Name: proto.String("dragon")})
+	packet := readCmd(t, gate, kCmdGuildCreate)
+	resp := &pb.GuildResponse{}
+	proto.Unmarshal(packet.Payload, resp)
+	if resp.GetResult() != kGuildOK || resp.Guild.GetName() != "dragon" || len(resp.Guild.Members) != 1 {
+		t.Fatalf("unexpected response %v", resp)
+	}
+	dispatch(t, game, session, kCmdGuildNotice, &pb.GuildNoticeRequest{Notice: proto.String(strings.Repeat("a", kMaxGuildText+1))})
+	packet = readCmd(t, gate, kCmdGuildNotice)
+	proto.Unmarshal(packet.Payload, resp)
+	if resp.GetResult() != kGuildText {
+		t.Fatalf("unexpected result %v", resp.GetResult())
+	}
+	runInLoop(game, func() {
+		if g := game.guild.PlayerGuild(p.Id); g == nil || g.Notice != "" {
+			t.Errorf("unexpected guild %v", g)
+		}
+	})
+	runInLoop(game, game.modules.stop)
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/iakud/plume/log"
+	"github.com/iakud/plumeserver/timer"
+)
+
+var ErrGameStopped = errors.New("game: stopped")
+
+const kMaxCatchUp = 5 // 落后时最多连续追赶的帧数
+
+// 每帧的阶段, 先推进定时器, 保证处理消息时定时器的时间与逻辑时间一致
+const (
+	phaseTimers  = iota // 执行到期的定时器
+	phaseInbound        // 处理投递到逻辑线程的消息
+	phaseSystems        // 执行注册的系统
+	phaseCount
+)
+
+var phaseNames = [phaseCount]string{"timers", "inbound", "systems"}
+
+type PhaseStats struct {
+	Name  string        `json:"name"`
+	Total time.Duration `json:"total"`
+	Max   time.Duration `json:"max"`
+}
+
+type TickStats struct {
+	Ticks    uint64                 `json:"ticks"`
+	Overruns uint64                 `json:"overruns"` // 超过帧间隔的帧数
+	Dropped  uint64                 `json:"dropped"`  // 追赶不上丢弃的帧数
+	Max      time.Duration          `json:"max"`
+	Phases   [phaseCount]PhaseStats `json:"phases"`
+}
+
+type system struct {
+	name string
+	f    func(now time.Time, dt time.Duration)
+}
+
+// 逻辑线程的固定帧循环, 逻辑时间按帧间隔推进, 与帧的实际执行时间无关
+type tickLoop struct {
+	interval time.Duration
+	timers   *timer.Wheel
+
+	mutex    sync.Mutex
+	functors []func()
+	stopped  bool
+	done     chan struct{} // 停止时关闭, 未执行的消息不会再执行
+
+	// 以下只在逻辑线程访问
+	start   time.Time
+	tick    uint64
+	systems []system
+	stats   TickStats
+}
+
+func newTickLoop(interval time.Duration, start time.Time) *tickLoop {
+	l := &tickLoop{
+		interval: interval,
+		timers:   timer.NewWheel(interval, start),
+		done:     make(chan struct{}),
+		start:    start,
+	}
+	for i := range l.stats.Phases {
+		l.stats.Phases[i].Name = phaseNames[i]
+	}
+	return l
+}
+
+// 投递到逻辑线程, 在下一帧开始时执行, 停止后丢弃并返回false
+func (l *tickLoop) RunInLoop(f func()) bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.stopped {
+		return false
+	}
+	l.functors = append(l.functors, f)
+	return true
+}
+
+// 只在逻辑线程调用, 之后不再执行投递的消息、定时器和系统, Run返回
+func (l *tickLoop) Stop() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if !l.stopped {
+		l.stopped = true
+		close(l.done)
+	}
+}
+
+// 等待投递的消息时同时等待停止, 停止后丢弃的消息不会执行
+func (l *tickLoop) Done() <-chan struct{} {
+	return l.done
+}
+
+// 注册每帧执行的系统, 按注册顺序执行, 只在逻辑线程调用
+func (l *tickLoop) AddSystem(name string, f func(now time.Time, dt time.Duration)) {
+	l.systems = append(l.systems, system{name, f})
+}
+
+// 当前帧的逻辑时间
+func (l *tickLoop) Now() time.Time {
+	return l.start.Add(time.Duration(l.tick) * l.interval)
+}
+
+// 只在逻辑线程调用
+func (l *tickLoop) Stats() TickStats {
+	return l.stats
+}
+
+func (l *tickLoop) drain() {
+	l.mutex.Lock()
+	functors := l.functors
+	l.functors = nil
+	l.mutex.Unlock()
+	for i, f := range functors {
+		// stopped只在逻辑线程修改
+		if l.stopped {
+			log.Warningf("game: loop stopped, dropped %v functors", len(functors)-i)
+			return
+		}
+		f()
+	}
+}
+
+func (l *tickLoop) Run(ctx context.Context) {
+	t := time.NewTimer(l.interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			l.Stop()
+			return
+		case <-t.C:
+		}
+		// 落后时连续执行多帧追赶, 落后太多丢弃
+		behind := int(time.Since(l.start)/l.interval) - int(l.tick)
+		if behind > kMaxCatchUp {
+			dropped := behind - kMaxCatchUp
+			l.stats.Dropped += uint64(dropped)
+			l.start = l.start.Add(time.Duration(dropped) * l.interval)
+			log.Warningf("game: loop behind %v ticks, dropped %v", behind, dropped)
+			behind = kMaxCatchUp
+		}
+		for i := 0; i < behind && !l.stopped; i++ {
+			l.step()
+		}
+		if l.stopped {
+			return
+		}
+		t.Reset(time.Until(l.Now().Add(l.interval)))
+	}
+}
+
+func (l *tickLoop) step() {
+	l.tick++
+	now := l.Now()
+	begin := time.Now()
+	var phases [phaseCount]time.Duration
+	mark := begin
+	phase := func(i int) {
+		end := time.Now()
+		phases[i] = end.Sub(mark)
+		mark = end
+	}
+
+	l.timers.Advance(now)
+	phase(phaseTimers)
+	l.drain()
+	phase(phaseInbound)
+	if l.stopped {
+		return
+	}
+	for _, s := range l.systems {
+		s.f(now, l.interval)
+	}
+	phase(phaseSystems)
+
+	elapsed := mark.Sub(begin)
+	l.stats.Ticks++
+	l.stats.Max = max(l.stats.Max, elapsed)
+	for i := range phases {
+		l.stats.Phases[i].Total += phases[i]
+		l.stats.Phases[i].Max = max(l.stats.Phases[i].Max, phases[i])
+	}
+	if elapsed > l.interval {
+		l.stats.Overruns++
+		log.Warningf("game: tick %v overrun %v, timers %v, inbound %v, systems %v",
+			l.tick, elapsed, phases[phaseTimers], phases[phaseInbound], phases[phaseSystems])
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTickLoop(t *testing.T) {
+	start := time.Now()
+	loop := newTickLoop(time.Millisecond*10, start)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		loop.Run(ctx)
+		close(done)
+	}()
+
+	var ticks []time.Time
+	var fired time.Time
+	result := make(chan TickStats, 1)
+	loop.RunInLoop(func() {
+		loop.timers.AfterFunc(time.Millisecond*30, func() {
+			fired = loop.Now()
+		})
+		loop.AddSystem("record", func(now time.Time, dt time.Duration) {
+			if dt != time.Millisecond*10 {
+				t.Errorf("unexpected dt %v", dt)
+			}
+			ticks = append(ticks, now)
+			switch len(ticks) {
+			case 5:
+				// 超过帧间隔
+				time.Sleep(time.Millisecond * 15)
+			case 10:
+				result <- loop.Stats()
+			}
+		})
+	})
+	var stats TickStats
+	select {
+	case stats = <-result:
+	case <-time.After(time.Second * 5):
+		t.Fatal("tick timeout")
+	}
+	cancel()
+	<-done
+
+	// 逻辑时间按固定间隔推进
+	for i := 1; i < len(ticks); i++ {
+		if ticks[i].Sub(ticks[i-1]) != time.Millisecond*10 {
+			t.Fatalf("unexpected tick %v -> %v", ticks[i-1], ticks[i])
+		}
+	}
+	if fired.IsZero() || fired.Sub(ticks[0]) != time.Millisecond*30 {
+		t.Fatalf("unexpected timer fired at %v", fired.Sub(ticks[0]))
+	}
+	if stats.Overruns == 0 || stats.Phases[phaseSystems].Max < time.Millisecond*15 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
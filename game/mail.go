@@ -0,0 +1,525 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/iakud/plume/log"
+	"github.com/iakud/plumeserver/service/pb"
+	"github.com/iakud/plumeserver/store"
+	"github.com/iakud/plumeserver/timer"
+)
+
+const (
+	kCmdMailList   int16 = 1001
+	kCmdMailRead   int16 = 1002
+	kCmdMailClaim  int16 = 1003
+	kCmdMailDelete int16 = 1004
+	kCmdMailNotify int16 = 1005
+)
+
+// 邮件操作的结果
+const (
+	kMailOK = iota
+	kMailNotFound
+	kMailExpired
+	kMailClaimed
+	kMailNoItems
+	kMailUnclaimed
+	kMailClaimFailed
+)
+
+const kMaxMails = 100 // 每个玩家最多保留的邮件, 超出时删除最早的已读邮件, 仍然放不下的继续待发
+
+const kMailStateKey = "mail/state"
+
+var (
+	ErrMailNotFound   = errors.New("game: mail not found")
+	ErrMailExpired    = errors.New("game: mail expired")
+	ErrMailClaimed    = errors.New("game: mail claimed")
+	ErrMailNoItems    = errors.New("game: mail has no items")
+	ErrMailUnclaimed  = errors.New("game: mail has unclaimed items")
+	ErrNoItemReceiver = errors.New("game: no item receiver")
+)
+
+var mailResults = map[error]int32{
+	ErrMailNotFound:  kMailNotFound,
+	ErrMailExpired:   kMailExpired,
+	ErrMailClaimed:   kMailClaimed,
+	ErrMailNoItems:   kMailNoItems,
+	ErrMailUnclaimed: kMailUnclaimed,
+}
+
+func mailResult(err error) int32 {
+	if err == nil {
+		return kMailOK
+	}
+	if result, ok := mailResults[err]; ok {
+		return result
+	}
+	return kMailClaimFailed
+}
+
+type MailItem struct {
+	Id    int32 `json:"id"`
+	Count int32 `json:"count"`
+}
+
+type Mail struct {
+	Id       uint64     `json:"id"`
+	Title    string     `json:"title"`
+	Content  string     `json:"content"`
+	Items    []MailItem `json:"items,omitempty"`
+	SendAt   time.Time  `json:"send_at"`
+	ExpireAt time.Time  `json:"expire_at"` // 为零时不过期
+	Read     bool       `json:"read"`
+	Claimed  bool       `json:"claimed"`
+}
+
+func (m *Mail) Expired(now time.Time) bool {
+	return !m.ExpireAt.IsZero() && !now.Before(m.ExpireAt)
+}
+
+func (m *Mail) clone() *Mail {
+	mail := *m
+	mail.Items = append([]MailItem(nil), m.Items...)
+	return &mail
+}
+
+func (m *Mail) proto() *pb.Mail {
+	mail := &pb.Mail{
+		Id:       proto.Uint64(m.Id),
+		Title:    proto.String(m.Title),
+		Content:  proto.String(m.Content),
+		SendTime: proto.Int64(m.SendAt.Unix()),
+		Read:     proto.Bool(m.Read),
+		Claimed:  proto.Bool(m.Claimed),
+	}
+	if !m.ExpireAt.IsZero() {
+		mail.ExpireTime = proto.Int64(m.ExpireAt.Unix())
+	}
+	mail.Items = mailItems(m.Items)
+	return mail
+}
+
+func mailItems(items []MailItem) []*pb.MailItem {
+	var pbItems []*pb.MailItem
+	for _, item := range items {
+		pbItems = append(pbItems, &pb.MailItem{Id: proto.Int32(item.Id), Count: proto.Int32(item.Count)})
+	}
+	return pbItems
+}
+
+// 玩家的邮箱, 保存在玩家数据中
+type MailBox struct {
+	Mails     []*Mail `json:"mails"`
+	Broadcast uint64  `json:"broadcast"` // 已经收到的全服邮件id
+	Received  uint64  `json:"received"`  // 已经收到的待发邮件id
+}
+
+func (mb *MailBox) find(id uint64) *Mail {
+	for _, mail := range mb.Mails {
+		if mail.Id == id {
+			return mail
+		}
+	}
+	return nil
+}
+
+func (mb *MailBox) remove(id uint64) {
+	for i, mail := range mb.Mails {
+		if mail.Id == id {
+			mb.Mails = append(mb.Mails[:i], mb.Mails[i+1:]...)
+			return
+		}
+	}
+}
+
+// 没有未领取附件的已读邮件可以被新邮件挤出
+func (m *Mail) evictable() bool {
+	return m.Read && (len(m.Items) == 0 || m.Claimed)
+}
+
+// 邮箱已满时按从早到晚删除可以挤出的邮件, 返回可以放入的数量
+func (mb *MailBox) room(n int) int {
+	if over := len(mb.Mails) + n - kMaxMails; over > 0 {
+		mails := mb.Mails[:0]
+		for _, mail := range mb.Mails {
+			if over > 0 && mail.evictable() {
+				over--
+				continue
+			}
+			mails = append(mails, mail)
+		}
+		clear(mb.Mails[len(mails):])
+		mb.Mails = mails
+	}
+	return max(min(n, kMaxMails-len(mb.Mails)), 0)
+}
+
+// 删除过期的邮件, 返回是否有删除
+func (mb *MailBox) expire(now time.Time) bool {
+	mails := mb.Mails[:0]
+	for _, mail := range mb.Mails {
+		if !mail.Expired(now) {
+			mails = append(mails, mail)
+		}
+	}
+	clear(mb.Mails[len(mails):])
+	expired := len(mails) != len(mb.Mails)
+	mb.Mails = mails
+	return expired
+}
+
+// 全服邮件和未加载玩家的待发邮件
+type mailState struct {
+	NextId     uint64             `json:"next_id"`
+	Broadcasts []*Mail            `json:"broadcasts"`
+	Pending    map[uint64][]*Mail `json:"pending"`
+}
+
+// 邮件模块, 全服邮件在玩家登录时才发放到邮箱
+type MailModule struct {
+	game  *GameApp
+	store store.Store
+	grant func(p *Player, items []MailItem) error
+
+	state  mailState
+	ticker *timer.Timer
+
+	saves chan []byte
+	wg    sync.WaitGroup
+}
+
+func NewMailModule(s store.Store) *MailModule {
+	return &MailModule{store: s}
+}
+
+func (m *MailModule) Name() string {
+	return "mail"
+}
+
+func (m *MailModule) Depends() []string {
+	return []string{"player"}
+}
+
+func (m *MailModule) Init(game *GameApp) error {
+	m.game = game
+	game.players.OnLogin(m.login)
+	game.hub.Register(kCmdMailList, m.handleList)
+	game.hub.Register(kCmdMailRead, m.handleRead)
+	game.hub.Register(kCmdMailClaim, m.handleClaim)
+	game.hub.Register(kCmdMailDelete, m.handleDelete)
+	return nil
+}
+
+func (m *MailModule) Start() error {
+	m.state = mailState{NextId: 1, Pending: make(map[uint64][]*Mail)}
+	data, _, err := m.store.Get(kMailStateKey)
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+	case err != nil:
+		return err
+	default:
+		if err := json.Unmarshal(data, &m.state); err != nil {
+			return err
+		}
+		if m.state.Pending == nil {
+			m.state.Pending = make(map[uint64][]*Mail)
+		}
+	}
+	m.saves = make(chan []byte, 64)
+	m.wg.Add(1)
+	go m.saveLoop()
+	m.ticker = m.game.timers.EveryFunc(time.Minute, m.expire)
+	return nil
+}
+
+func (m *MailModule) Stop() {
+	m.ticker.Stop()
+	close(m.saves)
+	m.wg.Wait()
+}
+
+// 领取附件时发放物品, 返回错误时领取失败
+func (m *MailModule) SetGrant(grant func(p *Player, items []MailItem) error) {
+	m.grant = grant
+}
+
+func (m *MailModule) saveLoop() {
+	defer m.wg.Done()
+	for data := range m.saves {
+		if _, err := m.store.Put(kMailStateKey, data, store.AnyVersion); err != nil {
+			log.Errorf("game: mail state save error: %v", err)
+		}
+	}
+}
+
+func (m *MailModule) save() {
+	data, err := json.Marshal(&m.state)
+	if err != nil {
+		log.Errorf("game: mail state marshal error: %v", err)
+		return
+	}
+	m.saves <- data
+}
+
+func (m *MailModule) nextId() uint64 {
+	id := m.state.NextId
+	m.state.NextId++
+	return id
+}
+
+// 发送给玩家, 先加入待发邮件, 玩家已加载时立即放入邮箱, 否则在下次登录时收到
+func (m *MailModule) Send(playerId uint64, mail *Mail) uint64 {
+	mail.Id = m.nextId()
+	mail.SendAt = time.Now()
+	m.state.Pending[playerId] = append(m.state.Pending[playerId], mail)
+	m.save()
+	if p := m.game.players.Player(playerId); p != nil {
+		m.receive(p)
+	}
+	return mail.Id
+}
+
+// 全服邮件, 在线玩家立即收到, 其他玩家在登录时收到
+// 发送之后创建的玩家不会收到
+func (m *MailModule) Broadcast(mail *Mail) uint64 {
+	mail.Id = m.nextId()
+	mail.SendAt = time.Now()
+	m.state.Broadcasts = append(m.state.Broadcasts, mail)
+	m.save()
+	m.game.players.RangeOnline(m.receive)
+	return mail.Id
+}
+
+func (m *MailModule) login(p *Player) {
+	m.receive(p)
+}
+
+// 放入待发邮件和没有收到的全服邮件, 邮箱放不下时保留到下次
+func (m *MailModule) receive(p *Player) {
+	mb := &p.Data.Mail
+	var mails []*Mail
+	var waiting bool
+	for _, mail := range m.state.Pending[p.Id] {
+		if mail.Id <= mb.Received {
+			waiting = true
+			continue
+		}
+		mails = append(mails, mail.clone())
+	}
+	if n := m.deliver(p, mails); n > 0 {
+		mb.Received = mails[n-1].Id
+		waiting = true
+	} else if len(mails) > 0 {
+		log.Warningf("game: player %v mailbox full, %v mails pending", p.Id, len(mails))
+	}
+	// 玩家数据保存之后才删除待发邮件, 中途崩溃时按Received跳过已经收到的
+	if waiting {
+		received := mb.Received
+		m.game.players.Save(p, func(err error) {
+			if err == nil {
+				m.received(p.Id, received)
+			}
+		})
+	}
+
+	now := time.Now()
+	for _, mail := range m.state.Broadcasts {
+		if mail.Id <= mb.Broadcast {
+			continue
+		}
+		if !mail.Expired(now) && !mail.SendAt.Before(p.Data.CreatedAt) && m.deliver(p, []*Mail{mail.clone()}) == 0 {
+			break
+		}
+		mb.Broadcast = mail.Id
+		p.MarkDirty()
+	}
+}
+
+// 删除玩家已经保存的待发邮件
+func (m *MailModule) received(playerId uint64, id uint64) {
+	pending := m.state.Pending[playerId]
+	i := 0
+	for i < len(pending) && pending[i].Id <= id {
+		i++
+	}
+	if i == 0 {
+		return
+	}
+	if i == len(pending) {
+		delete(m.state.Pending, playerId)
+	} else {
+		m.state.Pending[playerId] = pending[i:]
+	}
+	m.save()
+}
+
+// 返回放入邮箱的数量, 按顺序放入mails的前面部分
+func (m *MailModule) deliver(p *Player, mails []*Mail) int {
+	mb := &p.Data.Mail
+	if mb.expire(time.Now()) {
+		p.MarkDirty()
+	}
+	n := mb.room(len(mails))
+	if n == 0 {
+		return 0
+	}
+	mb.Mails = append(mb.Mails, mails[:n]...)
+	p.MarkDirty()
+	if !p.Online() {
+		return n
+	}
+	notify := &pb.MailNotify{}
+	for _, mail := range mails[:n] {
+		notify.Mails = append(notify.Mails, mail.proto())
+	}
+	p.Session.Send(kCmdMailNotify, notify)
+	return n
+}
+
+// 定期清理过期的全服邮件和待发邮件
+func (m *MailModule) expire() {
+	now := time.Now()
+	changed := false
+	broadcasts := m.state.Broadcasts[:0]
+	for _, mail := range m.state.Broadcasts {
+		if !mail.Expired(now) {
+			broadcasts = append(broadcasts, mail)
+		}
+	}
+	if len(broadcasts) != len(m.state.Broadcasts) {
+		clear(m.state.Broadcasts[len(broadcasts):])
+		m.state.Broadcasts = broadcasts
+		changed = true
+	}
+	for id, pending := range m.state.Pending {
+		mb := MailBox{Mails: pending}
+		if !mb.expire(now) {
+			continue
+		}
+		changed = true
+		if len(mb.Mails) == 0 {
+			delete(m.state.Pending, id)
+		} else {
+			m.state.Pending[id] = mb.Mails
+		}
+	}
+	if changed {
+		m.save()
+	}
+}
+
+// 过期的邮件不能读取和领取
+func (m *MailModule) mail(p *Player, id uint64) (*Mail, error) {
+	mail := p.Data.Mail.find(id)
+	if mail == nil {
+		return nil, ErrMailNotFound
+	}
+	if mail.Expired(time.Now()) {
+		return nil, ErrMailExpired
+	}
+	return mail, nil
+}
+
+func (m *MailModule) Read(p *Player, id uint64) error {
+	mail, err := m.mail(p, id)
+	if err != nil {
+		return err
+	}
+	if !mail.Read {
+		mail.Read = true
+		p.MarkDirty()
+	}
+	return nil
+}
+
+// 领取附件, 每封邮件只能领取一次
+func (m *MailModule) Claim(p *Player, id uint64) ([]MailItem, error) {
+	mail, err := m.mail(p, id)
+	if err != nil {
+		return nil, err
+	}
+	if mail.Claimed {
+		return nil, ErrMailClaimed
+	}
+	if len(mail.Items) == 0 {
+		return nil, ErrMailNoItems
+	}
+	if m.grant == nil {
+		return nil, ErrNoItemReceiver
+	}
+	if err := m.grant(p, mail.Items); err != nil {
+		return nil, err
+	}
+	mail.Claimed = true
+	mail.Read = true
+	p.MarkDirty()
+	m.receive(p)
+	return mail.Items, nil
+}
+
+// 有未领取附件的邮件不能删除
+func (m *MailModule) Delete(p *Player, id uint64) error {
+	mail := p.Data.Mail.find(id)
+	if mail == nil {
+		return ErrMailNotFound
+	}
+	if len(mail.Items) > 0 && !mail.Claimed && !mail.Expired(time.Now()) {
+		return ErrMailUnclaimed
+	}
+	p.Data.Mail.remove(id)
+	p.MarkDirty()
+	m.receive(p)
+	return nil
+}
+
+func (m *MailModule) handleList(ctx context.Context, cmd int16, req *pb.MailListRequest) {
+	p, ok := FromPlayerContext(ctx)
+	if !ok {
+		return
+	}
+	if p.Data.Mail.expire(time.Now()) {
+		p.MarkDirty()
+	}
+	resp := &pb.MailListResponse{}
+	for _, mail := range p.Data.Mail.Mails {
+		resp.Mails = append(resp.Mails, mail.proto())
+	}
+	p.Session.Send(cmd, resp)
+}
+
+func (m *MailModule) handleRead(ctx context.Context, cmd int16, req *pb.MailReadRequest) {
+	p, ok := FromPlayerContext(ctx)
+	if !ok {
+		return
+	}
+	err := m.Read(p, req.GetId())
+	p.Session.Send(cmd, &pb.MailReadResponse{Result: proto.Int32(mailResult(err)), Id: req.Id})
+}
+
+func (m *MailModule) handleClaim(ctx context.Context, cmd int16, req *pb.MailClaimRequest) {
+	p, ok := FromPlayerContext(ctx)
+	if !ok {
+		return
+	}
+	items, err := m.Claim(p, req.GetId())
+	if err != nil {
+		log.Debugf("game: player %v claim mail %v error: %v", p.Id, req.GetId(), err)
+	}
+	p.Session.Send(cmd, &pb.MailClaimResponse{Result: proto.Int32(mailResult(err)), Id: req.Id, Items: mailItems(items)})
+}
+
+func (m *MailModule) handleDelete(ctx context.Context, cmd int16, req *pb.MailDeleteRequest) {
+	p, ok := FromPlayerContext(ctx)
+	if !ok {
+		return
+	}
+	err := m.Delete(p, req.GetId())
+	p.Session.Send(cmd, &pb.MailDeleteResponse{Result: proto.Int32(mailResult(err)), Id: req.Id})
+}
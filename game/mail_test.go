@@ -0,0 +1,265 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/iakud/plumeserver/service"
+	"github.com/iakud/plumeserver/service/pb"
+	"github.com/iakud/plumeserver/store"
+)
+
+// 启动玩家和邮件模块, 领取的物品记录在granted中
+func newTestMail(t *testing.T, s store.Store) (*GameApp, map[uint64][]MailItem) {
+	game := newTestGame(t)
+	game.players = NewPlayerManager(newStorePlayerStore(s), time.Hour, time.Hour)
+	game.mail = NewMailModule(s)
+	game.Register(game.players)
+	game.Register(game.mail)
+	granted := make(map[uint64][]MailItem)
+	game.mail.SetGrant(func(p *Player, items []MailItem) error {
+		granted[p.Id] = append(granted[p.Id], items...)
+		return nil
+	})
+	if err := game.modules.init(game); err != nil {
+		t.Fatal(err)
+	}
+	runInLoop(game, func() {
+		if err := game.modules.start(); err != nil {
+			t.Error(err)
+		}
+	})
+	return game, granted
+}
+
+// 模拟客户端发送的消息
+func dispatch(t *testing.T, game *GameApp, session *service.Session, cmd int16, message proto.Message) {
+	buf, err := proto.Marshal(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	runInLoop(game, func() {
+		ctx := service.NewSessionContext(context.Background(), session)
+		ctx = game.players.context(ctx, session)
+		if err := game.hub.Dispatch(ctx, cmd, buf); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestMailClaim(t *testing.T) {
+	s, err := store.OpenFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	game, granted := newTestMail(t, s)
+	gate := newTestGate(t)
+	session := gate.session(1)
+	login(t, game, game.players, session, 1001)
+
+	var id uint64
+	runInLoop(game, func() {
+		id = game.mail.Send(1001, &Mail{Title: "reward", Items: []MailItem{{Id: 1, Count: 10}}})
+	})
+	notify := &pb.MailNotify{}
+	gate.expect(t, 1, kCmdMailNotify, notify)
+	if len(notify.Mails) != 1 || notify.Mails[0].GetId() != id || len(notify.Mails[0].Items) != 1 {
+		t.Fatalf("unexpected notify %v", notify)
+	}
+
+	// 有附件未领取不能删除
+	dispatch(t, game, session, kCmdMailDelete, &pb.MailDeleteRequest{Id: proto.Uint64(id)})
+	deleted := &pb.MailDeleteResponse{}
+	gate.expect(t, 1, kCmdMailDelete, deleted)
+	if deleted.GetResult() != kMailUnclaimed {
+		t.Fatalf("unexpected delete result %v", deleted.GetResult())
+	}
+
+	dispatch(t, game, session, kCmdMailClaim, &pb.MailClaimRequest{Id: proto.Uint64(id)})
+	claim := &pb.MailClaimResponse{}
+	gate.expect(t, 1, kCmdMailClaim, claim)
+	if claim.GetResult() != kMailOK || len(claim.Items) != 1 || claim.Items[0].GetCount() != 10 {
+		t.Fatalf("unexpected claim %v", claim)
+	}
+	// 只能领取一次
+	dispatch(t, game, session, kCmdMailClaim, &pb.MailClaimRequest{Id: proto.Uint64(id)})
+	gate.expect(t, 1, kCmdMailClaim, claim)
+	if claim.GetResult() != kMailClaimed {
+		t.Fatalf("unexpected claim result %v", claim.GetResult())
+	}
+	runInLoop(game, func() {
+		if items := granted[1001]; len(items) != 1 || items[0].Count != 10 {
+			t.Errorf("unexpected granted %v", items)
+		}
+	})
+
+	dispatch(t, game, session, kCmdMailDelete, &pb.MailDeleteRequest{Id: proto.Uint64(id)})
+	gate.expect(t, 1, kCmdMailDelete, deleted)
+	dispatch(t, game, session, kCmdMailList, &pb.MailListRequest{})
+	list := &pb.MailListResponse{}
+	gate.expect(t, 1, kCmdMailList, list)
+	if deleted.GetResult() != kMailOK || len(list.Mails) != 0 {
+		t.Fatalf("unexpected mails %v", list.Mails)
+	}
+	runInLoop(game, game.modules.stop)
+}
+
+func TestMailExpire(t *testing.T) {
+	s, err := store.OpenFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	game, _ := newTestMail(t, s)
+	gate := newTestGate(t)
+	p := login(t, game, game.players, gate.session(1), 1001)
+	runInLoop(game, func() {
+		id := game.mail.Send(1001, &Mail{Title: "expire", Items: []MailItem{{Id: 1, Count: 1}}, ExpireAt: time.Now().Add(-time.Second)})
+		if _, err := game.mail.Claim(p, id); err != ErrMailExpired {
+			t.Errorf("unexpected claim error %v", err)
+		}
+		// 过期的邮件可以删除
+		if err := game.mail.Delete(p, id); err != nil {
+			t.Error(err)
+		}
+		game.mail.Broadcast(&Mail{Title: "expire", ExpireAt: time.Now().Add(-time.Second)})
+		game.mail.expire()
+		if len(game.mail.state.Broadcasts) != 0 {
+			t.Error("broadcast not expired")
+		}
+	})
+	runInLoop(game, game.modules.stop)
+}
+
+func TestMailDelivery(t *testing.T) {
+	s, err := store.OpenFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	game, _ := newTestMail(t, s)
+	// 在线玩家立即收到全服邮件, 离线玩家登录时收到
+	gate := newTestGate(t)
+	online := login(t, game, game.players, gate.session(1), 1001)
+	offline := login(t, game, game.players, gate.session(2), 1002)
+	runInLoop(game, func() {
+		game.players.Logout(offline.Session)
+	})
+	var broadcast uint64
+	runInLoop(game, func() {
+		game.mail.Send(1003, &Mail{Title: "pending"})
+		broadcast = game.mail.Broadcast(&Mail{Title: "notice"})
+		if len(online.Data.Mail.Mails) != 1 || len(offline.Data.Mail.Mails) != 0 {
+			t.Errorf("unexpected mails %v %v", len(online.Data.Mail.Mails), len(offline.Data.Mail.Mails))
+		}
+	})
+	login(t, game, game.players, gate.session(3), 1002)
+	runInLoop(game, func() {
+		if mails := offline.Data.Mail.Mails; len(mails) != 1 || mails[0].Id != broadcast {
+			t.Errorf("unexpected mails %v", mails)
+		}
+	})
+	runInLoop(game, game.modules.stop)
+
+	// 重启后未加载玩家的邮件仍然保留, 之后创建的玩家收不到全服邮件
+	game, _ = newTestMail(t, s)
+	p := login(t, game, game.players, gate.session(4), 1003)
+	runInLoop(game, func() {
+		mails := p.Data.Mail.Mails
+		if len(mails) != 1 || mails[0].Title != "pending" {
+			t.Errorf("unexpected mails %v", mails)
+			return
+		}
+		if p.Data.Mail.Received != mails[0].Id || p.Data.Mail.Broadcast != broadcast {
+			t.Errorf("unexpected mailbox %+v", p.Data.Mail)
+		}
+	})
+	// 玩家数据保存之后删除待发邮件
+	waitPending(t, game, 1003, 0)
+	runInLoop(game, game.modules.stop)
+}
+
+// 等待玩家的待发邮件数量
+func waitPending(t *testing.T, game *GameApp, playerId uint64, n int) {
+	t.Helper()
+	for i := 0; ; i++ {
+		var pending int
+		runInLoop(game, func() { pending = len(game.mail.state.Pending[playerId]) })
+		if pending == n {
+			return
+		}
+		if i == 100 {
+			t.Fatalf("unexpected pending %v", pending)
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+}
+
+func TestMailOverflow(t *testing.T) {
+	s, err := store.OpenFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	game, _ := newTestMail(t, s)
+	gate := newTestGate(t)
+	p := login(t, game, game.players, gate.session(1), 1001)
+	var first, last uint64
+	runInLoop(game, func() {
+		first = game.mail.Send(1001, &Mail{Title: "reward", Items: []MailItem{{Id: 1, Count: 1}}})
+		for i := 1; i < kMaxMails; i++ {
+			game.mail.Send(1001, &Mail{Title: "notice"})
+		}
+		// 邮箱已满并且没有已读邮件, 新邮件继续待发
+		last = game.mail.Send(1001, &Mail{Title: "overflow"})
+		if len(p.Data.Mail.Mails) != kMaxMails || p.Data.Mail.find(last) != nil {
+			t.Errorf("unexpected mails %v", len(p.Data.Mail.Mails))
+		}
+		// 有附件的已读邮件不会被挤出
+		game.mail.Read(p, first)
+		game.mail.Read(p, first+1)
+		game.mail.Send(1001, &Mail{Title: "next"})
+	})
+	waitPending(t, game, 1001, 1)
+	runInLoop(game, func() {
+		if p.Data.Mail.find(first) == nil || p.Data.Mail.find(first+1) != nil || p.Data.Mail.find(last) == nil {
+			t.Error("unexpected evicted mails")
+		}
+		// 领取附件后可以挤出
+		if _, err := game.mail.Claim(p, first); err != nil {
+			t.Error(err)
+		}
+		if p.Data.Mail.find(first) != nil || len(p.Data.Mail.Mails) != kMaxMails {
+			t.Errorf("unexpected mails %v", len(p.Data.Mail.Mails))
+		}
+	})
+	waitPending(t, game, 1001, 0)
+	runInLoop(game, game.modules.stop)
+}
+
+// 玩家数据已经保存但待发邮件没有删除时崩溃, 重新登录不会重复收到
+func TestMailReceived(t *testing.T) {
+	s, err := store.OpenFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	game, _ := newTestMail(t, s)
+	gate := newTestGate(t)
+	p := login(t, game, game.players, gate.session(1), 1001)
+	runInLoop(game, func() {
+		mail := &Mail{Id: 7, Title: "pending"}
+		game.mail.state.Pending[1001] = []*Mail{mail, {Id: 8, Title: "next"}}
+		p.Data.Mail.Mails = []*Mail{mail.clone()}
+		p.Data.Mail.Received = 7
+		game.mail.receive(p)
+		if mails := p.Data.Mail.Mails; len(mails) != 2 || mails[1].Id != 8 || p.Data.Mail.Received != 8 {
+			t.Errorf("unexpected mailbox %+v", p.Data.Mail)
+		}
+	})
+	waitPending(t, game, 1001, 0)
+	runInLoop(game, game.modules.stop)
+}
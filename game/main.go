@@ -2,28 +2,222 @@ package main
 
 import (
 	"context"
+	"flag"
+	"os"
+	"time"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/iakud/plume"
 	"github.com/iakud/plume/log"
+	"github.com/iakud/plumeserver/filter"
+	"github.com/iakud/plumeserver/gm"
+	"github.com/iakud/plumeserver/service"
+	"github.com/iakud/plumeserver/store"
+	"github.com/iakud/plumeserver/timer"
+)
+
+var (
+	addr         = flag.String("addr", ":7100", "gate listen address")
+	tickRate     = flag.Int("tick-rate", 20, "logic ticks per second")
+	saveInterval = flag.Duration("save-interval", time.Minute*5, "player data save interval")
+	unloadDelay  = flag.Duration("unload-delay", time.Minute*5, "offline player unload delay")
+	dataDir      = flag.String("data", "data", "data directory of the file store")
+	itemFile     = flag.String("items", "", "item config file")
+	bagCapacity  = flag.Int("bag-capacity", 100, "bag slot capacity")
+	auditFile    = flag.String("audit", "", "item audit log file, empty to write the server log")
+	chatWords    = flag.String("chat-words", "", "sensitive word list file of chat")
+	chatReject   = flag.Bool("chat-reject", false, "reject chat with sensitive words instead of replacing them")
+	gmAuditFile  = flag.String("gm-audit", "", "gm command audit log file, empty to write the server log")
+	adminAddr    = flag.String("admin", "", "admin http listen address for gm commands, empty to disable")
+	adminToken   = flag.String("admin-token", "", "admin bearer token")
+	console      = flag.Bool("console", false, "read gm commands from stdin")
+	devLogin     = flag.Bool("dev-login", false, "accept the account and player id sent by clients without verification, for development only")
 )
 
 type GameApp struct {
+	loop    *tickLoop
+	hub     *service.MessageHub
+	gate    *gateServer
+	admin   *adminServer
+	modules *modules
+	store   store.Store
+	players *PlayerManager
+	mail    *MailModule
+	bag     *BagModule
+	guild   *GuildModule
+	chat    *ChatModule
+	gm      *GMModule
+	timers  *timer.Wheel // 按逻辑时间推进, 只在逻辑线程访问
+
+	cancel context.CancelFunc
+}
+
+func newGameApp(s store.Store, items map[int32]*ItemConfig, audit AuditLog, gmAudit gm.AuditLog, chat ...ChatOption) *GameApp {
+	game := &GameApp{modules: newModules(), store: s}
+	game.players = NewPlayerManager(newStorePlayerStore(s), *saveInterval, *unloadDelay)
+	game.players.devLogin = *devLogin
+	game.Register(game.players)
+	game.mail = NewMailModule(s)
+	game.Register(game.mail)
+	game.bag = NewBagModule(items, *bagCapacity, audit)
+	game.Register(game.bag)
+	game.mail.SetGrant(game.bag.GrantMail)
+	game.guild = NewGuildModule(s)
+	game.Register(game.guild)
+	game.chat = NewChatModule(s, chat...)
+	game.Register(game.chat)
+	game.gm = NewGMModule(gmAudit)
+	game.Register(game.gm)
+	return game
+}
+
+// 在Init之前注册模块
+func (game *GameApp) Register(m Module) {
+	if err := game.modules.register(m); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// 获取依赖的模块
+func (game *GameApp) Module(name string) Module {
+	return game.modules.get(name)
 }
 
 func (game *GameApp) Init() {
 	log.Info("game init")
+	game.loop = newTickLoop(time.Second/time.Duration(*tickRate), time.Now())
+	game.timers = game.loop.timers
+	game.hub = service.NewMessageHub()
+	if err := game.modules.init(game); err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	game.cancel = cancel
+	go game.Run(ctx)
+
+	// 模块在逻辑线程启动完成后再接受gate连接
+	started := make(chan error, 1)
+	game.loop.RunInLoop(func() {
+		game.timers.EveryFunc(time.Minute, game.logStats)
+		started <- game.modules.start()
+	})
+	if err := <-started; err != nil {
+		log.Fatal(err)
+	}
+	game.gate = newGateServer(*addr, game)
+	go game.gate.ListenAndServe()
+	if *adminAddr != "" {
+		game.admin = newAdminServer(game, *adminToken)
+		go func() {
+			if err := game.admin.ListenAndServe(*adminAddr); err != nil {
+				log.Error("game: admin ", err)
+			}
+		}()
+	}
+	if *console {
+		go runConsole(game, os.Stdin, os.Stdout)
+	}
 }
 
 func (game *GameApp) Run(ctx context.Context) {
 	log.Info("game run")
-	<-ctx.Done()
+	game.loop.Run(ctx)
+}
+
+func (game *GameApp) logStats() {
+	stats := game.loop.Stats()
+	if stats.Ticks == 0 {
+		return
+	}
+	log.Infof("game: ticks %v, overruns %v, dropped %v, max %v", stats.Ticks, stats.Overruns, stats.Dropped, stats.Max)
+	for _, phase := range stats.Phases {
+		log.Infof("game: phase %v avg %v, max %v", phase.Name, phase.Total/time.Duration(stats.Ticks), phase.Max)
+	}
 }
 
 func (game *GameApp) Shutdown() {
 	log.Info("game shutdown")
+	// 先等待gate断开的会话登出, 之后模块不会再收到消息
+	game.gate.Close()
+	game.gate.Wait()
+	if game.admin != nil {
+		game.admin.Close()
+	}
+	// 逻辑线程中按依赖逆序停止模块, 之后逻辑线程不再执行投递的消息
+	stopped := make(chan struct{})
+	game.loop.RunInLoop(func() {
+		game.modules.stop()
+		game.loop.Stop()
+		close(stopped)
+	})
+	<-stopped
+	if err := game.store.Close(); err != nil {
+		log.Error("game: store close", err)
+	}
+	game.cancel()
+}
+
+func (game *GameApp) connect(session *service.Session) {
+	log.Debugf("game: session %v %v connected, version %v", session.Id, session.RemoteAddr, session.Version)
+}
+
+// 客户端断开(包括超时), 在这里及时保存玩家数据
+func (game *GameApp) disconnect(session *service.Session, reason uint8) {
+	log.Debugf("game: session %v disconnected, reason %v", session.Id, reason)
+	game.players.Logout(session)
+}
+
+// 以下方法只在逻辑线程调用
+func (game *GameApp) Multicast(sessions []*service.Session, cmd int16, message proto.Message) error {
+	return service.Multicast(sessions, cmd, message)
+}
+
+func (game *GameApp) Broadcast(cmd int16, message proto.Message) error {
+	return game.gate.broadcast(cmd, message)
+}
+
+func (game *GameApp) Groupcast(group uint64, cmd int16, message proto.Message) error {
+	return game.gate.groupcast(group, cmd, message)
 }
 
 func main() {
-	services := plume.WithServices(&GameApp{})
+	flag.Parse()
+	s, err := store.OpenFileStore(*dataDir)
+	if err != nil {
+		log.Fatal("game: open store", err)
+	}
+	items := make(map[int32]*ItemConfig)
+	if *itemFile != "" {
+		if items, err = LoadItems(*itemFile); err != nil {
+			log.Fatal("game: load items", err)
+		}
+	}
+	var audit AuditLog = logAuditLog{}
+	if *auditFile != "" {
+		if audit, err = OpenFileAuditLog(*auditFile); err != nil {
+			log.Fatal("game: open audit log", err)
+		}
+	}
+	var chat []ChatOption
+	if *chatWords != "" {
+		words, err := filter.Load(*chatWords)
+		if err != nil {
+			log.Fatal("game: load chat words", err)
+		}
+		mode := FilterReplace
+		if *chatReject {
+			mode = FilterReject
+		}
+		chat = append(chat, WithChatFilter(words, mode))
+	}
+	var gmAudit gm.AuditLog = gm.LogAuditLog{}
+	if *gmAuditFile != "" {
+		if gmAudit, err = gm.OpenFileAuditLog(*gmAuditFile); err != nil {
+			log.Fatal("game: open gm audit log", err)
+		}
+	}
+	game := newGameApp(s, items, audit, gmAudit, chat...)
+	services := plume.WithServices(game)
 	plume.Run(services)
-}
\ No newline at end of file
+}
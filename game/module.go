@@ -0,0 +1,130 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/iakud/plume/log"
+)
+
+var (
+	ErrModuleExists  = errors.New("game: module exists")
+	ErrModuleMissing = errors.New("game: module dependency missing")
+	ErrModuleCycle   = errors.New("game: module dependency cycle")
+)
+
+// 游戏功能模块, Init中注册消息处理, Start和Stop在逻辑线程调用
+type Module interface {
+	Name() string
+	Depends() []string // 依赖的模块, 先于本模块Init和Start, 后于本模块Stop
+	Init(game *GameApp) error
+	Start() error
+	Stop()
+}
+
+type modules struct {
+	registered map[string]Module
+	names      []string // 注册顺序, 保证排序结果稳定
+	sorted     []Module
+	started    int // 已经Start的模块数量
+}
+
+func newModules() *modules {
+	return &modules{registered: make(map[string]Module)}
+}
+
+func (ms *modules) register(m Module) error {
+	name := m.Name()
+	if _, ok := ms.registered[name]; ok {
+		return fmt.Errorf("%w: %v", ErrModuleExists, name)
+	}
+	ms.registered[name] = m
+	ms.names = append(ms.names, name)
+	return nil
+}
+
+func (ms *modules) get(name string) Module {
+	return ms.registered[name]
+}
+
+// 按依赖拓扑排序, 依赖缺失或循环时返回错误
+func (ms *modules) sort() error {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	states := make(map[string]int, len(ms.names))
+	sorted := make([]Module, 0, len(ms.names))
+	var path []string
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch states[name] {
+		case visited:
+			return nil
+		case visiting:
+			// 从path中截取环
+			for i, n := range path {
+				if n == name {
+					cycle := append(path[i:len(path):len(path)], name)
+					return fmt.Errorf("%w: %v", ErrModuleCycle, strings.Join(cycle, " -> "))
+				}
+			}
+		}
+		m := ms.registered[name]
+		states[name] = visiting
+		path = append(path, name)
+		for _, depend := range m.Depends() {
+			if _, ok := ms.registered[depend]; !ok {
+				return fmt.Errorf("%w: %v depends on %v", ErrModuleMissing, name, depend)
+			}
+			if err := visit(depend); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		states[name] = visited
+		sorted = append(sorted, m)
+		return nil
+	}
+	for _, name := range ms.names {
+		if err := visit(name); err != nil {
+			return err
+		}
+	}
+	ms.sorted = sorted
+	return nil
+}
+
+func (ms *modules) init(game *GameApp) error {
+	if err := ms.sort(); err != nil {
+		return err
+	}
+	for _, m := range ms.sorted {
+		if err := m.Init(game); err != nil {
+			return fmt.Errorf("game: module %v init: %w", m.Name(), err)
+		}
+	}
+	return nil
+}
+
+// 启动失败时停止已经启动的模块
+func (ms *modules) start() error {
+	for _, m := range ms.sorted {
+		if err := m.Start(); err != nil {
+			ms.stop()
+			return fmt.Errorf("game: module %v start: %w", m.Name(), err)
+		}
+		ms.started++
+	}
+	return nil
+}
+
+func (ms *modules) stop() {
+	for ; ms.started > 0; ms.started-- {
+		m := ms.sorted[ms.started-1]
+		m.Stop()
+		log.Infof("game: module %v stopped", m.Name())
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type testModule struct {
+	name    string
+	depends []string
+	events  *[]string
+	err     error
+}
+
+func (m *testModule) Name() string      { return m.name }
+func (m *testModule) Depends() []string { return m.depends }
+
+func (m *testModule) Init(game *GameApp) error {
+	*m.events = append(*m.events, "init "+m.name)
+	return nil
+}
+
+func (m *testModule) Start() error {
+	if m.err != nil {
+		return m.err
+	}
+	*m.events = append(*m.events, "start "+m.name)
+	return nil
+}
+
+func (m *testModule) Stop() {
+	*m.events = append(*m.events, "stop "+m.name)
+}
+
+func TestModules(t *testing.T) {
+	var events []string
+	ms := newModules()
+	ms.register(&testModule{name: "guild", depends: []string{"mail", "bag"}, events: &events})
+	ms.register(&testModule{name: "mail", depends: []string{"bag"}, events: &events})
+	ms.register(&testModule{name: "bag", events: &events})
+	if err := ms.register(&testModule{name: "bag", events: &events}); !errors.Is(err, ErrModuleExists) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if err := ms.init(nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := ms.start(); err != nil {
+		t.Fatal(err)
+	}
+	ms.stop()
+	expect := []string{
+		"init bag", "init mail", "init guild",
+		"start bag", "start mail", "start guild",
+		"stop guild", "stop mail", "stop bag",
+	}
+	if !reflect.DeepEqual(events, expect) {
+		t.Fatalf("unexpected events %v", events)
+	}
+}
+
+func TestModuleStartError(t *testing.T) {
+	var events []string
+	ms := newModules()
+	ms.register(&testModule{name: "bag", events: &events})
+	ms.register(&testModule{name: "mail", depends: []string{"bag"}, events: &events, err: errors.New("failed")})
+	if err := ms.init(nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := ms.start(); err == nil {
+		t.Fatal("expect start error")
+	}
+	// 只停止已经启动的模块
+	expect := []string{"init bag", "init mail", "start bag", "stop bag"}
+	if !reflect.DeepEqual(events, expect) {
+		t.Fatalf("unexpected events %v", events)
+	}
+}
+
+func TestModuleDependency(t *testing.T) {
+	var events []string
+	ms := newModules()
+	ms.register(&testModule{name: "a", depends: []string{"b"}, events: &events})
+	ms.register(&testModule{name: "b", depends: []string{"c"}, events: &events})
+	ms.register(&testModule{name: "c", depends: []string{"a"}, events: &events})
+	err := ms.sort()
+	if !errors.Is(err, ErrModuleCycle) || err.Error() != "game: module dependency cycle: a -> b -> c -> a" {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	ms = newModules()
+	ms.register(&testModule{name: "a", depends: []string{"b"}, events: &events})
+	if err := ms.sort(); !errors.Is(err, ErrModuleMissing) {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,481 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/iakud/plume/log"
+	"github.com/iakud/plumeserver/gm"
+	"github.com/iakud/plumeserver/service"
+	"github.com/iakud/plumeserver/service/pb"
+	"github.com/iakud/plumeserver/store"
+	"github.com/iakud/plumeserver/timer"
+)
+
+const kCmdLogin int16 = 101
+
+// 登录的结果
+const (
+	kLoginOK = iota
+	kLoginInvalid
+	kLoginAccount
+	kLoginFailed
+)
+
+var (
+	ErrPlayerNotFound = errors.New("game: player not found")
+	ErrSessionClosed  = errors.New("game: session closed")
+	ErrPlayerAccount  = errors.New("game: player belongs to another account")
+)
+
+// 玩家数据的存储, 在逻辑线程之外调用
+type PlayerStore interface {
+	Load(id uint64) ([]byte, error) // 不存在时返回ErrPlayerNotFound
+	Save(id uint64, data []byte) error
+}
+
+// 需要保存的玩家数据
+type PlayerData struct {
+	Id        uint64    `json:"id"`
+	Account   string    `json:"account,omitempty"` // 第一次登录的账号
+	Name      string    `json:"name"`
+	Level     int32     `json:"level"`
+	CreatedAt time.Time `json:"created_at"`
+	LoginAt   time.Time `json:"login_at"`
+	LogoutAt  time.Time `json:"logout_at"`
+	Mail      MailBox   `json:"mail"`
+	Bag       Bag       `json:"bag"`
+	Position  Position  `json:"position"`
+	GM        gm.Level  `json:"gm,omitempty"` // GM权限等级
+}
+
+// 玩家所在的场景和坐标
+type Position struct {
+	Scene uint64  `json:"scene"` // 为0时不在场景中
+	X     float32 `json:"x"`
+	Z     float32 `json:"z"`
+}
+
+// 玩家对象, 只在逻辑线程访问
+type Player struct {
+	Id      uint64
+	Data    *PlayerData
+	Session *service.Session // 离线时为空
+
+	dirty    bool
+	saving   int // 正在保存的次数
+	logoutAt time.Time
+}
+
+// 修改数据后标记, 定期保存
+func (p *Player) MarkDirty() {
+	p.dirty = true
+}
+
+func (p *Player) Online() bool {
+	return p.Session != nil
+}
+
+type playerKey struct{}
+
+func newPlayerContext(ctx context.Context, p *Player) context.Context {
+	return context.WithValue(ctx, playerKey{}, p)
+}
+
+// handler中获取当前会话登录的玩家
+func FromPlayerContext(ctx context.Context) (*Player, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	p, ok := ctx.Value(playerKey{}).(*Player)
+	return p, ok
+}
+
+type saveTask struct {
+	player *Player
+	data   []byte
+	done   func(error)
+}
+
+type loginRequest struct {
+	session *service.Session
+	account string
+	cb      func(*Player, error)
+}
+
+// 管理在线和离线缓存的玩家, 负责加载, 定期保存和卸载
+type PlayerManager struct {
+	game  *GameApp
+	store PlayerStore
+
+	saveInterval time.Duration // 定期保存的间隔
+	unloadDelay  time.Duration // 离线后保留在内存中的时间
+	devLogin     bool          // 开发用的登录命令, 不验证客户端发送的账号
+
+	players  map[uint64]*Player
+	sessions map[uint64]*Player         // 会话id到玩家
+	loading  map[uint64][]*loginRequest // 正在加载的玩家
+	ticker   *timer.Timer
+	hooks    []func(*Player)                // 登录后的回调
+	unhooks  []func(*Player)                // 登出前的回调
+	moves    []func(p *Player, from uint64) // 切换场景后的回调
+
+	saves chan *saveTask
+	wg    sync.WaitGroup
+}
+
+func NewPlayerManager(store PlayerStore, saveInterval, unloadDelay time.Duration) *PlayerManager {
+	return &PlayerManager{
+		store:        store,
+		saveInterval: saveInterval,
+		unloadDelay:  unloadDelay,
+		players:      make(map[uint64]*Player),
+		sessions:     make(map[uint64]*Player),
+		loading:      make(map[uint64][]*loginRequest),
+	}
+}
+
+func (pm *PlayerManager) Name() string {
+	return "player"
+}
+
+func (pm *PlayerManager) Depends() []string {
+	return nil
+}
+
+func (pm *PlayerManager) Init(game *GameApp) error {
+	pm.game = game
+	if pm.devLogin {
+		log.Warning("game: dev login enabled, client accounts are not verified")
+		game.hub.Register(kCmdLogin, pm.handleLogin)
+	}
+	return nil
+}
+
+func (pm *PlayerManager) Start() error {
+	pm.saves = make(chan *saveTask, 1024)
+	pm.wg.Add(1)
+	go pm.saveLoop()
+	pm.ticker = pm.game.timers.EveryFunc(pm.saveInterval, pm.saveAll)
+	return nil
+}
+
+// 保存所有玩家并等待保存完成
+func (pm *PlayerManager) Stop() {
+	pm.ticker.Stop()
+	pm.saveAll()
+	close(pm.saves)
+	pm.wg.Wait()
+}
+
+// 按提交顺序保存, 保证同一个玩家的数据不会被旧数据覆盖
+func (pm *PlayerManager) saveLoop() {
+	defer pm.wg.Done()
+	for task := range pm.saves {
+		task := task
+		err := pm.store.Save(task.player.Id, task.data)
+		pm.game.loop.RunInLoop(func() {
+			pm.saved(task, err)
+		})
+	}
+}
+
+func (pm *PlayerManager) Player(id uint64) *Player {
+	return pm.players[id]
+}
+
+func (pm *PlayerManager) SessionPlayer(session *service.Session) *Player {
+	return pm.sessions[session.Id]
+}
+
+// 遍历在线玩家
+func (pm *PlayerManager) RangeOnline(f func(p *Player)) {
+	for _, p := range pm.sessions {
+		f(p)
+	}
+}
+
+// 模块在Init中注册, 玩家每次登录后调用
+func (pm *PlayerManager) OnLogin(f func(p *Player)) {
+	pm.hooks = append(pm.hooks, f)
+}
+
+// 模块在Init中注册, 玩家登出前调用, 此时会话仍然有效
+func (pm *PlayerManager) OnLogout(f func(p *Player)) {
+	pm.unhooks = append(pm.unhooks, f)
+}
+
+// 模块在Init中注册, 玩家切换场景后调用, from为之前的场景
+func (pm *PlayerManager) OnSceneChange(f func(p *Player, from uint64)) {
+	pm.moves = append(pm.moves, f)
+}
+
+// 修改玩家的位置, 进入和离开场景都通过这里, 场景变化时通知模块
+func (pm *PlayerManager) SetPosition(p *Player, pos Position) {
+	from := p.Data.Position.Scene
+	p.Data.Position = pos
+	p.MarkDirty()
+	if pos.Scene == from {
+		return
+	}
+	for _, hook := range pm.moves {
+		hook(p, from)
+	}
+}
+
+// 会话登录玩家, 加载完成后在逻辑线程回调
+func (pm *PlayerManager) Login(session *service.Session, id uint64, account string, cb func(*Player, error)) {
+	if p, ok := pm.players[id]; ok {
+		if p.Data.Account != "" && p.Data.Account != account {
+			cb(nil, ErrPlayerAccount)
+			return
+		}
+		pm.bind(p, session, account)
+		cb(p, nil)
+		return
+	}
+	request := &loginRequest{session: session, account: account, cb: cb}
+	if requests, ok := pm.loading[id]; ok {
+		pm.loading[id] = append(requests, request)
+		return
+	}
+	pm.loading[id] = []*loginRequest{request}
+	go func() {
+		data, err := pm.store.Load(id)
+		pm.game.loop.RunInLoop(func() {
+			pm.loaded(id, data, err)
+		})
+	}()
+}
+
+func (pm *PlayerManager) loaded(id uint64, b []byte, err error) {
+	requests := pm.loading[id]
+	delete(pm.loading, id)
+	var data *PlayerData
+	switch {
+	case errors.Is(err, ErrPlayerNotFound):
+		data = &PlayerData{Id: id, Level: 1, CreatedAt: time.Now()}
+		err = nil
+	case err == nil:
+		data = &PlayerData{}
+		err = json.Unmarshal(b, data)
+	}
+	if err != nil {
+		log.Errorf("game: player %v load error: %v", id, err)
+		for _, request := range requests {
+			request.cb(nil, err)
+		}
+		return
+	}
+	p := &Player{Id: id, Data: data, dirty: true}
+	pm.players[id] = p
+	// 同时登录的请求, 后登录的顶掉先登录的
+	for _, request := range requests {
+		if request.session == nil {
+			request.cb(nil, ErrSessionClosed)
+			continue
+		}
+		if p.Data.Account != "" && p.Data.Account != request.account {
+			request.cb(nil, ErrPlayerAccount)
+			continue
+		}
+		pm.bind(p, request.session, request.account)
+		request.cb(p, nil)
+	}
+	if !p.Online() {
+		pm.logout(p)
+	}
+}
+
+func (pm *PlayerManager) bind(p *Player, session *service.Session, account string) {
+	// 同一个会话登录其他玩家, 先登出之前的玩家
+	if old, ok := pm.sessions[session.Id]; ok && old != p {
+		pm.unbind(old)
+	}
+	if p.Session != nil && p.Session != session {
+		log.Infof("game: player %v login replaced, session %v", p.Id, p.Session.Id)
+		delete(pm.sessions, p.Session.Id)
+		p.Session.Kick()
+	}
+	p.Session = session
+	if p.Data.Account == "" {
+		p.Data.Account = account
+	}
+	p.Data.LoginAt = time.Now()
+	p.MarkDirty()
+	pm.sessions[session.Id] = p
+	session.SetState(service.StatePlayer)
+	log.Infof("game: player %v login, session %v", p.Id, session.Id)
+	for _, hook := range pm.hooks {
+		hook(p)
+	}
+}
+
+// 会话断开, 保存玩家数据并延迟卸载
+func (pm *PlayerManager) Logout(session *service.Session) {
+	// 会话在加载期间断开
+	for _, requests := range pm.loading {
+		for _, request := range requests {
+			if request.session == session {
+				request.session = nil
+			}
+		}
+	}
+	if p, ok := pm.sessions[session.Id]; ok {
+		pm.unbind(p)
+	}
+}
+
+func (pm *PlayerManager) unbind(p *Player) {
+	for _, hook := range pm.unhooks {
+		hook(p)
+	}
+	session := p.Session
+	delete(pm.sessions, session.Id)
+	p.Session = nil
+	p.Data.LogoutAt = time.Now()
+	p.MarkDirty()
+	log.Infof("game: player %v logout, session %v", p.Id, session.Id)
+	pm.logout(p)
+}
+
+func (pm *PlayerManager) logout(p *Player) {
+	p.logoutAt = pm.game.timers.Now()
+	pm.save(p, nil)
+	pm.game.timers.AfterFunc(pm.unloadDelay, func() {
+		pm.unload(p)
+	})
+}
+
+// 离线超过保留时间并且数据已经保存才卸载
+func (pm *PlayerManager) unload(p *Player) {
+	if pm.players[p.Id] != p || p.Online() || p.dirty || p.saving > 0 {
+		return
+	}
+	if pm.game.timers.Now().Sub(p.logoutAt) < pm.unloadDelay {
+		return
+	}
+	delete(pm.players, p.Id)
+	log.Debugf("game: player %v unloaded", p.Id)
+}
+
+// 立即保存玩家数据, 写入存储后在逻辑线程回调
+func (pm *PlayerManager) Save(p *Player, done func(error)) {
+	p.MarkDirty()
+	pm.save(p, done)
+}
+
+func (pm *PlayerManager) save(p *Player, done func(error)) {
+	if !p.dirty {
+		return
+	}
+	data, err := json.Marshal(p.Data)
+	if err != nil {
+		log.Errorf("game: player %v marshal error: %v", p.Id, err)
+		return
+	}
+	p.dirty = false
+	p.saving++
+	pm.saves <- &saveTask{player: p, data: data, done: done}
+}
+
+func (pm *PlayerManager) saved(task *saveTask, err error) {
+	p := task.player
+	p.saving--
+	if task.done != nil {
+		task.done(err)
+	}
+	if err != nil {
+		// 下次定期保存时重试
+		log.Errorf("game: player %v save error: %v", p.Id, err)
+		p.dirty = true
+		return
+	}
+	pm.unload(p)
+}
+
+func (pm *PlayerManager) saveAll() {
+	for _, p := range pm.players {
+		pm.save(p, nil)
+	}
+}
+
+// 登录后的会话, 在context中加入玩家
+func (pm *PlayerManager) context(ctx context.Context, session *service.Session) context.Context {
+	if p, ok := pm.sessions[session.Id]; ok {
+		return newPlayerContext(ctx, p)
+	}
+	return ctx
+}
+
+// 玩家数据保存在Store中
+type storePlayerStore struct {
+	store store.Store
+}
+
+func newStorePlayerStore(s store.Store) *storePlayerStore {
+	return &storePlayerStore{store: s}
+}
+
+func (s *storePlayerStore) key(id uint64) string {
+	return "player/" + strconv.FormatUint(id, 10)
+}
+
+func (s *storePlayerStore) Load(id uint64) ([]byte, error) {
+	data, _, err := s.store.Get(s.key(id))
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, ErrPlayerNotFound
+	}
+	return data, err
+}
+
+// 保存顺序由PlayerManager保证, 不检查版本
+func (s *storePlayerStore) Save(id uint64, data []byte) error {
+	_, err := s.store.Put(s.key(id), data, store.AnyVersion)
+	return err
+}
+
+func loginResult(err error) int32 {
+	switch {
+	case err == nil:
+		return kLoginOK
+	case errors.Is(err, ErrPlayerAccount):
+		return kLoginAccount
+	default:
+		return kLoginFailed
+	}
+}
+
+// 只用于开发, 直接信任客户端发送的账号和玩家id, 只检查玩家属于该账号
+// 正式环境需要先接入账号认证, 不注册这个命令
+func (pm *PlayerManager) handleLogin(ctx context.Context, cmd int16, req *pb.LoginRequest) {
+	session, ok := service.FromSessionContext(ctx)
+	if !ok {
+		return
+	}
+	if req.GetAccount() == "" || req.GetPlayerId() == 0 {
+		session.Send(cmd, &pb.LoginResponse{Result: proto.Int32(kLoginInvalid)})
+		return
+	}
+	pm.Login(session, req.GetPlayerId(), req.GetAccount(), func(p *Player, err error) {
+		if errors.Is(err, ErrSessionClosed) {
+			return
+		}
+		resp := &pb.LoginResponse{Result: proto.Int32(loginResult(err))}
+		if err != nil {
+			log.Warningf("game: session %v login player %v error: %v", session.Id, req.GetPlayerId(), err)
+			session.Send(cmd, resp)
+			return
+		}
+		session.SetAccount(req.GetAccount())
+		resp.PlayerId = proto.Uint64(p.Id)
+		resp.Name = proto.String(p.Data.Name)
+		resp.Level = proto.Int32(p.Data.Level)
+		session.Send(cmd, resp)
+	})
+}
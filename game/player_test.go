@@ -0,0 +1,274 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/iakud/plumeserver/service"
+	"github.com/iakud/plumeserver/service/pb"
+	"github.com/iakud/plumeserver/store"
+)
+
+// 记录保存次数的存储
+type testPlayerStore struct {
+	*memoryPlayerStore
+
+	mutex sync.Mutex
+	saves int
+}
+
+func (s *testPlayerStore) Save(id uint64, data []byte) error {
+	s.mutex.Lock()
+	s.saves++
+	s.mutex.Unlock()
+	return s.memoryPlayerStore.Save(id, data)
+}
+
+func newTestGame(t *testing.T) *GameApp {
+	loop := newTickLoop(time.Millisecond*10, time.Now())
+	game := &GameApp{loop: loop, timers: loop.timers, hub: service.NewMessageHub(), modules: newModules()}
+	ctx, cancel := context.WithCancel(context.Background())
+	go loop.Run(ctx)
+	t.Cleanup(cancel)
+	return game
+}
+
+// 在逻辑线程执行并等待完成
+func runInLoop(game *GameApp, f func()) {
+	done := make(chan struct{})
+	game.loop.RunInLoop(func() {
+		f()
+		close(done)
+	})
+	<-done
+}
+
+func login(t *testing.T, game *GameApp, pm *PlayerManager, session *service.Session, id uint64) *Player {
+	result := make(chan *Player, 1)
+	game.loop.RunInLoop(func() {
+		pm.Login(session, id, "", func(p *Player, err error) {
+			if err != nil {
+				t.Error(err)
+			}
+			result <- p
+		})
+	})
+	select {
+	case p := <-result:
+		return p
+	case <-time.After(time.Second * 5):
+		t.Fatal("login timeout")
+		return nil
+	}
+}
+
+func TestPlayerManager(t *testing.T) {
+	game := newTestGame(t)
+	store := &testPlayerStore{memoryPlayerStore: newMemoryPlayerStore()}
+	pm := NewPlayerManager(store, time.Hour, time.Millisecond*50)
+	pm.Init(game)
+	runInLoop(game, func() { pm.Start() })
+	gate := newTestGate(t)
+
+	session := gate.session(1)
+	p := login(t, game, pm, session, 1001)
+	runInLoop(game, func() {
+		if !p.Online() || pm.SessionPlayer(session) != p {
+			t.Error("player not online")
+		}
+		ctx := pm.context(context.Background(), session)
+		if player, ok := FromPlayerContext(ctx); !ok || player != p {
+			t.Error("player not in context")
+		}
+		p.Data.Name = "iakud"
+		p.MarkDirty()
+		pm.Logout(session)
+	})
+
+	// 保存后延迟卸载
+	time.Sleep(time.Millisecond * 200)
+	runInLoop(game, func() {
+		if pm.Player(1001) != nil {
+			t.Error("player not unloaded")
+		}
+	})
+	session = gate.session(2)
+	p = login(t, game, pm, session, 1001)
+	if p.Data.Name != "iakud" {
+		t.Fatalf("unexpected player data %v", p.Data)
+	}
+
+	runInLoop(game, pm.Stop)
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	if store.saves != 2 {
+		t.Fatalf("unexpected saves %v", store.saves)
+	}
+}
+
+func TestPlayerRelogin(t *testing.T) {
+	game := newTestGame(t)
+	s, err := store.OpenFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	pm := NewPlayerManager(newStorePlayerStore(s), time.Hour, time.Hour)
+	pm.Init(game)
+	runInLoop(game, func() { pm.Start() })
+	gate := newTestGate(t)
+
+	session := gate.session(1)
+	p := login(t, game, pm, session, 1001)
+	runInLoop(game, func() { pm.Logout(session) })
+	// 保留期内重新登录使用内存中的玩家
+	session = gate.session(2)
+	if login(t, game, pm, session, 1001) != p {
+		t.Fatal("unexpected new player")
+	}
+	runInLoop(game, pm.Stop)
+}
+
+// 同时保存多个玩家, 每个玩家的保存完成回调只调用一次
+func TestPlayerSaveMany(t *testing.T) {
+	game := newTestGame(t)
+	pm := NewPlayerManager(&testPlayerStore{memoryPlayerStore: newMemoryPlayerStore()}, time.Hour, time.Hour)
+	pm.Init(game)
+	runInLoop(game, func() { pm.Start() })
+	gate := newTestGate(t)
+
+	const n = 20
+	var players []*Player
+	for i := 0; i < n; i++ {
+		players = append(players, login(t, game, pm, gate.session(uint64(i+1)), uint64(1001+i)))
+	}
+	saved := make(chan uint64, n*2)
+	runInLoop(game, func() {
+		for _, p := range players {
+			p := p
+			pm.Save(p, func(err error) {
+				if err != nil {
+					t.Error(err)
+				}
+				saved <- p.Id
+			})
+		}
+	})
+	counts := make(map[uint64]int)
+	for i := 0; i < n; i++ {
+		select {
+		case id := <-saved:
+			counts[id]++
+		case <-time.After(time.Second * 5):
+			t.Fatal("save timeout")
+		}
+	}
+	runInLoop(game, func() {
+		for _, p := range players {
+			if counts[p.Id] != 1 || p.saving != 0 {
+				t.Errorf("player %v saved %v saving %v", p.Id, counts[p.Id], p.saving)
+			}
+		}
+	})
+	runInLoop(game, pm.Stop)
+}
+
+func TestPlayerLoginHandler(t *testing.T) {
+	game := newTestGame(t)
+	game.players = NewPlayerManager(newMemoryPlayerStore(), time.Hour, time.Hour)
+	game.players.Init(game)
+	// 没有开启时不注册登录命令
+	if err := game.hub.Dispatch(nil, kCmdLogin, nil); !errors.Is(err, service.ErrNoHandler) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	game = newTestGame(t)
+	game.players = NewPlayerManager(newMemoryPlayerStore(), time.Hour, time.Hour)
+	game.players.devLogin = true
+	game.players.Init(game)
+	runInLoop(game, func() { game.players.Start() })
+	gate := newTestGate(t)
+	session := gate.session(1)
+	resp := &pb.LoginResponse{}
+	send := func(account string, id uint64) {
+		t.Helper()
+		dispatch(t, game, session, kCmdLogin, &pb.LoginRequest{Account: proto.String(account), PlayerId: proto.Uint64(id)})
+		if err := proto.Unmarshal(readCmd(t, gate, kCmdLogin).Payload, resp); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	send("", 1001)
+	if resp.GetResult() != kLoginInvalid {
+		t.Fatalf("unexpected response %v", resp)
+	}
+	// 登录成功后先通知gate账号和状态, 再回复客户端
+	dispatch(t, game, session, kCmdLogin, &pb.LoginRequest{Account: proto.String("iakud"), PlayerId: proto.Uint64(1001)})
+	var account string
+	var state uint8 = 0xff
+	packet := gate.read(t)
+	for ; packet.Type != service.BackendData; packet = gate.read(t) {
+		switch packet.Type {
+		case service.BackendAccount:
+			account = string(packet.Payload)
+		case service.BackendState:
+			state = packet.Payload[0]
+		}
+	}
+	if account != "iakud" || state != service.StatePlayer {
+		t.Fatalf("unexpected account %q state %v", account, state)
+	}
+	if err := proto.Unmarshal(packet.Payload, resp); err != nil || resp.GetResult() != kLoginOK || resp.GetPlayerId() != 1001 {
+		t.Fatalf("unexpected response %v %v", resp, err)
+	}
+
+	// 同一个会话登录其他玩家, 之前的玩家登出
+	send("iakud", 1002)
+	if resp.GetResult() != kLoginOK {
+		t.Fatalf("unexpected response %v", resp)
+	}
+	runInLoop(game, func() {
+		if p := game.players.Player(1001); p == nil || p.Online() {
+			t.Error("previous player still online")
+		}
+		if p := game.players.SessionPlayer(session); p == nil || p.Id != 1002 {
+			t.Error("unexpected session player")
+		}
+	})
+	// 其他账号不能登录
+	send("other", 1001)
+	if resp.GetResult() != kLoginAccount {
+		t.Fatalf("unexpected response %v", resp)
+	}
+	runInLoop(game, game.players.Stop)
+}
+
+// 内存中的存储
+type memoryPlayerStore struct {
+	mutex   sync.Mutex
+	players map[uint64][]byte
+}
+
+func newMemoryPlayerStore() *memoryPlayerStore {
+	return &memoryPlayerStore{players: make(map[uint64][]byte)}
+}
+
+func (s *memoryPlayerStore) Load(id uint64) ([]byte, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	data, ok := s.players[id]
+	if !ok {
+		return nil, ErrPlayerNotFound
+	}
+	return data, nil
+}
+
+func (s *memoryPlayerStore) Save(id uint64, data []byte) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.players[id] = data
+	return nil
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/iakud/plume/log"
+	"github.com/iakud/plumeserver/internal/adminhttp"
+	"github.com/iakud/plumeserver/service"
+)
+
+// 管理后台, 查询会话, 踢人和禁言
+type adminServer struct {
+	*adminhttp.Server
+	server *Server
+}
+
+func newAdminServer(server *Server, token string) *adminServer {
+	admin := &adminServer{server: server}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/sessions", admin.handleSessions)
+	mux.HandleFunc("/session", admin.handleSession)
+	mux.HandleFunc("/kick", admin.handleKick)
+	mux.HandleFunc("/mute", admin.handleMute)
+	mux.HandleFunc("/bans", admin.handleBans)
+	mux.HandleFunc("/ban", admin.handleBan)
+	mux.HandleFunc("/unban", admin.handleUnban)
+	admin.Server = adminhttp.NewServer("gate", token, mux)
+	return admin
+}
+
+// GET /sessions
+func (admin *adminServer) handleSessions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		adminhttp.WriteError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	sessions := admin.server.allSessions()
+	infos := make([]*SessionInfo, 0, len(sessions))
+	for _, session := range sessions {
+		infos = append(infos, session.Info())
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Id < infos[j].Id })
+	adminhttp.WriteJSON(w, infos)
+}
+
+func (admin *adminServer) getSession(w http.ResponseWriter, r *http.Request, method string) *Session {
+	if r.Method != method {
+		adminhttp.WriteError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return nil
+	}
+	id, err := strconv.ParseUint(r.FormValue("id"), 10, 64)
+	if err != nil {
+		adminhttp.WriteError(w, http.StatusBadRequest, "invalid id")
+		return nil
+	}
+	session := admin.server.GetSession(id)
+	if session == nil {
+		adminhttp.WriteError(w, http.StatusNotFound, "session not found")
+		return nil
+	}
+	return session
+}
+
+// GET /session?id=
+func (admin *adminServer) handleSession(w http.ResponseWriter, r *http.Request) {
+	if session := admin.getSession(w, r, http.MethodGet); session != nil {
+		adminhttp.WriteJSON(w, session.Info())
+	}
+}
+
+// POST /kick?id=
+func (admin *adminServer) handleKick(w http.ResponseWriter, r *http.Request) {
+	session := admin.getSession(w, r, http.MethodPost)
+	if session == nil {
+		return
+	}
+	log.Infof("gate: admin kick session %v", session.id)
+	session.Close(service.ReasonKicked)
+	adminhttp.WriteJSON(w, session.Info())
+}
+
+// POST /mute?id=&duration=10m, duration为0解除禁言
+func (admin *adminServer) handleMute(w http.ResponseWriter, r *http.Request) {
+	session := admin.getSession(w, r, http.MethodPost)
+	if session == nil {
+		return
+	}
+	duration, err := time.ParseDuration(r.FormValue("duration"))
+	if err != nil {
+		adminhttp.WriteError(w, http.StatusBadRequest, "invalid duration")
+		return
+	}
+	log.Infof("gate: admin mute session %v for %v", session.id, duration)
+	session.Mute(duration)
+	adminhttp.WriteJSON(w, session.Info())
+}
+
+// GET /bans
+func (admin *adminServer) handleBans(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		adminhttp.WriteError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	adminhttp.WriteJSON(w, admin.server.Bans())
+}
+
+// POST /ban?target=1.2.3.0/24&duration=1h, duration为forever永久封禁
+func (admin *adminServer) handleBan(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		adminhttp.WriteError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	duration := BanForever
+	if s := r.FormValue("duration"); s != "forever" {
+		d, err := time.ParseDuration(s)
+		if err != nil || d <= 0 {
+			adminhttp.WriteError(w, http.StatusBadRequest, "invalid duration")
+			return
+		}
+		duration = d
+	}
+	target := r.FormValue("target")
+	if err := admin.server.Ban(target, duration); err != nil {
+		adminhttp.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	log.Infof("gate: admin ban %v for %v", target, duration)
+	adminhttp.WriteJSON(w, admin.server.Bans())
+}
+
+// POST /unban?target=
+func (admin *adminServer) handleUnban(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		adminhttp.WriteError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	target := r.FormValue("target")
+	if err := admin.server.Unban(target); err != nil {
+		adminhttp.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	log.Infof("gate: admin unban %v", target)
+	adminhttp.WriteJSON(w, admin.server.Bans())
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/iakud/plumeserver/service"
+)
+
+func adminRequest(t *testing.T, method, url, token string, v interface{}) int {
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if v != nil && resp.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func TestAdmin(t *testing.T) {
+	srv, game, addr := newTestServer(t, newConfig())
+	conns, sessions := dialTestClients(t, game, addr, 1)
+	game.login(t, srv, sessions[0])
+	game.conn.Send((&service.BackendPacket{Type: service.BackendAccount, Session: sessions[0], Payload: []byte("nuannuan")}).Marshal())
+
+	const token = "secret"
+	admin := httptest.NewServer(newAdminServer(srv, token).Handler())
+	defer admin.Close()
+
+	if code := adminRequest(t, http.MethodGet, admin.URL+"/sessions", "", nil); code != http.StatusUnauthorized {
+		t.Fatalf("unexpected status %v", code)
+	}
+	if code := adminRequest(t, http.MethodGet, admin.URL+"/sessions", "wrong", nil); code != http.StatusUnauthorized {
+		t.Fatalf("unexpected status %v", code)
+	}
+	// 客户端发送消息后查询
+	if err := writePacket(conns[0], &Packet{Cmd: 1, Payload: []byte("hello")}); err != nil {
+		t.Fatal(err)
+	}
+	game.expect(t, service.BackendData)
+	var infos []*SessionInfo
+	if code := adminRequest(t, http.MethodGet, admin.URL+"/sessions", token, &infos); code != http.StatusOK {
+		t.Fatalf("unexpected status %v", code)
+	}
+	if len(infos) != 1 || infos[0].Id != sessions[0] || infos[0].Account != "nuannuan" || infos[0].BytesIn == 0 || infos[0].BytesOut == 0 {
+		t.Fatalf("unexpected sessions: %+v", infos[0])
+	}
+
+	// 禁言后聊天命令不转发, 其他命令照常转发
+	url := fmt.Sprintf("%v/mute?id=%v&duration=1m", admin.URL, sessions[0])
+	if code := adminRequest(t, http.MethodPost, url, token, nil); code != http.StatusOK {
+		t.Fatalf("unexpected status %v", code)
+	}
+	if err := writePacket(conns[0], &Packet{Cmd: 1301, Payload: []byte("muted")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := writePacket(conns[0], &Packet{Cmd: 1, Payload: []byte("move")}); err != nil {
+		t.Fatal(err)
+	}
+	if data := game.expect(t, service.BackendData); data.Cmd != 1 || string(data.Payload) != "move" {
+		t.Fatalf("unexpected data: cmd=%v, payload=%q", data.Cmd, data.Payload)
+	}
+	url = fmt.Sprintf("%v/kick?id=%v", admin.URL, sessions[0])
+	if code := adminRequest(t, http.MethodPost, url, token, nil); code != http.StatusOK {
+		t.Fatalf("unexpected status %v", code)
+	}
+	packet := <-game.packets
+	if packet.Type != service.BackendDisconnect || packet.Reason() != service.ReasonKicked {
+		t.Fatalf("unexpected packet: type=%v, reason=%v", packet.Type, packet.Reason())
+	}
+	url = fmt.Sprintf("%v/session?id=%v", admin.URL, sessions[0])
+	if code := adminRequest(t, http.MethodGet, url, token, nil); code != http.StatusNotFound {
+		t.Fatalf("unexpected status %v", code)
+	}
+
+	// 永久封禁需要明确指定, 没有时长或者时长不大于零时拒绝
+	for _, duration := range []string{"", "-1h", "0s", "1h", "forever"} {
+		url = fmt.Sprintf("%v/ban?target=10.1.0.0/16&duration=%v", admin.URL, duration)
+		code := adminRequest(t, http.MethodPost, url, token, nil)
+		if expect := duration == "1h" || duration == "forever"; (code == http.StatusOK) != expect {
+			t.Fatalf("duration %q unexpected status %v", duration, code)
+		}
+	}
+	if bans := srv.Bans(); len(bans) != 1 || !bans[0].Expire.IsZero() {
+		t.Fatalf("unexpected bans %v", bans)
+	}
+}
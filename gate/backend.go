@@ -0,0 +1,174 @@
+package main
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/iakud/plume/log"
+	"github.com/iakud/plume/network"
+	"github.com/iakud/plumeserver/service"
+)
+
+var ErrBackendUnavailable = errors.New("gate: backend unavailable")
+
+// gate到game的连接
+type backend struct {
+	client *network.TCPClient
+	server *Server
+
+	mutex        sync.Mutex
+	disconnected chan struct{}
+}
+
+func newBackend(addr string, server *Server) *backend {
+	b := &backend{
+		client: network.NewTCPClient(addr),
+		server: server,
+	}
+	return b
+}
+
+func (b *backend) dialAndServe() {
+	b.client.EnableRetry() // 启用retry
+	if err := b.client.DialAndServe(b, service.BackendCodec); err != nil {
+		if err == network.ErrClientClosed {
+			return
+		}
+		log.Error("gate: backend", err)
+	}
+}
+
+func (b *backend) close() {
+	b.client.Close()
+}
+
+// 发送完剩余的消息再关闭, 保证game收到断开通知
+func (b *backend) shutdown(timeout time.Duration) {
+	connection := b.client.GetConnection()
+	if connection == nil {
+		b.close()
+		return
+	}
+	disconnected := make(chan struct{})
+	b.mutex.Lock()
+	b.disconnected = disconnected
+	b.mutex.Unlock()
+
+	connection.Shutdown()
+	select {
+	case <-disconnected:
+	case <-time.After(timeout):
+	}
+	b.close()
+}
+
+func (b *backend) send(packet *service.BackendPacket) error {
+	connection := b.client.GetConnection()
+	if connection == nil {
+		return ErrBackendUnavailable
+	}
+	return connection.Send(packet.Marshal())
+}
+
+func (b *backend) connect(session *Session) error {
+	packet := service.NewConnectPacket(session.id, session.version, session.build, session.RemoteAddr().String())
+	return b.send(packet)
+}
+
+func (b *backend) disconnect(session *Session, reason uint8) error {
+	return b.send(service.NewDisconnectPacket(session.id, reason))
+}
+
+func (b *backend) forward(session *Session, p *Packet) error {
+	packet := &service.BackendPacket{
+		Type:    service.BackendData,
+		Session: session.id,
+		Cmd:     p.Cmd,
+		Payload: p.Payload,
+	}
+	return b.send(packet)
+}
+
+func (b *backend) Connect(connection *network.TCPConnection, connected bool) {
+	if connected {
+		log.Infof("gate: backend %v connected", connection.RemoteAddr())
+	} else {
+		log.Infof("gate: backend %v disconnected", connection.RemoteAddr())
+		b.mutex.Lock()
+		if b.disconnected != nil {
+			close(b.disconnected)
+			b.disconnected = nil
+		}
+		b.mutex.Unlock()
+	}
+}
+
+func (b *backend) Receive(connection *network.TCPConnection, buf []byte) {
+	var packet service.BackendPacket
+	if err := packet.Unmarshal(buf); err != nil {
+		log.Warning("gate: backend", err)
+		return
+	}
+	switch packet.Type {
+	case service.BackendData, service.BackendMulticast, service.BackendBroadcast, service.BackendGroupcast:
+		// 负数命令只由gate发送, 如明文的密钥交换, game不能下发
+		if isGateCmd(packet.Cmd) {
+			log.Warningf("gate: backend packet type %v with gate cmd %v dropped", packet.Type, packet.Cmd)
+			return
+		}
+	}
+	switch packet.Type {
+	case service.BackendData:
+		if session := b.server.GetSession(packet.Session); session != nil {
+			session.Send(&Packet{Cmd: packet.Cmd, Payload: packet.Payload})
+		}
+	case service.BackendKick:
+		if session := b.server.GetSession(packet.Session); session != nil {
+			session.Close(service.ReasonKicked)
+		}
+	case service.BackendAccount:
+		if session := b.server.GetSession(packet.Session); session != nil {
+			session.setAccount(string(packet.Payload))
+		}
+	case service.BackendState:
+		if len(packet.Payload) == 0 || !service.ValidState(packet.Payload[0]) {
+			log.Warning("gate: backend", service.ErrSessionState)
+			return
+		}
+		if session := b.server.GetSession(packet.Session); session != nil {
+			session.setState(packet.Payload[0])
+		}
+	case service.BackendMulticast:
+		ids, payload, err := packet.Multicast()
+		if err != nil {
+			log.Warning("gate: backend", err)
+			return
+		}
+		b.fanout(b.server.getSessions(ids), packet.Cmd, payload)
+	case service.BackendBroadcast:
+		b.fanout(b.server.allSessions(), packet.Cmd, packet.Payload)
+	case service.BackendGroupcast:
+		b.fanout(b.server.getSessions(b.server.groups.members(packet.Session)), packet.Cmd, packet.Payload)
+	case service.BackendJoinGroup, service.BackendLeaveGroup:
+		group, err := packet.Group()
+		if err != nil {
+			log.Warning("gate: backend", err)
+			return
+		}
+		if packet.Type == service.BackendJoinGroup {
+			if b.server.GetSession(packet.Session) != nil {
+				b.server.groups.join(group, packet.Session)
+			}
+		} else {
+			b.server.groups.leave(group, packet.Session)
+		}
+	}
+}
+
+// 每个会话的序号不同, payload共用
+func (b *backend) fanout(sessions []*Session, cmd int16, payload []byte) {
+	for _, session := range sessions {
+		session.Send(&Packet{Cmd: cmd, Payload: payload})
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/iakud/plumeserver/service"
+)
+
+func dialTestClients(t *testing.T, game *testGame, addr string, n int) ([]net.Conn, []uint64) {
+	conns := make([]net.Conn, n)
+	sessions := make([]uint64, n)
+	for i := 0; i < n; i++ {
+		conn, err := net.Dial("tcp", addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		clientHandshake(t, conn, 0)
+		conns[i] = conn
+		sessions[i] = game.expect(t, service.BackendConnect).Session
+	}
+	return conns, sessions
+}
+
+func expectPayload(t *testing.T, conn net.Conn, payload string) {
+	conn.SetReadDeadline(time.Now().Add(time.Second * 5))
+	p, err := readPacket(conn, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(p.Payload) != payload {
+		t.Fatalf("unexpected payload %q, expect %q", p.Payload, payload)
+	}
+}
+
+func TestFanout(t *testing.T) {
+	_, game, addr := newTestServer(t, newConfig())
+	conns, sessions := dialTestClients(t, game, addr, 3)
+
+	// 多播给前两个
+	game.conn.Send(service.NewMulticastPacket(sessions[:2], 1, []byte("multicast")).Marshal())
+	expectPayload(t, conns[0], "multicast")
+	expectPayload(t, conns[1], "multicast")
+
+	// 组播给第二个和第三个
+	const group uint64 = 100
+	game.conn.Send(service.NewGroupPacket(service.BackendJoinGroup, sessions[1], group).Marshal())
+	game.conn.Send(service.NewGroupPacket(service.BackendJoinGroup, sessions[2], group).Marshal())
+	packet := &service.BackendPacket{Type: service.BackendGroupcast, Session: group, Cmd: 1, Payload: []byte("groupcast")}
+	game.conn.Send(packet.Marshal())
+	expectPayload(t, conns[1], "groupcast")
+	expectPayload(t, conns[2], "groupcast")
+
+	// 广播给所有人, 第一个客户端没有收到组播
+	packet = &service.BackendPacket{Type: service.BackendBroadcast, Cmd: 1, Payload: []byte("broadcast")}
+	game.conn.Send(packet.Marshal())
+	for _, conn := range conns {
+		expectPayload(t, conn, "broadcast")
+	}
+
+	// game不能下发gate的命令
+	game.send(sessions[0], CmdKeyExchange, []byte("fake"))
+	packet = &service.BackendPacket{Type: service.BackendBroadcast, Cmd: CmdHandshake, Payload: []byte("fake")}
+	game.conn.Send(packet.Marshal())
+	game.send(sessions[0], 1, []byte("data"))
+	expectPayload(t, conns[0], "data")
+}
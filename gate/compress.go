@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"compress/flate"
+	"errors"
+	"io"
+	"sync"
+)
+
+var ErrDecompressTooLarge = errors.New("gate: decompressed packet too large")
+
+var flateWriterPool = sync.Pool{
+	New: func() interface{} {
+		w, _ := flate.NewWriter(nil, flate.BestSpeed)
+		return w
+	},
+}
+
+func compress(payload []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := flateWriterPool.Get().(*flate.Writer)
+	defer flateWriterPool.Put(w)
+	w.Reset(&buf)
+	if _, err := w.Write(payload); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func decompress(payload []byte, maxSize int) ([]byte, error) {
+	r := flate.NewReader(bytes.NewReader(payload))
+	defer r.Close()
+	if maxSize <= 0 {
+		return io.ReadAll(r)
+	}
+	// 限制解压后的大小
+	b, err := io.ReadAll(io.LimitReader(r, int64(maxSize)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(b) > maxSize {
+		return nil, ErrDecompressTooLarge
+	}
+	return b, nil
+}
+
+// 超过阈值的消息才压缩, 压缩后变大则发送原消息
+func compressPacket(p *Packet, threshold int) *Packet {
+	if threshold <= 0 || len(p.Payload) < threshold || p.Flags&flagCompressed != 0 {
+		return p
+	}
+	payload, err := compress(p.Payload)
+	if err != nil || len(payload) >= len(p.Payload) {
+		return p
+	}
+	return &Packet{Flags: p.Flags | flagCompressed, Cmd: p.Cmd, Seq: p.Seq, Payload: payload}
+}
+
+func decompressPacket(p *Packet, maxSize int) error {
+	if p.Flags&flagCompressed == 0 {
+		return nil
+	}
+	payload, err := decompress(p.Payload, maxSize)
+	if err != nil {
+		return err
+	}
+	p.Flags &^= flagCompressed
+	p.Payload = payload
+	return nil
+}
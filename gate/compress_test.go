@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"net"
+	"testing"
+
+	"github.com/iakud/plumeserver/service"
+)
+
+func TestCompressPacket(t *testing.T) {
+	payload := bytes.Repeat([]byte("inventory"), 100)
+	p := compressPacket(&Packet{Cmd: 1, Seq: 1, Payload: payload}, 512)
+	if p.Flags&flagCompressed == 0 || len(p.Payload) >= len(payload) {
+		t.Fatal("packet not compressed")
+	}
+	if err := decompressPacket(p, len(payload)); err != nil {
+		t.Fatal(err)
+	}
+	if p.Flags&flagCompressed != 0 || !bytes.Equal(p.Payload, payload) {
+		t.Fatal("unexpected decompressed payload")
+	}
+	// 低于阈值不压缩
+	if p := compressPacket(&Packet{Payload: []byte("short")}, 512); p.Flags&flagCompressed != 0 {
+		t.Fatal("short packet compressed")
+	}
+	// 解压后超过最大长度
+	p = compressPacket(&Packet{Payload: payload}, 512)
+	if err := decompressPacket(p, len(payload)-1); err != ErrDecompressTooLarge {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestCompressNegotiation(t *testing.T) {
+	srv, game, addr := newTestServer(t, newConfig())
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	_, options := clientHandshake(t, conn, optionCompress)
+	if options&optionCompress == 0 {
+		t.Fatal("compress not negotiated")
+	}
+	connect := game.expect(t, service.BackendConnect)
+	game.login(t, srv, connect.Session)
+
+	// 下发的大消息被压缩
+	payload := bytes.Repeat([]byte("inventory"), 100)
+	game.send(connect.Session, 1, payload)
+	p, err := readPacket(conn, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Flags&flagCompressed == 0 {
+		t.Fatal("packet not compressed")
+	}
+	if err := decompressPacket(p, 0); err != nil || !bytes.Equal(p.Payload, payload) {
+		t.Fatal("unexpected payload")
+	}
+	// 上行的压缩消息解压后转发给game
+	if err := writePacket(conn, compressPacket(&Packet{Cmd: 1, Payload: payload}, 512)); err != nil {
+		t.Fatal(err)
+	}
+	data := game.expect(t, service.BackendData)
+	if !bytes.Equal(data.Payload, payload) {
+		t.Fatal("game received compressed payload")
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"flag"
+	"time"
+)
+
+type Config struct {
+	Addr          string        // 客户端监听地址
+	EncryptAddr   string        // 加密传输的客户端监听地址, 为空不监听
+	KCPAddr       string        // KCP客户端监听地址(UDP), 为空不监听
+	KCPCookie     bool          // KCP新连接先用cookie验证地址, 关闭后兼容标准的ikcp客户端
+	GameAddr      string        // game地址
+	IdleTimeout   time.Duration // 读空闲超时, 超时未收到任何消息则断开
+	WriteTimeout  time.Duration // 写超时
+	MaxPacketSize int           // 单个消息最大长度
+	PendingSend   int           // 发送队列最大长度
+	ResumeTimeout time.Duration // 断线后会话保留时间, 0为不支持重连
+	ResumeBuffer  int           // 缓存的未确认消息数量
+
+	CompressThreshold int // 超过该长度的下发消息压缩, 0为不压缩
+
+	DrainTimeout time.Duration // 关闭时等待会话结束的时间
+	RedirectAddr string        // 关闭时通知客户端重连的地址, 为空由客户端自己选择
+
+	AdminAddr  string // 管理后台监听地址, 为空不开启
+	AdminToken string // 管理后台的bearer token
+
+	MaxConnPerIP int     // 单个IP的并发连接上限, 0为不限制
+	CIDRLimits   string  // 网段的并发连接上限, 如10.0.0.0/8=1000,192.168.0.0/16=50
+	ConnectRate  float64 // 单个IP每秒允许的新连接数, 0为不限制
+	ConnectBurst int     // 单个IP允许的突发连接数
+	BanFile      string  // 封禁列表文件, 为空不保存
+
+	MinVersion uint16 // 支持的最低协议版本
+	MaxVersion uint16 // 支持的最高协议版本
+	MinBuild   uint32 // 允许的最低客户端构建号
+
+	CommandFile string // 命令目录文件, 按会话状态过滤客户端命令, 为空使用内置规则
+	LoginCmds   string // 内置规则中未登录时允许的命令, 如101
+	GMCmds      string // 内置规则中只允许GM发送的命令, 如1401
+	MuteCmds    string // 禁言时不转发的聊天命令, 如1301,1302, 其他命令不受影响
+}
+
+func newConfig() *Config {
+	return &Config{
+		Addr:          ":7000",
+		KCPCookie:     true,
+		GameAddr:      "localhost:7100",
+		IdleTimeout:   time.Second * 30,
+		WriteTimeout:  time.Second * 10,
+		MaxPacketSize: 64 << 10,
+		PendingSend:   1024,
+		ResumeTimeout: time.Second * 30,
+		ResumeBuffer:  256,
+
+		CompressThreshold: 512,
+
+		DrainTimeout: time.Second * 30,
+
+		MaxConnPerIP: 16,
+		ConnectRate:  5,
+		ConnectBurst: 10,
+
+		MinVersion: 1,
+		MaxVersion: 1,
+
+		LoginCmds: "101",
+		GMCmds:    "1401",
+		MuteCmds:  "1301",
+	}
+}
+
+func (c *Config) parseFlags() {
+	flag.StringVar(&c.Addr, "addr", c.Addr, "client listen address")
+	flag.StringVar(&c.EncryptAddr, "encrypt-addr", c.EncryptAddr, "encrypted client listen address")
+	flag.StringVar(&c.KCPAddr, "kcp-addr", c.KCPAddr, "kcp client listen address")
+	flag.BoolVar(&c.KCPCookie, "kcp-cookie", c.KCPCookie, "verify new kcp peers with a cookie, disable for stock kcp clients")
+	flag.StringVar(&c.GameAddr, "game", c.GameAddr, "game server address")
+	flag.DurationVar(&c.IdleTimeout, "idle-timeout", c.IdleTimeout, "read idle timeout")
+	flag.DurationVar(&c.WriteTimeout, "write-timeout", c.WriteTimeout, "write timeout")
+	flag.IntVar(&c.MaxPacketSize, "max-packet-size", c.MaxPacketSize, "max client packet size")
+	flag.IntVar(&c.PendingSend, "pending-send", c.PendingSend, "max pending packets per session")
+	flag.DurationVar(&c.ResumeTimeout, "resume-timeout", c.ResumeTimeout, "session resume window, 0 to disable")
+	flag.IntVar(&c.ResumeBuffer, "resume-buffer", c.ResumeBuffer, "unacknowledged packets kept for resume")
+	flag.IntVar(&c.CompressThreshold, "compress-threshold", c.CompressThreshold, "compress packets larger than this, 0 to disable")
+	flag.DurationVar(&c.DrainTimeout, "drain-timeout", c.DrainTimeout, "grace period for sessions on shutdown")
+	flag.StringVar(&c.RedirectAddr, "redirect-addr", c.RedirectAddr, "address clients reconnect to on shutdown")
+	flag.StringVar(&c.AdminAddr, "admin-addr", c.AdminAddr, "admin http listen address")
+	flag.StringVar(&c.AdminToken, "admin-token", c.AdminToken, "admin bearer token")
+	flag.IntVar(&c.MaxConnPerIP, "max-conn-per-ip", c.MaxConnPerIP, "max concurrent connections per ip, 0 for unlimited")
+	flag.StringVar(&c.CIDRLimits, "cidr-limits", c.CIDRLimits, "max concurrent connections per cidr, e.g. 10.0.0.0/8=1000")
+	flag.Float64Var(&c.ConnectRate, "connect-rate", c.ConnectRate, "new connections per second per ip, 0 for unlimited")
+	flag.IntVar(&c.ConnectBurst, "connect-burst", c.ConnectBurst, "connect burst per ip")
+	flag.StringVar(&c.BanFile, "ban-file", c.BanFile, "ban list file")
+	flag.StringVar(&c.CommandFile, "commands", c.CommandFile, "command catalog file")
+	flag.StringVar(&c.LoginCmds, "login-cmds", c.LoginCmds, "commands allowed before login without a command catalog")
+	flag.StringVar(&c.GMCmds, "gm-cmds", c.GMCmds, "commands only gm sessions may send without a command catalog")
+	flag.StringVar(&c.MuteCmds, "mute-cmds", c.MuteCmds, "chat commands dropped for muted sessions, e.g. 1301,1302")
+	minVersion := flag.Uint("min-version", uint(c.MinVersion), "min supported protocol version")
+	maxVersion := flag.Uint("max-version", uint(c.MaxVersion), "max supported protocol version")
+	minBuild := flag.Uint("min-build", uint(c.MinBuild), "min allowed client build")
+	flag.Parse()
+	c.MinVersion = uint16(*minVersion)
+	c.MaxVersion = uint16(*maxVersion)
+	c.MinBuild = uint32(*minBuild)
+}
@@ -0,0 +1,340 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/netip"
+	"os"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/iakud/plume/log"
+	"github.com/iakud/plumeserver/service"
+)
+
+var (
+	ErrConnectionClosed      = errors.New("gate: connection closed")
+	ErrConnectionPendingFull = errors.New("gate: connection pending send full")
+)
+
+// 客户端连接, 握手或重连成功后绑定到会话
+type connection struct {
+	conn    net.Conn
+	ip      netip.Addr
+	server  *Server
+	encrypt bool // 必须加密传输
+
+	// 只在读goroutine访问
+	session    *Session
+	recvCipher *frameCipher
+
+	bytesIn  atomic.Uint64
+	bytesOut atomic.Uint64
+
+	mutex      sync.Mutex
+	cond       *sync.Cond
+	packets    []*Packet
+	options    uint8
+	sendCipher *frameCipher
+	closed     bool
+	closing    bool
+	reason     uint8
+}
+
+func newConnection(conn net.Conn, ip netip.Addr, server *Server, opts *listenOptions) *connection {
+	c := &connection{
+		conn:    conn,
+		ip:      ip,
+		server:  server,
+		encrypt: opts.encrypt,
+	}
+	c.cond = sync.NewCond(&c.mutex)
+	return c
+}
+
+func (c *connection) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+func (c *connection) serve() {
+	reason := service.ReasonClosed
+	defer func() {
+		if err := recover(); err != nil {
+			const size = 64 << 10
+			buf := make([]byte, size)
+			buf = buf[:runtime.Stack(buf, false)]
+			log.Errorf("gate: panic serving %v: %v\n%s", c.RemoteAddr(), err, buf)
+			reason = service.ReasonError
+		}
+		c.close(reason)
+		c.server.removeConnection(c)
+		if c.session != nil {
+			c.session.detach(c, c.Reason())
+		}
+	}()
+
+	// start write
+	go c.backgroundWrite()
+	defer c.stopBackgroundWrite()
+	// loop read
+	config := c.server.config
+	r := bufio.NewReader(c.conn)
+	for {
+		if config.IdleTimeout > 0 {
+			c.conn.SetReadDeadline(time.Now().Add(config.IdleTimeout))
+		}
+		p, err := readPacket(r, config.MaxPacketSize)
+		if err != nil {
+			switch {
+			case errors.Is(err, os.ErrDeadlineExceeded):
+				reason = service.ReasonIdleTimeout
+			case errors.Is(err, ErrPacketTooLarge):
+				reason = service.ReasonError
+			}
+			return
+		}
+		c.bytesIn.Add(uint64(4 + kPacketHeaderSize + len(p.Payload)))
+		if c.encrypt {
+			if c.recvCipher == nil {
+				// 加密连接第一个消息必须是交换密钥
+				if p.Cmd != CmdKeyExchange || !c.receiveKeyExchange(p) {
+					reason = service.ReasonError
+					return
+				}
+				continue
+			}
+			if err := c.recvCipher.open(p); err != nil {
+				reason = service.ReasonError
+				return
+			}
+		} else if p.Flags&flagEncrypted != 0 {
+			reason = service.ReasonError
+			return
+		}
+		if p.Flags&flagCompressed != 0 {
+			if c.Options()&optionCompress == 0 {
+				reason = service.ReasonError
+				return
+			}
+			if err := decompressPacket(p, config.MaxPacketSize); err != nil {
+				reason = service.ReasonError
+				return
+			}
+		}
+		if !c.receive(p) {
+			reason = service.ReasonError
+			return
+		}
+	}
+}
+
+func (c *connection) receiveKeyExchange(p *Packet) bool {
+	publicKey, recv, send, err := keyExchange(p.Payload)
+	if err != nil {
+		log.Warningf("gate: connection %v key exchange error: %v", c.RemoteAddr(), err)
+		return false
+	}
+	c.recvCipher = recv
+	// 回应以明文发送, 之后的消息都加密
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.packets = append(c.packets, &Packet{Cmd: CmdKeyExchange, Payload: publicKey})
+	c.sendCipher = send
+	c.cond.Signal()
+	return true
+}
+
+func (c *connection) receive(p *Packet) bool {
+	if c.session == nil {
+		return c.receiveHandshake(p)
+	}
+	if !isGateCmd(p.Cmd) {
+		if c.session.isMuted(p.Cmd) {
+			return true
+		}
+		if !c.session.allow(p.Cmd) {
+			log.Warningf("gate: session %v cmd %v not allowed", c.session.id, p.Cmd)
+			return true
+		}
+		c.server.forward(c.session, p)
+		return true
+	}
+	switch p.Cmd {
+	case CmdPing:
+		c.send(&Packet{Cmd: CmdPong, Payload: p.Payload})
+	case CmdAck:
+		c.session.ack(p.Seq)
+	default:
+		log.Warningf("gate: session %v unknown gate cmd %v", c.session.id, p.Cmd)
+	}
+	return true
+}
+
+// 握手前只处理心跳, 握手和重连
+func (c *connection) receiveHandshake(p *Packet) bool {
+	switch p.Cmd {
+	case CmdPing:
+		c.send(&Packet{Cmd: CmdPong, Payload: p.Payload})
+	case CmdHandshake:
+		var h handshake
+		if err := h.unmarshal(p.Payload); err != nil {
+			return false
+		}
+		if result := c.server.checkVersion(&h); result != kHandshakeOK {
+			log.Infof("gate: connection %v version %v build %v rejected, result %v", c.RemoteAddr(), h.version, h.build, result)
+			reply := &handshakeReply{result: result}
+			c.shutdown(&Packet{Cmd: CmdHandshake, Payload: reply.marshal()})
+			return true
+		}
+		session, err := c.server.handshake(c, &h)
+		if err != nil {
+			return false
+		}
+		c.session = session
+	case CmdResume:
+		// payload为token, seq为客户端已收到的最大序号
+		if session := c.server.resume(c, p.Payload, p.Seq); session != nil {
+			c.session = session
+			return true
+		}
+		c.send(&Packet{Cmd: CmdResume, Payload: []byte{kResumeFailed}})
+	default:
+		log.Warningf("gate: connection %v cmd %v before handshake", c.RemoteAddr(), p.Cmd)
+		return false
+	}
+	return true
+}
+
+func (c *connection) backgroundWrite() {
+	defer func() {
+		if err := recover(); err != nil {
+			const size = 64 << 10
+			buf := make([]byte, size)
+			buf = buf[:runtime.Stack(buf, false)]
+			log.Errorf("gate: panic serving %v: %v\n%s", c.RemoteAddr(), err, buf)
+			c.close(service.ReasonError)
+		}
+	}()
+
+	// loop write
+	config := c.server.config
+	w := bufio.NewWriter(c.conn)
+	for closed := false; !closed; {
+		var packets []*Packet
+		var options uint8
+		var sendCipher *frameCipher
+
+		c.mutex.Lock()
+		for !c.closed && len(c.packets) == 0 {
+			c.cond.Wait()
+		}
+		packets, c.packets = c.packets, packets // swap
+		options = c.options
+		sendCipher = c.sendCipher
+		closed = c.closed
+		c.mutex.Unlock()
+
+		if len(packets) == 0 {
+			continue
+		}
+		if config.WriteTimeout > 0 {
+			c.conn.SetWriteDeadline(time.Now().Add(config.WriteTimeout))
+		}
+		for _, p := range packets {
+			if options&optionCompress != 0 {
+				p = compressPacket(p, config.CompressThreshold)
+			}
+			if sendCipher != nil && p.Cmd != CmdKeyExchange {
+				p = sendCipher.seal(p)
+			}
+			if err := writePacket(w, p); err != nil {
+				c.writeError(err)
+				return
+			}
+			c.bytesOut.Add(uint64(4 + kPacketHeaderSize + len(p.Payload)))
+		}
+		if err := w.Flush(); err != nil {
+			c.writeError(err)
+			return
+		}
+	}
+	// 发送完剩余的消息后关闭
+	c.close(service.ReasonClosed)
+}
+
+func (c *connection) writeError(err error) {
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		c.close(service.ReasonWriteTimeout)
+		return
+	}
+	c.close(service.ReasonClosed)
+}
+
+func (c *connection) stopBackgroundWrite() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.closed {
+		return
+	}
+	c.closed = true
+	c.cond.Signal()
+}
+
+func (c *connection) Options() uint8 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.options
+}
+
+func (c *connection) setOptions(options uint8) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.options = options
+}
+
+func (c *connection) send(p *Packet) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.closed {
+		return ErrConnectionClosed
+	}
+	if pendingSend := c.server.config.PendingSend; pendingSend > 0 && len(c.packets) >= pendingSend {
+		return ErrConnectionPendingFull
+	}
+	c.packets = append(c.packets, p)
+	c.cond.Signal()
+	return nil
+}
+
+// 发送最后一个消息后关闭连接
+func (c *connection) shutdown(p *Packet) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.closed {
+		return
+	}
+	c.packets = append(c.packets, p)
+	c.closed = true
+	c.cond.Signal()
+}
+
+// 关闭连接, 只记录第一次关闭的原因
+func (c *connection) close(reason uint8) {
+	c.mutex.Lock()
+	if !c.closing {
+		c.closing = true
+		c.reason = reason
+	}
+	c.mutex.Unlock()
+
+	c.conn.Close()
+}
+
+func (c *connection) Reason() uint8 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.reason
+}
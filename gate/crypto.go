@@ -0,0 +1,99 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+)
+
+// 加密连接先用X25519交换密钥, 之后每个方向用独立的AES-GCM密钥加密消息,
+// nonce为该方向的消息计数, 重放或乱序的消息无法解密
+const kPublicKeySize = 32
+
+var ErrDecrypt = errors.New("gate: decrypt packet error")
+
+type frameCipher struct {
+	aead  cipher.AEAD
+	count uint64
+}
+
+func newFrameCipher(key []byte) (*frameCipher, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &frameCipher{aead: aead}, nil
+}
+
+func (fc *frameCipher) nextNonce() []byte {
+	nonce := make([]byte, fc.aead.NonceSize())
+	binary.BigEndian.PutUint64(nonce[len(nonce)-8:], fc.count)
+	fc.count++
+	return nonce
+}
+
+// 加密cmd, seq和payload, 消息头只保留flags
+func (fc *frameCipher) seal(p *Packet) *Packet {
+	b := make([]byte, 6+len(p.Payload))
+	binary.BigEndian.PutUint16(b, uint16(p.Cmd))
+	binary.BigEndian.PutUint32(b[2:], p.Seq)
+	copy(b[6:], p.Payload)
+	flags := p.Flags | flagEncrypted
+	return &Packet{Flags: flags, Payload: fc.aead.Seal(nil, fc.nextNonce(), b, []byte{flags})}
+}
+
+func (fc *frameCipher) open(p *Packet) error {
+	if p.Flags&flagEncrypted == 0 {
+		return ErrDecrypt
+	}
+	b, err := fc.aead.Open(nil, fc.nextNonce(), p.Payload, []byte{p.Flags})
+	if err != nil || len(b) < 6 {
+		return ErrDecrypt
+	}
+	p.Flags &^= flagEncrypted
+	p.Cmd = int16(binary.BigEndian.Uint16(b))
+	p.Seq = binary.BigEndian.Uint32(b[2:])
+	p.Payload = b[6:]
+	return nil
+}
+
+func deriveKey(secret []byte, label string) []byte {
+	h := sha256.New()
+	h.Write(secret)
+	h.Write([]byte(label))
+	return h.Sum(nil)
+}
+
+// 生成gate的临时密钥, 返回gate公钥和收发两个方向的加密器
+func keyExchange(peerPublicKey []byte) ([]byte, *frameCipher, *frameCipher, error) {
+	curve := ecdh.X25519()
+	peer, err := curve.NewPublicKey(peerPublicKey)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	private, err := curve.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	secret, err := private.ECDH(peer)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	recv, err := newFrameCipher(deriveKey(secret, "client"))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	send, err := newFrameCipher(deriveKey(secret, "server"))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return private.PublicKey().Bytes(), recv, send, nil
+}
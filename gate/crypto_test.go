@@ -0,0 +1,158 @@
+package main
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/iakud/plumeserver/service"
+)
+
+// 客户端交换密钥, 返回收发两个方向的加密器
+func clientKeyExchange(t *testing.T, conn net.Conn) (*frameCipher, *frameCipher) {
+	private, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writePacket(conn, &Packet{Cmd: CmdKeyExchange, Payload: private.PublicKey().Bytes()}); err != nil {
+		t.Fatal(err)
+	}
+	p, err := readPacket(conn, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Cmd != CmdKeyExchange || len(p.Payload) != kPublicKeySize {
+		t.Fatalf("unexpected key exchange: %v", p)
+	}
+	peer, err := ecdh.X25519().NewPublicKey(p.Payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret, err := private.ECDH(peer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recv, err := newFrameCipher(deriveKey(secret, "server"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	send, err := newFrameCipher(deriveKey(secret, "client"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return recv, send
+}
+
+func TestEncryptedConnection(t *testing.T) {
+	srv, game, addr := newTestServer(t, newConfig(), WithEncrypt())
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	recv, send := clientKeyExchange(t, conn)
+
+	// 握手也是加密的
+	if err := writePacket(conn, send.seal(&Packet{Cmd: CmdHandshake, Payload: (&handshake{version: 1}).marshal()})); err != nil {
+		t.Fatal(err)
+	}
+	p, err := readPacket(conn, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Cmd != 0 || p.Flags&flagEncrypted == 0 {
+		t.Fatalf("handshake reply not encrypted: %v", p)
+	}
+	if err := recv.open(p); err != nil || p.Cmd != CmdHandshake {
+		t.Fatalf("unexpected handshake reply: %v, %v", p, err)
+	}
+	connect := game.expect(t, service.BackendConnect)
+	game.login(t, srv, connect.Session)
+
+	game.send(connect.Session, 1, []byte("secret"))
+	p, err = readPacket(conn, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := recv.open(p); err != nil || p.Cmd != 1 || p.Seq != 1 || string(p.Payload) != "secret" {
+		t.Fatalf("unexpected packet: %v, %v", p, err)
+	}
+
+	sealed := send.seal(&Packet{Cmd: 1, Payload: []byte("data")})
+	if err := writePacket(conn, sealed); err != nil {
+		t.Fatal(err)
+	}
+	data := game.expect(t, service.BackendData)
+	if string(data.Payload) != "data" {
+		t.Fatalf("unexpected payload: %v", data.Payload)
+	}
+	// 重放的消息被拒绝, 连接断开
+	if err := writePacket(conn, sealed); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readPacket(conn, 0); err != io.EOF {
+		t.Fatalf("replayed packet accepted: %v", err)
+	}
+}
+
+func TestEncryptRequired(t *testing.T) {
+	_, _, addr := newTestServer(t, newConfig(), WithEncrypt())
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if err := writePacket(conn, &Packet{Cmd: CmdHandshake, Payload: (&handshake{version: 1}).marshal()}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readPacket(conn, 0); err != io.EOF {
+		t.Fatalf("plain handshake accepted: %v", err)
+	}
+}
+
+// 加密的会话不能从明文监听重连
+func TestResumeDowngrade(t *testing.T) {
+	srv, _, addr := newTestServer(t, newConfig())
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Serve(ln, WithEncrypt())
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	recv, send := clientKeyExchange(t, conn)
+	if err := writePacket(conn, send.seal(&Packet{Cmd: CmdHandshake, Payload: (&handshake{version: 1}).marshal()})); err != nil {
+		t.Fatal(err)
+	}
+	p, err := readPacket(conn, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var reply handshakeReply
+	if err := recv.open(p); err != nil || reply.unmarshal(p.Payload) != nil || reply.result != kHandshakeOK {
+		t.Fatalf("unexpected handshake reply: %v, %v", p, err)
+	}
+	conn.Close()
+	time.Sleep(time.Millisecond * 100)
+
+	plain, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer plain.Close()
+	if err := writePacket(plain, &Packet{Cmd: CmdResume, Payload: []byte(reply.token)}); err != nil {
+		t.Fatal(err)
+	}
+	p, err = readPacket(plain, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Cmd != CmdResume || p.Payload[0] != kResumeFailed {
+		t.Fatalf("unexpected resume: %v", p)
+	}
+}
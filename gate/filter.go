@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/iakud/plumeserver/service"
+)
+
+// 按会话状态过滤客户端命令, 默认拒绝
+// 配置命令目录时只允许目录中的命令, 否则使用内置规则:
+// 未登录只能发送登录命令, GM命令只允许GM发送, 其他命令需要登录
+type commandFilter struct {
+	masks    map[int16]uint8
+	fallback uint8          // 不在masks中的命令允许的状态
+	mutes    map[int16]bool // 禁言时不转发的命令
+}
+
+func loadCommandFilter(config *Config) (*commandFilter, error) {
+	mutes, err := parseCmds(config.MuteCmds)
+	if err != nil {
+		return nil, err
+	}
+	if config.CommandFile == "" {
+		return defaultCommandFilter(config.LoginCmds, config.GMCmds, mutes)
+	}
+	catalog, err := service.LoadCommands(config.CommandFile)
+	if err != nil {
+		return nil, err
+	}
+	masks := make(map[int16]uint8, len(catalog))
+	for cmd, command := range catalog {
+		mask, err := command.StateMask()
+		if err != nil {
+			return nil, err
+		}
+		masks[cmd] = mask
+	}
+	return &commandFilter{masks: masks, mutes: mutes}, nil
+}
+
+// 内置规则, 登录命令在登录后也可以发送, 用于切换玩家
+func defaultCommandFilter(loginCmds, gmCmds string, mutes map[int16]bool) (*commandFilter, error) {
+	logins, err := parseCmds(loginCmds)
+	if err != nil {
+		return nil, err
+	}
+	gms, err := parseCmds(gmCmds)
+	if err != nil {
+		return nil, err
+	}
+	const (
+		guest  = 1 << service.StateGuest
+		player = 1 << service.StatePlayer
+		gm     = 1 << service.StateGM
+	)
+	masks := make(map[int16]uint8, len(logins)+len(gms))
+	for cmd := range gms {
+		masks[cmd] = gm
+	}
+	for cmd := range logins {
+		masks[cmd] = guest | player | gm
+	}
+	return &commandFilter{masks: masks, fallback: player | gm, mutes: mutes}, nil
+}
+
+func parseCmds(s string) (map[int16]bool, error) {
+	cmds := make(map[int16]bool)
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		cmd, err := strconv.ParseInt(item, 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("gate: invalid cmd %q", item)
+		}
+		cmds[int16(cmd)] = true
+	}
+	return cmds, nil
+}
+
+// 目录中没有的命令不允许发送
+func (f *commandFilter) allow(state uint8, cmd int16) bool {
+	if !service.ValidState(state) {
+		return false
+	}
+	mask, ok := f.masks[cmd]
+	if !ok {
+		mask = f.fallback
+	}
+	return mask&(1<<state) != 0
+}
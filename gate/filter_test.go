@@ -0,0 +1,126 @@
+package main
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/iakud/plumeserver/service"
+)
+
+const testCommands = `[
+	{"cmd": 1, "name": "login", "states": ["guest"]},
+	{"cmd": 2, "name": "move", "states": ["player", "gm"]},
+	{"cmd": 3, "name": "gm", "states": ["gm"]}
+]`
+
+func TestCommandFilter(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "commands.json")
+	if err := os.WriteFile(filename, []byte(testCommands), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config := newConfig()
+	config.CommandFile = filename
+	_, game, addr := newTestServer(t, config)
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	clientHandshake(t, conn, 0)
+	connect := game.expect(t, service.BackendConnect)
+
+	// 未登录只能发送登录命令
+	for _, cmd := range []int16{2, 3, 4, 1} {
+		if err := writePacket(conn, &Packet{Cmd: cmd}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if data := game.expect(t, service.BackendData); data.Cmd != 1 {
+		t.Fatalf("unexpected cmd %v", data.Cmd)
+	}
+
+	state := &service.BackendPacket{Type: service.BackendState, Session: connect.Session, Payload: []byte{service.StatePlayer}}
+	game.conn.Send(state.Marshal())
+	time.Sleep(time.Millisecond * 100)
+	for _, cmd := range []int16{1, 3, 2} {
+		if err := writePacket(conn, &Packet{Cmd: cmd}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if data := game.expect(t, service.BackendData); data.Cmd != 2 {
+		t.Fatalf("unexpected cmd %v", data.Cmd)
+	}
+
+	// 超出掩码范围的状态被忽略, 会话保持玩家状态
+	state = &service.BackendPacket{Type: service.BackendState, Session: connect.Session, Payload: []byte{8}}
+	game.conn.Send(state.Marshal())
+	time.Sleep(time.Millisecond * 100)
+	if err := writePacket(conn, &Packet{Cmd: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if data := game.expect(t, service.BackendData); data.Cmd != 2 {
+		t.Fatalf("unexpected cmd %v", data.Cmd)
+	}
+	f := &commandFilter{masks: map[int16]uint8{2: 0xff}}
+	if f.allow(8, 2) || !f.allow(7, 2) {
+		t.Fatal("unexpected state mask")
+	}
+}
+
+func TestLoadCommandFilter(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "commands.json")
+	if err := os.WriteFile(filename, []byte(`[{"cmd": 1, "states": ["admin"]}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config := newConfig()
+	config.CommandFile = filename
+	if _, err := loadCommandFilter(config); err != service.ErrCommandState {
+		t.Fatalf("unexpected error %v", err)
+	}
+	config = newConfig()
+	config.MuteCmds = "1301,chat"
+	if _, err := loadCommandFilter(config); err == nil {
+		t.Fatal("invalid mute cmds accepted")
+	}
+	config.MuteCmds = " 1301, 1302"
+	filter, err := loadCommandFilter(config)
+	if err != nil || !filter.mutes[1301] || !filter.mutes[1302] || filter.mutes[1] {
+		t.Fatalf("unexpected mute cmds %v %v", filter.mutes, err)
+	}
+}
+
+// 没有命令目录时使用内置规则, 不会全部放行
+func TestDefaultCommandFilter(t *testing.T) {
+	filter, err := loadCommandFilter(newConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		state uint8
+		cmd   int16
+		allow bool
+	}{
+		{service.StateGuest, 101, true},
+		{service.StateGuest, 1001, false},
+		{service.StateGuest, 1401, false},
+		{service.StatePlayer, 101, true},
+		{service.StatePlayer, 1001, true},
+		{service.StatePlayer, 1401, false},
+		{service.StateGM, 1001, true},
+		{service.StateGM, 1401, true},
+		{8, 101, false},
+	}
+	for _, test := range tests {
+		if filter.allow(test.state, test.cmd) != test.allow {
+			t.Fatalf("state %v cmd %v allow %v", test.state, test.cmd, !test.allow)
+		}
+	}
+	config := newConfig()
+	config.GMCmds = "gm"
+	if _, err := loadCommandFilter(config); err == nil {
+		t.Fatal("invalid gm cmds accepted")
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"sync"
+)
+
+// 会话分组(公会, 场景), 由game管理成员
+type groups struct {
+	mutex    sync.Mutex
+	groups   map[uint64]map[uint64]struct{} // group -> sessions
+	sessions map[uint64]map[uint64]struct{} // session -> groups
+}
+
+func newGroups() *groups {
+	return &groups{
+		groups:   make(map[uint64]map[uint64]struct{}),
+		sessions: make(map[uint64]map[uint64]struct{}),
+	}
+}
+
+func (g *groups) join(group uint64, session uint64) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	members, ok := g.groups[group]
+	if !ok {
+		members = make(map[uint64]struct{})
+		g.groups[group] = members
+	}
+	members[session] = struct{}{}
+	joined, ok := g.sessions[session]
+	if !ok {
+		joined = make(map[uint64]struct{})
+		g.sessions[session] = joined
+	}
+	joined[group] = struct{}{}
+}
+
+func (g *groups) leave(group uint64, session uint64) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.remove(group, session)
+	if joined, ok := g.sessions[session]; ok {
+		delete(joined, group)
+		if len(joined) == 0 {
+			delete(g.sessions, session)
+		}
+	}
+}
+
+// 会话关闭时离开所有组
+func (g *groups) leaveAll(session uint64) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	for group := range g.sessions[session] {
+		g.remove(group, session)
+	}
+	delete(g.sessions, session)
+}
+
+func (g *groups) remove(group uint64, session uint64) {
+	members, ok := g.groups[group]
+	if !ok {
+		return
+	}
+	delete(members, session)
+	if len(members) == 0 {
+		delete(g.groups, group)
+	}
+}
+
+func (g *groups) members(group uint64) []uint64 {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	members := g.groups[group]
+	sessions := make([]uint64, 0, len(members))
+	for session := range members {
+		sessions = append(sessions, session)
+	}
+	return sessions
+}
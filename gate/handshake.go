@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// 握手选项, 客户端请求的选项和gate支持的选项取交集
+const (
+	optionCompress uint8 = 1 << iota // 消息压缩
+)
+
+// 握手结果
+const (
+	kHandshakeOK          uint8 = iota
+	kHandshakeUpdate            // 客户端版本过低, 请更新
+	kHandshakeUnsupported       // 客户端版本高于gate支持的版本
+)
+
+var ErrHandshake = errors.New("gate: handshake error")
+
+// 客户端握手: options(1) | version(2) | build(4)
+type handshake struct {
+	options uint8
+	version uint16 // 协议版本
+	build   uint32 // 客户端构建号
+}
+
+func (h *handshake) marshal() []byte {
+	b := make([]byte, 7)
+	b[0] = h.options
+	binary.BigEndian.PutUint16(b[1:], h.version)
+	binary.BigEndian.PutUint32(b[3:], h.build)
+	return b
+}
+
+func (h *handshake) unmarshal(b []byte) error {
+	if len(b) < 7 {
+		return ErrHandshake
+	}
+	h.options = b[0]
+	h.version = binary.BigEndian.Uint16(b[1:])
+	h.build = binary.BigEndian.Uint32(b[3:])
+	return nil
+}
+
+// gate回应握手: result(1) | token(16) | options(1) | version(2)
+// 握手失败时只有result, 之后gate断开连接
+type handshakeReply struct {
+	result  uint8
+	token   string
+	options uint8
+	version uint16
+}
+
+func (h *handshakeReply) marshal() []byte {
+	if h.result != kHandshakeOK {
+		return []byte{h.result}
+	}
+	b := make([]byte, 0, 1+kTokenSize+3)
+	b = append(b, h.result)
+	b = append(b, h.token...)
+	b = append(b, h.options)
+	b = binary.BigEndian.AppendUint16(b, h.version)
+	return b
+}
+
+func (h *handshakeReply) unmarshal(b []byte) error {
+	if len(b) < 1 {
+		return ErrHandshake
+	}
+	h.result = b[0]
+	if h.result != kHandshakeOK {
+		return nil
+	}
+	if len(b) < 1+kTokenSize+3 {
+		return ErrHandshake
+	}
+	h.token = string(b[1 : 1+kTokenSize])
+	h.options = b[1+kTokenSize]
+	h.version = binary.BigEndian.Uint16(b[2+kTokenSize:])
+	return nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/iakud/plumeserver/internal/lossy"
+	"github.com/iakud/plumeserver/kcp"
+	"github.com/iakud/plumeserver/service"
+)
+
+func TestKCPSession(t *testing.T) {
+	srv, game, _ := newTestServer(t, newConfig())
+	ln := kcp.NewListener(lossy.Listen(t, 0.1, 1))
+	go srv.Serve(ln)
+
+	conn := kcp.NewConn(1, lossy.Listen(t, 0.1, 2), ln.Addr())
+	defer conn.Close()
+	clientHandshake(t, conn, 0)
+	connect := game.expect(t, service.BackendConnect)
+	game.login(t, srv, connect.Session)
+
+	payload := make([]byte, 16*1024)
+	rand.New(rand.NewSource(3)).Read(payload)
+	for i := 0; i < 10; i++ {
+		if err := writePacket(conn, &Packet{Cmd: 1, Payload: payload}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 10; i++ {
+		data := game.expect(t, service.BackendData)
+		if data.Session != connect.Session || !bytes.Equal(data.Payload, payload) {
+			t.Fatalf("unexpected data: session=%v, size=%v", data.Session, len(data.Payload))
+		}
+	}
+
+	for i := 0; i < 10; i++ {
+		game.send(connect.Session, 2, payload)
+	}
+	for i := 0; i < 10; i++ {
+		p, err := readPacket(conn, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.Cmd != 2 || p.Seq != uint32(i+1) || !bytes.Equal(p.Payload, payload) {
+			t.Fatalf("unexpected packet: cmd=%v, seq=%v", p.Cmd, p.Seq)
+		}
+	}
+}
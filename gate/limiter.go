@@ -0,0 +1,314 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrBanned             = errors.New("gate: address banned")
+	ErrTooManyConnections = errors.New("gate: too many connections")
+	ErrConnectRateLimited = errors.New("gate: connect rate limited")
+	ErrBanDuration        = errors.New("gate: invalid ban duration")
+)
+
+// 永久封禁, 其他封禁的时长必须大于零
+const BanForever time.Duration = 0
+
+const kBucketSweepInterval = 1024
+
+// 网段的并发连接上限
+type cidrLimit struct {
+	prefix netip.Prefix
+	max    int
+	count  int
+}
+
+type Ban struct {
+	Target string    `json:"target"`
+	Expire time.Time `json:"expire,omitempty"` // 为零永久封禁
+}
+
+// 连接速率令牌桶
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// 按IP和网段限制连接, 封禁列表可以在运行时修改并保存到文件
+type limiter struct {
+	maxPerIP int
+	rate     float64 // 每秒允许的连接数
+	burst    int
+	banFile  string
+
+	mutex   sync.Mutex
+	conns   map[netip.Addr]int
+	cidrs   []*cidrLimit
+	buckets map[netip.Addr]*bucket
+	allows  int
+	bans    map[netip.Prefix]time.Time
+}
+
+func newLimiter(config *Config) (*limiter, error) {
+	l := &limiter{
+		maxPerIP: config.MaxConnPerIP,
+		rate:     config.ConnectRate,
+		burst:    config.ConnectBurst,
+		banFile:  config.BanFile,
+		conns:    make(map[netip.Addr]int),
+		buckets:  make(map[netip.Addr]*bucket),
+		bans:     make(map[netip.Prefix]time.Time),
+	}
+	if l.burst <= 0 {
+		l.burst = 1
+	}
+	cidrs, err := parseCIDRLimits(config.CIDRLimits)
+	if err != nil {
+		return nil, err
+	}
+	l.cidrs = cidrs
+	if err := l.load(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// 格式: 10.0.0.0/8=1000,192.168.0.0/16=50
+func parseCIDRLimits(s string) ([]*cidrLimit, error) {
+	var cidrs []*cidrLimit
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		cidr, max, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("gate: invalid cidr limit %q", item)
+		}
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return nil, err
+		}
+		n, err := strconv.Atoi(max)
+		if err != nil {
+			return nil, err
+		}
+		cidrs = append(cidrs, &cidrLimit{prefix: prefix.Masked(), max: n})
+	}
+	return cidrs, nil
+}
+
+func addrIP(addr net.Addr) (netip.Addr, bool) {
+	ap, err := netip.ParseAddrPort(addr.String())
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return ap.Addr().Unmap(), true
+}
+
+// 解析IP或网段
+func parseTarget(target string) (netip.Prefix, error) {
+	if strings.Contains(target, "/") {
+		prefix, err := netip.ParsePrefix(target)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		return prefix.Masked(), nil
+	}
+	ip, err := netip.ParseAddr(target)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	ip = ip.Unmap()
+	return netip.PrefixFrom(ip, ip.BitLen()), nil
+}
+
+// 检查是否允许连接, 允许后必须调用release
+func (l *limiter) allow(ip netip.Addr) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	now := time.Now()
+	if l.isBanned(ip, now) {
+		return ErrBanned
+	}
+	if !l.take(ip, now) {
+		return ErrConnectRateLimited
+	}
+	if l.maxPerIP > 0 && l.conns[ip] >= l.maxPerIP {
+		return ErrTooManyConnections
+	}
+	for _, cidr := range l.cidrs {
+		if cidr.prefix.Contains(ip) && cidr.count >= cidr.max {
+			return ErrTooManyConnections
+		}
+	}
+	l.conns[ip]++
+	for _, cidr := range l.cidrs {
+		if cidr.prefix.Contains(ip) {
+			cidr.count++
+		}
+	}
+	return nil
+}
+
+func (l *limiter) release(ip netip.Addr) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if n := l.conns[ip]; n > 1 {
+		l.conns[ip] = n - 1
+	} else {
+		delete(l.conns, ip)
+	}
+	for _, cidr := range l.cidrs {
+		if cidr.prefix.Contains(ip) {
+			cidr.count--
+		}
+	}
+}
+
+func (l *limiter) take(ip netip.Addr, now time.Time) bool {
+	if l.rate <= 0 {
+		return true
+	}
+	l.allows++
+	if l.allows%kBucketSweepInterval == 0 {
+		l.sweep(now)
+	}
+	b, ok := l.buckets[ip]
+	if !ok {
+		b = &bucket{tokens: float64(l.burst), last: now}
+		l.buckets[ip] = b
+	}
+	b.tokens += now.Sub(b.last).Seconds() * l.rate
+	if b.tokens > float64(l.burst) {
+		b.tokens = float64(l.burst)
+	}
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// 清理已经恢复满的令牌桶
+func (l *limiter) sweep(now time.Time) {
+	for ip, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= float64(l.burst) {
+			delete(l.buckets, ip)
+		}
+	}
+}
+
+func (l *limiter) isBanned(ip netip.Addr, now time.Time) bool {
+	for prefix, expire := range l.bans {
+		if !prefix.Contains(ip) {
+			continue
+		}
+		if expire.IsZero() || now.Before(expire) {
+			return true
+		}
+		delete(l.bans, prefix)
+	}
+	return false
+}
+
+// duration为0永久封禁
+func (l *limiter) ban(target string, duration time.Duration) (netip.Prefix, error) {
+	if duration < 0 {
+		return netip.Prefix{}, ErrBanDuration
+	}
+	prefix, err := parseTarget(target)
+	if err != nil {
+		return prefix, err
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	var expire time.Time
+	if duration != BanForever {
+		expire = time.Now().Add(duration)
+	}
+	l.bans[prefix] = expire
+	return prefix, l.save()
+}
+
+func (l *limiter) unban(target string) error {
+	prefix, err := parseTarget(target)
+	if err != nil {
+		return err
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	delete(l.bans, prefix)
+	return l.save()
+}
+
+func (l *limiter) banList() []Ban {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.list(time.Now())
+}
+
+func (l *limiter) list(now time.Time) []Ban {
+	bans := make([]Ban, 0, len(l.bans))
+	for prefix, expire := range l.bans {
+		if !expire.IsZero() && !now.Before(expire) {
+			delete(l.bans, prefix)
+			continue
+		}
+		bans = append(bans, Ban{Target: prefix.String(), Expire: expire})
+	}
+	sort.Slice(bans, func(i, j int) bool { return bans[i].Target < bans[j].Target })
+	return bans
+}
+
+func (l *limiter) load() error {
+	if l.banFile == "" {
+		return nil
+	}
+	b, err := os.ReadFile(l.banFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	var bans []Ban
+	if err := json.Unmarshal(b, &bans); err != nil {
+		return err
+	}
+	for _, ban := range bans {
+		prefix, err := parseTarget(ban.Target)
+		if err != nil {
+			return err
+		}
+		l.bans[prefix] = ban.Expire
+	}
+	return nil
+}
+
+// 先写临时文件再替换, 避免写一半
+func (l *limiter) save() error {
+	if l.banFile == "" {
+		return nil
+	}
+	b, err := json.MarshalIndent(l.list(time.Now()), "", "\t")
+	if err != nil {
+		return err
+	}
+	tmp := l.banFile + ".tmp"
+	if err := os.WriteFile(tmp, b, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, l.banFile)
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"net/netip"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLimiterConnections(t *testing.T) {
+	config := newConfig()
+	config.MaxConnPerIP = 2
+	config.CIDRLimits = "10.0.0.0/24=3"
+	config.ConnectRate = 0
+	l, err := newLimiter(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ip1 := netip.MustParseAddr("10.0.0.1")
+	ip2 := netip.MustParseAddr("10.0.0.2")
+	if l.allow(ip1) != nil || l.allow(ip1) != nil {
+		t.Fatal("connection refused")
+	}
+	if err := l.allow(ip1); err != ErrTooManyConnections {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// 网段上限
+	if err := l.allow(ip2); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.allow(ip2); err != ErrTooManyConnections {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := l.allow(netip.MustParseAddr("10.0.1.1")); err != nil {
+		t.Fatal(err)
+	}
+	l.release(ip1)
+	if err := l.allow(ip2); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLimiterRate(t *testing.T) {
+	config := newConfig()
+	config.MaxConnPerIP = 0
+	config.ConnectRate = 1000
+	config.ConnectBurst = 3
+	l, err := newLimiter(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ip := netip.MustParseAddr("192.168.1.1")
+	for i := 0; i < 3; i++ {
+		if err := l.allow(ip); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := l.allow(ip); err != ErrConnectRateLimited {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	time.Sleep(time.Millisecond * 10)
+	if err := l.allow(ip); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLimiterBan(t *testing.T) {
+	config := newConfig()
+	config.BanFile = filepath.Join(t.TempDir(), "bans.json")
+	l, err := newLimiter(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.ban("172.16.0.0/16", BanForever); err != nil {
+		t.Fatal(err)
+	}
+	// 负数时长不会变成永久封禁
+	if _, err := l.ban("9.9.9.9", -time.Hour); err != ErrBanDuration {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := l.allow(netip.MustParseAddr("9.9.9.9")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.ban("8.8.8.8", time.Millisecond*50); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.allow(netip.MustParseAddr("172.16.3.4")); err != ErrBanned {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := l.allow(netip.MustParseAddr("8.8.8.8")); err != ErrBanned {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// 从文件恢复封禁列表
+	l, err = newLimiter(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bans := l.banList(); len(bans) != 2 || bans[0].Target != "172.16.0.0/16" || bans[1].Target != "8.8.8.8/32" {
+		t.Fatalf("unexpected bans: %v", bans)
+	}
+	// 过期自动解封
+	time.Sleep(time.Millisecond * 60)
+	if err := l.allow(netip.MustParseAddr("8.8.8.8")); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.unban("172.16.0.0/16"); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.allow(netip.MustParseAddr("172.16.3.4")); err != nil {
+		t.Fatal(err)
+	}
+}
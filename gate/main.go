@@ -1,5 +1,85 @@
 package main
 
-func main() {
+import (
+	"context"
+
+	"github.com/iakud/plume"
+	"github.com/iakud/plume/log"
+)
+
+type GateApp struct {
+	config *Config
+	server *Server
+	admin  *adminServer
+
+	cancel context.CancelFunc
+}
+
+func (gate *GateApp) Init() {
+	log.Info("gate init")
+	server, err := NewServer(gate.config)
+	if err != nil {
+		log.Fatal("gate: ", err)
+	}
+	gate.server = server
+	if gate.config.AdminAddr != "" {
+		gate.admin = newAdminServer(gate.server, gate.config.AdminToken)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	gate.cancel = cancel
+	go gate.Run(ctx)
+}
+
+func (gate *GateApp) Run(ctx context.Context) {
+	log.Info("gate run")
+	go func() {
+		<-ctx.Done()
+		gate.server.Close()
+		if gate.admin != nil {
+			gate.admin.Close()
+		}
+	}()
+	if gate.admin != nil {
+		go func() {
+			if err := gate.admin.ListenAndServe(gate.config.AdminAddr); err != nil {
+				log.Error("gate: admin ", err)
+			}
+		}()
+	}
+	if addr := gate.config.EncryptAddr; addr != "" {
+		go gate.listenAndServe(addr, WithEncrypt())
+	}
+	if addr := gate.config.KCPAddr; addr != "" {
+		go gate.listenAndServeKCP(addr)
+	}
+	gate.listenAndServe(gate.config.Addr)
+}
 
+func (gate *GateApp) listenAndServe(addr string, o ...ListenOption) {
+	gate.serve(gate.server.ListenAndServe(addr, o...))
+}
+
+func (gate *GateApp) listenAndServeKCP(addr string) {
+	gate.serve(gate.server.ListenAndServeKCP(addr))
+}
+
+func (gate *GateApp) serve(err error) {
+	if err != nil && err != ErrServerClosed {
+		log.Error("gate: ", err)
+		plume.Shutdown()
+	}
+}
+
+func (gate *GateApp) Shutdown() {
+	log.Info("gate shutdown")
+	gate.server.Drain(gate.config.DrainTimeout, gate.config.RedirectAddr)
+	gate.cancel()
+}
+
+func main() {
+	config := newConfig()
+	config.parseFlags()
+	services := plume.WithServices(&GateApp{config: config})
+	plume.Run(services)
 }
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// 客户端消息格式: size(4) | flags(1) | cmd(2) | seq(4) | payload
+// seq为gate下发消息的序号, 用于断线重连后补发
+const kPacketHeaderSize = 1 + 2 + 4
+
+// 消息头flags
+const (
+	flagCompressed uint8 = 1 << iota // payload已压缩
+	flagEncrypted                    // cmd, seq和payload已加密
+)
+
+// 网关命令, cmd小于0的消息由gate处理, 不转发给game
+const (
+	CmdPing        int16 = -1 - iota // 心跳
+	CmdPong                          // 心跳回应
+	CmdHandshake                     // 握手, 建立新会话
+	CmdResume                        // 断线重连, 恢复会话
+	CmdAck                           // 确认收到的消息序号
+	CmdKeyExchange                   // 加密连接交换密钥
+	CmdRedirect                      // gate即将关闭, 通知客户端重连到其他地址
+)
+
+var ErrPacketTooLarge = errors.New("gate: packet too large")
+
+type Packet struct {
+	Flags   uint8
+	Cmd     int16
+	Seq     uint32
+	Payload []byte
+}
+
+func isGateCmd(cmd int16) bool {
+	return cmd < 0
+}
+
+func readPacket(r io.Reader, maxSize int) (*Packet, error) {
+	h := make([]byte, 4)
+	if _, err := io.ReadFull(r, h); err != nil {
+		return nil, err
+	}
+	n := int(binary.BigEndian.Uint32(h))
+	if n < kPacketHeaderSize || (maxSize > 0 && n > maxSize) {
+		return nil, ErrPacketTooLarge
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, err
+	}
+	p := &Packet{
+		Flags:   b[0],
+		Cmd:     int16(binary.BigEndian.Uint16(b[1:])),
+		Seq:     binary.BigEndian.Uint32(b[3:]),
+		Payload: b[kPacketHeaderSize:],
+	}
+	return p, nil
+}
+
+func writePacket(w io.Writer, p *Packet) error {
+	h := make([]byte, 4+kPacketHeaderSize)
+	binary.BigEndian.PutUint32(h, uint32(kPacketHeaderSize+len(p.Payload)))
+	h[4] = p.Flags
+	binary.BigEndian.PutUint16(h[5:], uint16(p.Cmd))
+	binary.BigEndian.PutUint32(h[7:], p.Seq)
+	if _, err := w.Write(h); err != nil {
+		return err
+	}
+	if _, err := w.Write(p.Payload); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package main
+
+// 未确认的下发消息, 写满后覆盖最早的消息
+type ringBuffer struct {
+	packets []*Packet
+	head    int
+	size    int
+}
+
+func newRingBuffer(capacity int) *ringBuffer {
+	if capacity <= 0 {
+		capacity = 1
+	}
+	return &ringBuffer{packets: make([]*Packet, capacity)}
+}
+
+func (rb *ringBuffer) Len() int {
+	return rb.size
+}
+
+func (rb *ringBuffer) push(p *Packet) {
+	capacity := len(rb.packets)
+	if rb.size == capacity {
+		rb.packets[rb.head] = nil
+		rb.head = (rb.head + 1) % capacity
+		rb.size--
+	}
+	rb.packets[(rb.head+rb.size)%capacity] = p
+	rb.size++
+}
+
+// 丢弃序号不大于seq的消息
+func (rb *ringBuffer) ack(seq uint32) {
+	capacity := len(rb.packets)
+	for rb.size > 0 && !seqAfter(rb.packets[rb.head].Seq, seq) {
+		rb.packets[rb.head] = nil
+		rb.head = (rb.head + 1) % capacity
+		rb.size--
+	}
+}
+
+// 序号大于seq的消息
+func (rb *ringBuffer) since(seq uint32) []*Packet {
+	capacity := len(rb.packets)
+	var packets []*Packet
+	for i := 0; i < rb.size; i++ {
+		p := rb.packets[(rb.head+i)%capacity]
+		if seqAfter(p.Seq, seq) {
+			packets = append(packets, p)
+		}
+	}
+	return packets
+}
+
+// 序号回绕时也能正确比较
+func seqAfter(a, b uint32) bool {
+	return int32(a-b) > 0
+}
@@ -0,0 +1,400 @@
+package main
+
+import (
+	"crypto/rand"
+	"errors"
+	"net"
+	"net/netip"
+	"sync"
+	"time"
+
+	"github.com/iakud/plume/log"
+	"github.com/iakud/plumeserver/kcp"
+	"github.com/iakud/plumeserver/service"
+)
+
+var (
+	ErrServerClosed = errors.New("gate: server closed")
+)
+
+type Server struct {
+	config  *Config
+	backend *backend
+	groups  *groups
+	limiter *limiter
+	filter  *commandFilter
+
+	once        sync.Once
+	mutex       sync.Mutex
+	listeners   map[net.Listener]struct{}
+	connections map[*connection]struct{}
+	sessions    map[uint64]*Session
+	tokens      map[string]*Session
+	nextId      uint64
+	draining    bool
+	drained     chan struct{}
+	closed      bool
+}
+
+func NewServer(config *Config) (*Server, error) {
+	limiter, err := newLimiter(config)
+	if err != nil {
+		return nil, err
+	}
+	filter, err := loadCommandFilter(config)
+	if err != nil {
+		return nil, err
+	}
+	srv := &Server{
+		config:      config,
+		listeners:   make(map[net.Listener]struct{}),
+		connections: make(map[*connection]struct{}),
+		sessions:    make(map[uint64]*Session),
+		tokens:      make(map[string]*Session),
+		groups:      newGroups(),
+		drained:     make(chan struct{}),
+		limiter:     limiter,
+		filter:      filter,
+	}
+	srv.backend = newBackend(config.GameAddr, srv)
+	return srv, nil
+}
+
+type listenOptions struct {
+	encrypt bool
+}
+
+type ListenOption func(o *listenOptions)
+
+// 该监听的连接必须加密传输
+func WithEncrypt() ListenOption {
+	return func(o *listenOptions) {
+		o.encrypt = true
+	}
+}
+
+func (srv *Server) ListenAndServe(addr string, o ...ListenOption) error {
+	if addr == "" {
+		addr = ":0"
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return srv.Serve(ln, o...)
+}
+
+// 基于UDP的可靠传输, 与TCP共用会话和消息格式
+func (srv *Server) ListenAndServeKCP(addr string, o ...ListenOption) error {
+	if addr == "" {
+		addr = ":0"
+	}
+	var options []kcp.ListenerOption
+	if !srv.config.KCPCookie {
+		options = append(options, kcp.WithoutCookie())
+	}
+	ln, err := kcp.Listen(addr, options...)
+	if err != nil {
+		return err
+	}
+	return srv.Serve(ln, o...)
+}
+
+func (srv *Server) Serve(ln net.Listener, o ...ListenOption) error {
+	var opts listenOptions
+	for _, option := range o {
+		option(&opts)
+	}
+	defer ln.Close()
+
+	if err := srv.newListener(ln); err != nil {
+		return err
+	}
+	defer srv.removeListener(ln)
+	srv.once.Do(func() { go srv.backend.dialAndServe() })
+
+	var tempDelay time.Duration // how long to sleep on accept failure
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if srv.isClosed() || srv.isDraining() {
+				return ErrServerClosed
+			}
+			if ne, ok := err.(net.Error); ok && ne.Temporary() {
+				if tempDelay == 0 {
+					tempDelay = 5 * time.Millisecond
+				} else {
+					tempDelay *= 2
+				}
+				if max := 1 * time.Second; tempDelay > max {
+					tempDelay = max
+				}
+				log.Warningf("gate: Server accept error: %v; retrying in %v", err, tempDelay)
+				time.Sleep(tempDelay)
+				continue
+			}
+			log.Errorf("gate: Server error: %v", err)
+			return err
+		}
+		tempDelay = 0
+
+		ip, ok := addrIP(conn.RemoteAddr())
+		if !ok {
+			conn.Close()
+			continue
+		}
+		if err := srv.limiter.allow(ip); err != nil {
+			log.Debugf("gate: refuse %v: %v", conn.RemoteAddr(), err)
+			conn.Close()
+			continue
+		}
+		connection, err := srv.newConnection(conn, ip, &opts)
+		if err != nil {
+			conn.Close()
+			return err
+		}
+		go connection.serve()
+	}
+}
+
+func (srv *Server) isClosed() bool {
+	srv.mutex.Lock()
+	defer srv.mutex.Unlock()
+	return srv.closed
+}
+
+func (srv *Server) isDraining() bool {
+	srv.mutex.Lock()
+	defer srv.mutex.Unlock()
+	return srv.draining
+}
+
+func (srv *Server) newListener(ln net.Listener) error {
+	srv.mutex.Lock()
+	defer srv.mutex.Unlock()
+
+	if srv.closed {
+		return ErrServerClosed
+	}
+	srv.listeners[ln] = struct{}{}
+	return nil
+}
+
+func (srv *Server) removeListener(ln net.Listener) {
+	srv.mutex.Lock()
+	defer srv.mutex.Unlock()
+	delete(srv.listeners, ln)
+}
+
+func (srv *Server) newConnection(conn net.Conn, ip netip.Addr, opts *listenOptions) (*connection, error) {
+	srv.mutex.Lock()
+	defer srv.mutex.Unlock()
+
+	if srv.closed || srv.draining {
+		srv.limiter.release(ip)
+		return nil, ErrServerClosed
+	}
+	c := newConnection(conn, ip, srv, opts)
+	srv.connections[c] = struct{}{}
+	return c, nil
+}
+
+func (srv *Server) removeConnection(c *connection) {
+	srv.mutex.Lock()
+	delete(srv.connections, c)
+	srv.mutex.Unlock()
+
+	srv.limiter.release(c.ip)
+}
+
+// 封禁IP或网段, 并断开已有的连接, duration为BanForever时永久封禁
+func (srv *Server) Ban(target string, duration time.Duration) error {
+	prefix, err := srv.limiter.ban(target, duration)
+	if err != nil {
+		return err
+	}
+	srv.mutex.Lock()
+	defer srv.mutex.Unlock()
+	for c := range srv.connections {
+		if prefix.Contains(c.ip) {
+			c.close(service.ReasonKicked)
+		}
+	}
+	return nil
+}
+
+func (srv *Server) Unban(target string) error {
+	return srv.limiter.unban(target)
+}
+
+func (srv *Server) Bans() []Ban {
+	return srv.limiter.banList()
+}
+
+func (srv *Server) GetSession(id uint64) *Session {
+	srv.mutex.Lock()
+	defer srv.mutex.Unlock()
+	return srv.sessions[id]
+}
+
+func (srv *Server) getSessions(ids []uint64) []*Session {
+	srv.mutex.Lock()
+	defer srv.mutex.Unlock()
+	sessions := make([]*Session, 0, len(ids))
+	for _, id := range ids {
+		if session, ok := srv.sessions[id]; ok {
+			sessions = append(sessions, session)
+		}
+	}
+	return sessions
+}
+
+func (srv *Server) allSessions() []*Session {
+	srv.mutex.Lock()
+	defer srv.mutex.Unlock()
+	sessions := make([]*Session, 0, len(srv.sessions))
+	for _, session := range srv.sessions {
+		sessions = append(sessions, session)
+	}
+	return sessions
+}
+
+// gate支持的握手选项
+func (srv *Server) options() uint8 {
+	var options uint8
+	if srv.config.CompressThreshold > 0 {
+		options |= optionCompress
+	}
+	return options
+}
+
+// 检查客户端的协议版本和构建号
+func (srv *Server) checkVersion(h *handshake) uint8 {
+	config := srv.config
+	if h.version < config.MinVersion || h.build < config.MinBuild {
+		return kHandshakeUpdate
+	}
+	if h.version > config.MaxVersion {
+		return kHandshakeUnsupported
+	}
+	return kHandshakeOK
+}
+
+// 握手成功, 创建新会话并通知game
+func (srv *Server) handshake(c *connection, h *handshake) (*Session, error) {
+	b := make([]byte, kTokenSize)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	token := string(b)
+	options := h.options & srv.options()
+
+	srv.mutex.Lock()
+	if srv.closed || srv.draining {
+		srv.mutex.Unlock()
+		return nil, ErrServerClosed
+	}
+	srv.nextId++
+	session := newSession(srv.nextId, token, options, h.version, h.build, c, srv)
+	srv.sessions[session.id] = session
+	srv.tokens[token] = session
+	srv.mutex.Unlock()
+
+	reply := &handshakeReply{result: kHandshakeOK, token: token, options: options, version: h.version}
+	c.send(&Packet{Cmd: CmdHandshake, Payload: reply.marshal()})
+	c.setOptions(options)
+	log.Debugf("gate: session %v %v connected", session.id, session.RemoteAddr())
+	srv.backend.connect(session)
+	return session, nil
+}
+
+func (srv *Server) resume(c *connection, token []byte, ack uint32) *Session {
+	srv.mutex.Lock()
+	session, ok := srv.tokens[string(token)]
+	srv.mutex.Unlock()
+	// 不能通过明文监听重连加密的会话, 也不能反过来
+	if !ok || session.encrypt != c.encrypt {
+		return nil
+	}
+	if !session.attach(c, ack) {
+		return nil
+	}
+	return session
+}
+
+func (srv *Server) closeSession(session *Session, reason uint8) {
+	log.Debugf("gate: session %v %v disconnected, reason %v", session.id, session.RemoteAddr(), reason)
+
+	srv.mutex.Lock()
+	delete(srv.sessions, session.id)
+	delete(srv.tokens, session.token)
+	if srv.draining && len(srv.sessions) == 0 {
+		select {
+		case <-srv.drained:
+		default:
+			close(srv.drained)
+		}
+	}
+	srv.mutex.Unlock()
+	srv.groups.leaveAll(session.id)
+
+	srv.backend.disconnect(session, reason)
+}
+
+func (srv *Server) forward(session *Session, p *Packet) {
+	if err := srv.backend.forward(session, p); err != nil {
+		log.Warningf("gate: session %v forward cmd %v error: %v", session.id, p.Cmd, err)
+	}
+}
+
+// 优雅关闭: 停止接受新连接, 通知客户端重连到其他gate, 等待会话结束或超时后关闭
+func (srv *Server) Drain(timeout time.Duration, redirect string) {
+	srv.mutex.Lock()
+	if srv.closed || srv.draining {
+		srv.mutex.Unlock()
+		return
+	}
+	srv.draining = true
+	for ln := range srv.listeners {
+		ln.Close()
+	}
+	if len(srv.sessions) == 0 {
+		close(srv.drained)
+	}
+	srv.mutex.Unlock()
+
+	log.Infof("gate: draining, timeout %v", timeout)
+	sessions := srv.allSessions()
+	for _, session := range sessions {
+		session.notify(&Packet{Cmd: CmdRedirect, Payload: []byte(redirect)})
+	}
+	select {
+	case <-srv.drained:
+	case <-time.After(timeout):
+	}
+	// 剩余会话强制关闭, 通知game保存
+	sessions = srv.allSessions()
+	for _, session := range sessions {
+		session.Close(service.ReasonShutdown)
+	}
+	log.Infof("gate: drained, %v sessions closed", len(sessions))
+	srv.backend.shutdown(time.Second * 5)
+	srv.Close()
+}
+
+func (srv *Server) Close() {
+	srv.mutex.Lock()
+	defer srv.mutex.Unlock()
+
+	if srv.closed {
+		return
+	}
+	srv.closed = true
+	srv.backend.close()
+	for ln := range srv.listeners {
+		ln.Close()
+	}
+	for c := range srv.connections {
+		c.conn.Close()
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/iakud/plumeserver/service"
+)
+
+func TestDrain(t *testing.T) {
+	srv, game, addr := newTestServer(t, newConfig())
+	conns, sessions := dialTestClients(t, game, addr, 2)
+
+	done := make(chan struct{})
+	go func() {
+		srv.Drain(time.Millisecond*300, "gate2:7000")
+		close(done)
+	}()
+	for _, conn := range conns {
+		p, err := readPacket(conn, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.Cmd != CmdRedirect || string(p.Payload) != "gate2:7000" {
+			t.Fatalf("unexpected redirect: %v", p)
+		}
+	}
+	// 不再接受新连接
+	if conn, err := net.Dial("tcp", addr); err == nil {
+		conn.Close()
+		t.Fatal("draining server accepted connection")
+	}
+
+	// 客户端收到通知后断开, 不等待重连
+	conns[0].Close()
+	disconnect := game.expect(t, service.BackendDisconnect)
+	if disconnect.Session != sessions[0] || disconnect.Reason() != service.ReasonClosed {
+		t.Fatalf("unexpected disconnect: session=%v, reason=%v", disconnect.Session, disconnect.Reason())
+	}
+	// 超时后剩余会话被关闭
+	disconnect = game.expect(t, service.BackendDisconnect)
+	if disconnect.Session != sessions[1] || disconnect.Reason() != service.ReasonShutdown {
+		t.Fatalf("unexpected disconnect: session=%v, reason=%v", disconnect.Session, disconnect.Reason())
+	}
+	select {
+	case <-done:
+	case <-time.After(time.Second * 10):
+		t.Fatal("drain timeout")
+	}
+}
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/iakud/plume/log"
+	"github.com/iakud/plumeserver/service"
+)
+
+const kTokenSize = 16
+
+// 重连结果
+const (
+	kResumeOK uint8 = iota
+	kResumeFailed
+)
+
+var ErrSessionClosed = errors.New("gate: session closed")
+
+// 客户端会话, 连接断开后在重连窗口内保留, 对game来说是同一个会话
+type Session struct {
+	id          uint64
+	token       string
+	options     uint8  // 握手协商的选项
+	encrypt     bool   // 在加密监听上建立, 只能从加密监听重连
+	version     uint16 // 协议版本
+	build       uint32 // 客户端构建号
+	connectedAt time.Time
+	server      *Server
+
+	mutex      sync.Mutex
+	conn       *connection
+	remoteAddr net.Addr
+	account    string
+	mutedUntil time.Time
+	state      uint8  // 会话状态, 决定允许发送的命令
+	bytesIn    uint64 // 之前连接的流量
+	bytesOut   uint64
+	seq        uint32
+	buffer     *ringBuffer
+	timer      *time.Timer
+	closed     bool
+	reason     uint8
+}
+
+func newSession(id uint64, token string, options uint8, version uint16, build uint32, conn *connection, server *Server) *Session {
+	s := &Session{
+		id:          id,
+		token:       token,
+		options:     options,
+		encrypt:     conn.encrypt,
+		version:     version,
+		build:       build,
+		connectedAt: time.Now(),
+		server:      server,
+		conn:        conn,
+		remoteAddr:  conn.RemoteAddr(),
+	}
+	if server.config.ResumeTimeout > 0 {
+		s.buffer = newRingBuffer(server.config.ResumeBuffer)
+	}
+	return s
+}
+
+func (s *Session) Id() uint64 {
+	return s.id
+}
+
+func (s *Session) RemoteAddr() net.Addr {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.remoteAddr
+}
+
+func (s *Session) setAccount(account string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.account = account
+}
+
+func (s *Session) setState(state uint8) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.state = state
+}
+
+// 当前状态是否允许发送该命令
+func (s *Session) allow(cmd int16) bool {
+	s.mutex.Lock()
+	state := s.state
+	s.mutex.Unlock()
+	return s.server.filter.allow(state, cmd)
+}
+
+// 禁言期间客户端的聊天命令不转发给game, duration为0解除禁言
+func (s *Session) Mute(duration time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if duration <= 0 {
+		s.mutedUntil = time.Time{}
+		return
+	}
+	s.mutedUntil = time.Now().Add(duration)
+}
+
+// 只限制配置的聊天命令, 其他命令照常转发
+func (s *Session) isMuted(cmd int16) bool {
+	if !s.server.filter.mutes[cmd] {
+		return false
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return time.Now().Before(s.mutedUntil)
+}
+
+type SessionInfo struct {
+	Id          uint64    `json:"id"`
+	Account     string    `json:"account"`
+	RemoteAddr  string    `json:"remote_addr"`
+	BytesIn     uint64    `json:"bytes_in"`
+	BytesOut    uint64    `json:"bytes_out"`
+	ConnectedAt time.Time `json:"connected_at"`
+	Version     uint16    `json:"version"`
+	Build       uint32    `json:"build"`
+	Backend     string    `json:"backend"`
+	State       uint8     `json:"state"`
+	Online      bool      `json:"online"`
+	MutedUntil  time.Time `json:"muted_until,omitempty"`
+}
+
+func (s *Session) Info() *SessionInfo {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	info := &SessionInfo{
+		Id:          s.id,
+		Account:     s.account,
+		RemoteAddr:  s.remoteAddr.String(),
+		BytesIn:     s.bytesIn,
+		BytesOut:    s.bytesOut,
+		ConnectedAt: s.connectedAt,
+		Version:     s.version,
+		Build:       s.build,
+		Backend:     s.server.config.GameAddr,
+		State:       s.state,
+		Online:      s.conn != nil,
+		MutedUntil:  s.mutedUntil,
+	}
+	if s.conn != nil {
+		info.BytesIn += s.conn.bytesIn.Load()
+		info.BytesOut += s.conn.bytesOut.Load()
+	}
+	return info
+}
+
+// 连接解绑时累计流量
+func (s *Session) addBytes(c *connection) {
+	s.bytesIn += c.bytesIn.Load()
+	s.bytesOut += c.bytesOut.Load()
+}
+
+// 下发game的消息, 分配序号并缓存到客户端确认
+func (s *Session) Send(p *Packet) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.closed {
+		return ErrSessionClosed
+	}
+	s.seq++
+	p.Seq = s.seq
+	if s.buffer != nil {
+		s.buffer.push(p)
+	}
+	if s.conn == nil {
+		return nil
+	}
+	if err := s.conn.send(p); err == ErrConnectionPendingFull {
+		// 客户端接收太慢, 断开连接等待重连补发
+		s.conn.close(service.ReasonWriteTimeout)
+	}
+	return nil
+}
+
+// 发送gate的通知, 不分配序号, 断线时丢弃
+func (s *Session) notify(p *Packet) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.conn != nil {
+		s.conn.send(p)
+	}
+}
+
+func (s *Session) ack(seq uint32) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.buffer != nil {
+		s.buffer.ack(seq)
+	}
+}
+
+// 重连, 补发客户端未收到的消息
+func (s *Session) attach(c *connection, ack uint32) bool {
+	s.mutex.Lock()
+	if s.closed || s.buffer == nil {
+		s.mutex.Unlock()
+		return false
+	}
+	packets := s.buffer.since(ack)
+	if uint32(len(packets)) != s.seq-ack {
+		// 消息已被覆盖, 无法补发
+		s.mutex.Unlock()
+		log.Infof("gate: session %v resume failed, ack %v, seq %v", s.id, ack, s.seq)
+		return false
+	}
+	old := s.conn
+	if old != nil {
+		s.addBytes(old)
+	}
+	s.conn = c
+	s.remoteAddr = c.RemoteAddr()
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	s.buffer.ack(ack)
+	c.setOptions(s.options)
+	c.send(&Packet{Cmd: CmdResume, Payload: []byte{kResumeOK}})
+	for _, p := range packets {
+		c.send(p)
+	}
+	s.mutex.Unlock()
+
+	if old != nil {
+		old.close(service.ReasonClosed)
+	}
+	log.Debugf("gate: session %v resumed from %v, replay %v", s.id, c.RemoteAddr(), len(packets))
+	return true
+}
+
+// 连接断开, 网络原因断开的会话等待重连
+func (s *Session) detach(c *connection, reason uint8) {
+	s.mutex.Lock()
+	if s.closed || s.conn != c {
+		s.mutex.Unlock()
+		return
+	}
+	s.addBytes(c)
+	s.conn = nil
+	if s.buffer != nil && isResumable(reason) && !s.server.isDraining() {
+		s.timer = time.AfterFunc(s.server.config.ResumeTimeout, func() {
+			s.Close(reason)
+		})
+		s.mutex.Unlock()
+		return
+	}
+	s.mutex.Unlock()
+
+	s.Close(reason)
+}
+
+// 读空闲超时的客户端可能已经不在了, 不再保留会话, 避免game要再等一个重连窗口才知道
+func isResumable(reason uint8) bool {
+	switch reason {
+	case service.ReasonClosed, service.ReasonWriteTimeout:
+		return true
+	}
+	return false
+}
+
+// 关闭会话并通知game
+func (s *Session) Close(reason uint8) {
+	s.mutex.Lock()
+	if s.closed {
+		s.mutex.Unlock()
+		return
+	}
+	s.closed = true
+	s.reason = reason
+	conn := s.conn
+	if conn != nil {
+		s.addBytes(conn)
+	}
+	s.conn = nil
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	s.mutex.Unlock()
+
+	if conn != nil {
+		conn.close(reason)
+	}
+	s.server.closeSession(s, reason)
+}
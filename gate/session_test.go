@@ -0,0 +1,297 @@
+package main
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/iakud/plume/network"
+	"github.com/iakud/plumeserver/service"
+)
+
+// 模拟game, 记录gate发来的消息
+type testGame struct {
+	server     *network.TCPServer
+	packets    chan *service.BackendPacket
+	connection chan *network.TCPConnection
+	conn       *network.TCPConnection
+}
+
+func newTestGame(t *testing.T) (*testGame, string) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	g := &testGame{
+		server:     network.NewTCPServer(addr),
+		packets:    make(chan *service.BackendPacket, 64),
+		connection: make(chan *network.TCPConnection, 1),
+	}
+	go g.server.ListenAndServe(g, service.BackendCodec)
+	return g, addr
+}
+
+func (g *testGame) Connect(connection *network.TCPConnection, connected bool) {
+	if connected {
+		g.connection <- connection
+	}
+}
+
+func (g *testGame) Receive(connection *network.TCPConnection, buf []byte) {
+	packet := &service.BackendPacket{}
+	if err := packet.Unmarshal(buf); err != nil {
+		return
+	}
+	g.packets <- packet
+}
+
+func (g *testGame) expect(t *testing.T, packetType uint8) *service.BackendPacket {
+	for {
+		select {
+		case packet := <-g.packets:
+			if packet.Type == packetType {
+				return packet
+			}
+		case <-time.After(time.Second * 5):
+			t.Fatalf("expect backend packet %v timeout", packetType)
+			return nil
+		}
+	}
+}
+
+func (g *testGame) send(session uint64, cmd int16, payload []byte) {
+	packet := &service.BackendPacket{Type: service.BackendData, Session: session, Cmd: cmd, Payload: payload}
+	g.conn.Send(packet.Marshal())
+}
+
+// 客户端握手, 返回token和协商的选项
+func clientHandshake(t *testing.T, conn net.Conn, options uint8) ([]byte, uint8) {
+	h := &handshake{options: options, version: 1}
+	if err := writePacket(conn, &Packet{Cmd: CmdHandshake, Payload: h.marshal()}); err != nil {
+		t.Fatal(err)
+	}
+	p, err := readPacket(conn, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var reply handshakeReply
+	if p.Cmd != CmdHandshake || reply.unmarshal(p.Payload) != nil || reply.result != kHandshakeOK {
+		t.Fatalf("unexpected handshake: %v", p)
+	}
+	return []byte(reply.token), reply.options
+}
+
+// game通知会话已登录, 等待gate处理后客户端可以发送普通命令
+func (g *testGame) login(t *testing.T, srv *Server, session uint64) {
+	t.Helper()
+	g.conn.Send((&service.BackendPacket{Type: service.BackendState, Session: session, Payload: []byte{service.StatePlayer}}).Marshal())
+	for i := 0; ; i++ {
+		if s := srv.GetSession(session); s != nil && s.Info().State == service.StatePlayer {
+			return
+		}
+		if i > 500 {
+			t.Fatal("session state timeout")
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+}
+
+func newTestServer(t *testing.T, config *Config, o ...ListenOption) (*Server, *testGame, string) {
+	game, gameAddr := newTestGame(t)
+	config.GameAddr = gameAddr
+	srv, err := NewServer(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Serve(ln, o...)
+	// 等待连上game
+	for i := 0; srv.backend.client.GetConnection() == nil; i++ {
+		if i > 500 {
+			t.Fatal("backend connect timeout")
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+	game.conn = <-game.connection
+	t.Cleanup(func() {
+		srv.Close()
+		game.server.Close()
+	})
+	return srv, game, ln.Addr().String()
+}
+
+func TestPingPong(t *testing.T) {
+	_, game, addr := newTestServer(t, newConfig())
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	clientHandshake(t, conn, 0)
+	game.expect(t, service.BackendConnect)
+
+	payload := []byte("12345678")
+	if err := writePacket(conn, &Packet{Cmd: CmdPing, Payload: payload}); err != nil {
+		t.Fatal(err)
+	}
+	p, err := readPacket(conn, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Cmd != CmdPong || !bytes.Equal(p.Payload, payload) {
+		t.Fatalf("unexpected pong: %v", p)
+	}
+}
+
+func TestIdleTimeout(t *testing.T) {
+	config := newConfig()
+	config.IdleTimeout = time.Millisecond * 200
+	// 开启重连时空闲超时也立即通知game
+	config.ResumeTimeout = time.Second * 10
+	_, game, addr := newTestServer(t, config)
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	clientHandshake(t, conn, 0)
+	connect := game.expect(t, service.BackendConnect)
+
+	// 心跳保持连接
+	for i := 0; i < 3; i++ {
+		time.Sleep(time.Millisecond * 100)
+		if err := writePacket(conn, &Packet{Cmd: CmdPing}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	select {
+	case packet := <-game.packets:
+		t.Fatalf("unexpected backend packet %v", packet.Type)
+	default:
+	}
+
+	disconnect := game.expect(t, service.BackendDisconnect)
+	if disconnect.Session != connect.Session || disconnect.Reason() != service.ReasonIdleTimeout {
+		t.Fatalf("unexpected disconnect: session=%v, reason=%v", disconnect.Session, disconnect.Reason())
+	}
+}
+
+func TestResume(t *testing.T) {
+	srv, game, addr := newTestServer(t, newConfig())
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, _ := clientHandshake(t, conn, 0)
+	connect := game.expect(t, service.BackendConnect)
+	game.login(t, srv, connect.Session)
+
+	for i := 0; i < 3; i++ {
+		game.send(connect.Session, 1, []byte{byte(i)})
+	}
+	for i := 0; i < 3; i++ {
+		p, err := readPacket(conn, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.Seq != uint32(i+1) {
+			t.Fatalf("unexpected seq %v", p.Seq)
+		}
+	}
+	// 只确认了第一条消息就断线
+	if err := writePacket(conn, &Packet{Cmd: CmdAck, Seq: 1}); err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+	time.Sleep(time.Millisecond * 100)
+	game.send(connect.Session, 1, []byte{3})
+
+	conn, err = net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if err := writePacket(conn, &Packet{Cmd: CmdResume, Seq: 1, Payload: token}); err != nil {
+		t.Fatal(err)
+	}
+	p, err := readPacket(conn, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Cmd != CmdResume || p.Payload[0] != kResumeOK {
+		t.Fatalf("unexpected resume: %v", p)
+	}
+	// 补发第2条之后的消息
+	for i := 1; i < 4; i++ {
+		p, err := readPacket(conn, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.Seq != uint32(i+1) || p.Payload[0] != byte(i) {
+			t.Fatalf("unexpected replay: seq=%v, payload=%v", p.Seq, p.Payload)
+		}
+	}
+	// game侧仍是同一个会话
+	if err := writePacket(conn, &Packet{Cmd: 1}); err != nil {
+		t.Fatal(err)
+	}
+	data := game.expect(t, service.BackendData)
+	if data.Session != connect.Session {
+		t.Fatalf("unexpected session %v", data.Session)
+	}
+}
+
+func TestHandshakeVersion(t *testing.T) {
+	config := newConfig()
+	config.MinVersion = 2
+	config.MaxVersion = 3
+	config.MinBuild = 100
+	_, _, addr := newTestServer(t, config)
+
+	tests := []struct {
+		version uint16
+		build   uint32
+		result  uint8
+	}{
+		{1, 100, kHandshakeUpdate},
+		{2, 99, kHandshakeUpdate},
+		{4, 100, kHandshakeUnsupported},
+		{3, 100, kHandshakeOK},
+	}
+	for _, test := range tests {
+		conn, err := net.Dial("tcp", addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		h := &handshake{version: test.version, build: test.build}
+		if err := writePacket(conn, &Packet{Cmd: CmdHandshake, Payload: h.marshal()}); err != nil {
+			t.Fatal(err)
+		}
+		p, err := readPacket(conn, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var reply handshakeReply
+		if err := reply.unmarshal(p.Payload); err != nil || reply.result != test.result {
+			t.Fatalf("version %v build %v: unexpected result %v", test.version, test.build, reply.result)
+		}
+		if test.result == kHandshakeOK {
+			if reply.version != test.version {
+				t.Fatalf("unexpected version %v", reply.version)
+			}
+		} else {
+			// 拒绝后gate关闭连接
+			conn.SetReadDeadline(time.Now().Add(time.Second * 5))
+			if _, err := readPacket(conn, 0); err == nil {
+				t.Fatal("expect connection closed")
+			}
+		}
+		conn.Close()
+	}
+}
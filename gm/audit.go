@@ -0,0 +1,39 @@
+package gm
+
+import (
+	"time"
+
+	"github.com/iakud/plume/log"
+	"github.com/iakud/plumeserver/internal/auditlog"
+)
+
+// 执行过的命令, 包括失败的
+type Record struct {
+	Time     time.Time `json:"time"`
+	Operator string    `json:"operator"`
+	Level    Level     `json:"level"`
+	Line     string    `json:"line"`
+	Output   string    `json:"output,omitempty"`
+	Error    string    `json:"error,omitempty"`
+}
+
+type AuditLog interface {
+	Write(record *Record)
+	Close() error
+}
+
+// 写入服务器日志
+type LogAuditLog struct{}
+
+func (LogAuditLog) Write(r *Record) {
+	log.Infof("gm: operator %v level %v line %q error %q", r.Operator, r.Level, r.Line, r.Error)
+}
+
+func (LogAuditLog) Close() error {
+	return nil
+}
+
+// 每行一条json记录, 在单独的协程中写入, 队列满或关闭后改为写入服务器日志
+func OpenFileAuditLog(filename string) (AuditLog, error) {
+	return auditlog.Open(filename, 64, LogAuditLog{}.Write)
+}
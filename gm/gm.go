@@ -0,0 +1,293 @@
+package gm
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 权限等级, 只能执行等级不高于自己的命令
+type Level int32
+
+const (
+	LevelPlayer   Level = iota // 普通玩家, 只能查看帮助
+	LevelSupport               // 客服
+	LevelDesigner              // 策划
+	LevelAdmin                 // 管理员
+)
+
+var (
+	ErrCommandExists  = errors.New("gm: command exists")
+	ErrUnknownCommand = errors.New("gm: unknown command")
+	ErrPermission     = errors.New("gm: permission denied")
+	ErrArgs           = errors.New("gm: invalid arguments")
+	ErrQuote          = errors.New("gm: unterminated quote")
+)
+
+type ArgType int
+
+const (
+	Int ArgType = iota
+	Uint
+	Float
+	Bool
+	String
+	Duration // time.ParseDuration的格式
+)
+
+var argTypeNames = map[ArgType]string{
+	Int:      "int",
+	Uint:     "uint",
+	Float:    "float",
+	Bool:     "bool",
+	String:   "string",
+	Duration: "duration",
+}
+
+// 命令参数, 可选参数只能在最后
+type Arg struct {
+	Name     string
+	Type     ArgType
+	Optional bool
+}
+
+func (a *Arg) parse(s string) (interface{}, error) {
+	switch a.Type {
+	case Int:
+		return strconv.ParseInt(s, 10, 64)
+	case Uint:
+		return strconv.ParseUint(s, 10, 64)
+	case Float:
+		return strconv.ParseFloat(s, 64)
+	case Bool:
+		return strconv.ParseBool(s)
+	case Duration:
+		return time.ParseDuration(s)
+	default:
+		return s, nil
+	}
+}
+
+type Command struct {
+	Name  string
+	Level Level
+	Args  []Arg
+	Help  string
+	Run   func(c *Context) (string, error)
+}
+
+// 命令格式, 如additem <player:uint> <item:int> [count:int]
+func (c *Command) Usage() string {
+	var b strings.Builder
+	b.WriteString(c.Name)
+	for _, arg := range c.Args {
+		format := " <%v:%v>"
+		if arg.Optional {
+			format = " [%v:%v]"
+		}
+		fmt.Fprintf(&b, format, arg.Name, argTypeNames[arg.Type])
+	}
+	return b.String()
+}
+
+// 执行命令时的参数和执行者
+type Context struct {
+	Command  *Command
+	Operator string
+	Level    Level
+	args     map[string]interface{}
+}
+
+func (c *Context) Has(name string) bool {
+	_, ok := c.args[name]
+	return ok
+}
+
+func (c *Context) Int(name string) int64 {
+	v, _ := c.args[name].(int64)
+	return v
+}
+
+func (c *Context) Uint(name string) uint64 {
+	v, _ := c.args[name].(uint64)
+	return v
+}
+
+func (c *Context) Float(name string) float64 {
+	v, _ := c.args[name].(float64)
+	return v
+}
+
+func (c *Context) Bool(name string) bool {
+	v, _ := c.args[name].(bool)
+	return v
+}
+
+func (c *Context) String(name string) string {
+	v, _ := c.args[name].(string)
+	return v
+}
+
+func (c *Context) Duration(name string) time.Duration {
+	v, _ := c.args[name].(time.Duration)
+	return v
+}
+
+type options struct {
+	audit AuditLog
+}
+
+type Option func(o *options)
+
+func WithAuditLog(audit AuditLog) Option {
+	return func(o *options) {
+		o.audit = audit
+	}
+}
+
+// 命令注册表, 非并发安全, 由调用者保证在同一线程执行
+type Registry struct {
+	opts     options
+	commands map[string]*Command
+}
+
+func NewRegistry(o ...Option) *Registry {
+	var opts options
+	for _, option := range o {
+		option(&opts)
+	}
+	r := &Registry{opts: opts, commands: make(map[string]*Command)}
+	r.Register(&Command{
+		Name:  "help",
+		Level: LevelPlayer,
+		Args:  []Arg{{Name: "command", Type: String, Optional: true}},
+		Help:  "list commands or show the usage of a command",
+		Run:   r.help,
+	})
+	return r
+}
+
+func (r *Registry) Register(c *Command) error {
+	if _, ok := r.commands[c.Name]; ok {
+		return fmt.Errorf("%w: %v", ErrCommandExists, c.Name)
+	}
+	r.commands[c.Name] = c
+	return nil
+}
+
+// 等级可以执行的命令, 按名字排序
+func (r *Registry) Commands(level Level) []*Command {
+	var commands []*Command
+	for _, c := range r.commands {
+		if c.Level <= level {
+			commands = append(commands, c)
+		}
+	}
+	sort.Slice(commands, func(i, j int) bool { return commands[i].Name < commands[j].Name })
+	return commands
+}
+
+func (r *Registry) help(c *Context) (string, error) {
+	if c.Has("command") {
+		command, ok := r.commands[c.String("command")]
+		if !ok || command.Level > c.Level {
+			return "", fmt.Errorf("%w: %v", ErrUnknownCommand, c.String("command"))
+		}
+		return command.Usage() + "\n  " + command.Help, nil
+	}
+	var b strings.Builder
+	for i, command := range r.Commands(c.Level) {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		fmt.Fprintf(&b, "%-40v %v", command.Usage(), command.Help)
+	}
+	return b.String(), nil
+}
+
+// 解析并执行一行命令, 记录审计日志
+func (r *Registry) Execute(operator string, level Level, line string) (string, error) {
+	output, err := r.execute(operator, level, line)
+	if r.opts.audit != nil {
+		record := &Record{Time: time.Now(), Operator: operator, Level: level, Line: line, Output: output}
+		if err != nil {
+			record.Error = err.Error()
+		}
+		r.opts.audit.Write(record)
+	}
+	return output, err
+}
+
+func (r *Registry) execute(operator string, level Level, line string) (string, error) {
+	fields, err := split(line)
+	if err != nil {
+		return "", err
+	}
+	if len(fields) == 0 {
+		return "", ErrUnknownCommand
+	}
+	command, ok := r.commands[fields[0]]
+	if !ok {
+		return "", fmt.Errorf("%w: %v", ErrUnknownCommand, fields[0])
+	}
+	if command.Level > level {
+		return "", fmt.Errorf("%w: %v", ErrPermission, command.Name)
+	}
+	c := &Context{Command: command, Operator: operator, Level: level, args: make(map[string]interface{})}
+	values := fields[1:]
+	if len(values) > len(command.Args) {
+		return "", fmt.Errorf("%w: usage %v", ErrArgs, command.Usage())
+	}
+	for i, arg := range command.Args {
+		if i >= len(values) {
+			if !arg.Optional {
+				return "", fmt.Errorf("%w: usage %v", ErrArgs, command.Usage())
+			}
+			break
+		}
+		v, err := arg.parse(values[i])
+		if err != nil {
+			return "", fmt.Errorf("%w: %v %q is not %v", ErrArgs, arg.Name, values[i], argTypeNames[arg.Type])
+		}
+		c.args[arg.Name] = v
+	}
+	return command.Run(c)
+}
+
+// 按空白分割, 双引号内的空白不分割, 支持\"和\\转义
+func split(line string) ([]string, error) {
+	var fields []string
+	var b strings.Builder
+	inField, quoted, escaped := false, false, false
+	for _, r := range line {
+		switch {
+		case escaped:
+			b.WriteRune(r)
+			escaped = false
+		case quoted && r == '\\':
+			escaped = true
+		case r == '"':
+			quoted = !quoted
+			inField = true
+		case !quoted && (r == ' ' || r == '\t' || r == '\n' || r == '\r'):
+			if inField {
+				fields = append(fields, b.String())
+				b.Reset()
+				inField = false
+			}
+		default:
+			b.WriteRune(r)
+			inField = true
+		}
+	}
+	if quoted {
+		return nil, ErrQuote
+	}
+	if inField {
+		fields = append(fields, b.String())
+	}
+	return fields, nil
+}
@@ -0,0 +1,185 @@
+package gm
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iakud/plumeserver/internal/auditlog"
+)
+
+// 记录在内存中的审计日志
+type memoryAuditLog struct {
+	records []*Record
+}
+
+func (a *memoryAuditLog) Write(record *Record) {
+	a.records = append(a.records, record)
+}
+
+func (a *memoryAuditLog) Close() error {
+	return nil
+}
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		line   string
+		fields []string
+	}{
+		{"", nil},
+		{"  help  ", []string{"help"}},
+		{"say 1001 \"hello world\"", []string{"say", "1001", "hello world"}},
+		{"say \"\" a\tb", []string{"say", "", "a", "b"}},
+		{`say "a \"b\" \\c"`, []string{"say", `a "b" \c`}},
+	}
+	for _, test := range tests {
+		fields, err := split(test.line)
+		if err != nil || !reflect.DeepEqual(fields, test.fields) {
+			t.Errorf("split %q: got %q %v, expected %q", test.line, fields, err, test.fields)
+		}
+	}
+	if _, err := split(`say "hello`); err != ErrQuote {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestExecute(t *testing.T) {
+	audit := &memoryAuditLog{}
+	r := NewRegistry(WithAuditLog(audit))
+	var got *Context
+	err := r.Register(&Command{
+		Name:  "give",
+		Level: LevelDesigner,
+		Args: []Arg{
+			{Name: "player", Type: Uint},
+			{Name: "delta", Type: Int},
+			{Name: "rate", Type: Float},
+			{Name: "bind", Type: Bool},
+			{Name: "ttl", Type: Duration},
+			{Name: "note", Type: String, Optional: true},
+		},
+		Help: "give something",
+		Run: func(c *Context) (string, error) {
+			got = c
+			return "ok", nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Register(&Command{Name: "give"}); !errors.Is(err, ErrCommandExists) {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	output, err := r.Execute("tester", LevelAdmin, `give 1001 -5 0.5 true 1m30s "for test"`)
+	if err != nil || output != "ok" {
+		t.Fatalf("unexpected result %q %v", output, err)
+	}
+	if got.Uint("player") != 1001 || got.Int("delta") != -5 || got.Float("rate") != 0.5 || !got.Bool("bind") ||
+		got.Duration("ttl") != time.Second*90 || got.String("note") != "for test" || got.Operator != "tester" || got.Level != LevelAdmin {
+		t.Fatalf("unexpected context %+v", got.args)
+	}
+	// 可选参数可以省略
+	if _, err := r.Execute("tester", LevelDesigner, "give 1001 5 1 false 1s"); err != nil || got.Has("note") {
+		t.Fatalf("unexpected result %v %v", err, got.args)
+	}
+
+	failures := []struct {
+		level Level
+		line  string
+		err   error
+	}{
+		{LevelSupport, "give 1001 5 1 false 1s", ErrPermission},
+		{LevelAdmin, "take 1001", ErrUnknownCommand},
+		{LevelAdmin, "", ErrUnknownCommand},
+		{LevelAdmin, "give 1001 5", ErrArgs},
+		{LevelAdmin, "give 1001 5 1 false 1s a b", ErrArgs},
+		{LevelAdmin, "give -1 5 1 false 1s", ErrArgs},
+		{LevelAdmin, "give 1001 5 1 yes 1s", ErrArgs},
+		{LevelAdmin, "give 1001 5 1 false 10", ErrArgs},
+	}
+	for _, failure := range failures {
+		if _, err := r.Execute("tester", failure.level, failure.line); !errors.Is(err, failure.err) {
+			t.Errorf("execute %q: unexpected error %v, expected %v", failure.line, err, failure.err)
+		}
+	}
+
+	// 成功和失败的都记录
+	if len(audit.records) != 2+len(failures) {
+		t.Fatalf("unexpected records %v", len(audit.records))
+	}
+	if record := audit.records[0]; record.Operator != "tester" || record.Output != "ok" || record.Error != "" || record.Level != LevelAdmin {
+		t.Fatalf("unexpected record %+v", record)
+	}
+	if record := audit.records[2]; !strings.Contains(record.Error, "permission") || record.Line != failures[0].line {
+		t.Fatalf("unexpected record %+v", record)
+	}
+}
+
+func TestHelp(t *testing.T) {
+	r := NewRegistry()
+	r.Register(&Command{Name: "kick", Level: LevelSupport, Args: []Arg{{Name: "player", Type: Uint}}, Help: "kick a player"})
+	r.Register(&Command{Name: "reload", Level: LevelAdmin, Args: []Arg{{Name: "config", Type: String, Optional: true}}, Help: "reload config"})
+	if usage := r.commands["reload"].Usage(); usage != "reload [config:string]" {
+		t.Fatalf("unexpected usage %q", usage)
+	}
+
+	// 只列出等级可以执行的命令
+	output, err := r.Execute("tester", LevelSupport, "help")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(output, "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "help [command:string]") || !strings.HasPrefix(lines[1], "kick <player:uint>") {
+		t.Fatalf("unexpected help %q", output)
+	}
+	output, err = r.Execute("tester", LevelSupport, "help kick")
+	if err != nil || output != "kick <player:uint>\n  kick a player" {
+		t.Fatalf("unexpected help %q %v", output, err)
+	}
+	if _, err := r.Execute("tester", LevelSupport, "help reload"); !errors.Is(err, ErrUnknownCommand) {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestFileAuditLog(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "gm.log")
+	audit, err := OpenFileAuditLog(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := NewRegistry(WithAuditLog(audit))
+	r.Execute("console", LevelAdmin, "help")
+	r.Execute("console", LevelAdmin, "unknown")
+	if err := audit.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var records []*Record
+	for scanner := bufio.NewScanner(f); scanner.Scan(); {
+		record := &Record{}
+		if err := json.Unmarshal(scanner.Bytes(), record); err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, record)
+	}
+	if len(records) != 2 || records[0].Line != "help" || records[0].Output == "" || records[1].Error == "" {
+		t.Fatalf("unexpected records %+v", records)
+	}
+
+	// 关闭后改为写入服务器日志
+	r.Execute("console", LevelAdmin, "help")
+	if dropped := audit.(*auditlog.File[*Record]).Dropped(); dropped != 1 || audit.Close() == nil {
+		t.Fatalf("unexpected dropped %v", dropped)
+	}
+}
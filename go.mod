@@ -5,6 +5,5 @@ go 1.21
 require (
 	github.com/golang/protobuf v1.5.2
 	github.com/iakud/plume v0.0.0-20210823130714-646882ed5afd
+	google.golang.org/protobuf v1.33.0
 )
-
-require google.golang.org/protobuf v1.33.0 // indirect
@@ -0,0 +1,73 @@
+// 管理后台的HTTP服务, gate和game共用, 所有请求需要bearer token
+package adminhttp
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/iakud/plume/log"
+)
+
+type Server struct {
+	name  string // 日志前缀, 如gate
+	token string
+	http  *http.Server
+}
+
+func NewServer(name, token string, handler http.Handler) *Server {
+	s := &Server{name: name, token: token}
+	s.http = &http.Server{Handler: s.authorize(handler)}
+	return s
+}
+
+// 带认证的handler
+func (s *Server) Handler() http.Handler {
+	return s.http.Handler
+}
+
+func (s *Server) ListenAndServe(addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ln)
+}
+
+func (s *Server) Serve(ln net.Listener) error {
+	if s.token == "" {
+		log.Warningf("%v: admin token is empty, all requests will be rejected", s.name)
+	}
+	if err := s.http.Serve(ln); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+func (s *Server) Close() {
+	s.http.Close()
+}
+
+func (s *Server) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || s.token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			WriteError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func WriteJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func WriteError(w http.ResponseWriter, code int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
@@ -0,0 +1,99 @@
+// 审计日志文件, 每行一条json记录, game的物品审计和GM命令审计共用
+package auditlog
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/iakud/plume/log"
+)
+
+// 在单独的协程中追加写入文件, Write不阻塞调用者
+type File[T any] struct {
+	name     string
+	file     *os.File
+	records  chan T
+	fallback func(record T) // 队列满或关闭后的记录改为调用fallback
+	wg       sync.WaitGroup
+
+	mutex   sync.Mutex
+	closed  bool
+	dropped uint64 // 队列满或关闭后写入的记录数
+}
+
+func Open[T any](filename string, size int, fallback func(record T)) (*File[T], error) {
+	file, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	f := newFile(filename, size, fallback)
+	f.file = file
+	f.wg.Add(1)
+	go f.writeLoop()
+	return f, nil
+}
+
+func newFile[T any](name string, size int, fallback func(record T)) *File[T] {
+	return &File[T]{name: name, records: make(chan T, size), fallback: fallback}
+}
+
+func (f *File[T]) writeLoop() {
+	defer f.wg.Done()
+	w := bufio.NewWriter(f.file)
+	encoder := json.NewEncoder(w)
+	for record := range f.records {
+		if err := encoder.Encode(record); err != nil {
+			log.Errorf("auditlog: %v write error: %v", f.name, err)
+		}
+		// 没有等待的记录时刷新
+		if len(f.records) == 0 {
+			if err := w.Flush(); err != nil {
+				log.Errorf("auditlog: %v flush error: %v", f.name, err)
+			}
+		}
+	}
+	if err := w.Flush(); err != nil {
+		log.Errorf("auditlog: %v flush error: %v", f.name, err)
+	}
+}
+
+func (f *File[T]) Write(record T) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if !f.closed {
+		select {
+		case f.records <- record:
+			return
+		default:
+		}
+	}
+	f.dropped++
+	if f.fallback != nil {
+		f.fallback(record)
+	}
+}
+
+func (f *File[T]) Dropped() uint64 {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.dropped
+}
+
+// 等待队列中的记录写入后关闭文件
+func (f *File[T]) Close() error {
+	f.mutex.Lock()
+	if f.closed {
+		f.mutex.Unlock()
+		return os.ErrClosed
+	}
+	f.closed = true
+	close(f.records)
+	f.mutex.Unlock()
+	f.wg.Wait()
+	if dropped := f.Dropped(); dropped > 0 {
+		log.Warningf("auditlog: %v dropped %v records to the fallback", f.name, dropped)
+	}
+	return f.file.Close()
+}
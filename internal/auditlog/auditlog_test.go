@@ -0,0 +1,61 @@
+package auditlog
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type record struct {
+	Id int `json:"id"`
+}
+
+func TestFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "audit.log")
+	var fallback []*record
+	f, err := Open(filename, 16, func(r *record) { fallback = append(fallback, r) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		f.Write(&record{Id: i})
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// 关闭后写入fallback, 不会panic
+	f.Write(&record{Id: 10})
+	if f.Dropped() != 1 || len(fallback) != 1 || fallback[0].Id != 10 || f.Close() != os.ErrClosed {
+		t.Fatalf("unexpected dropped %v fallback %v", f.Dropped(), fallback)
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	n := 0
+	for ; scanner.Scan(); n++ {
+		r := &record{}
+		if err := json.Unmarshal(scanner.Bytes(), r); err != nil || r.Id != n {
+			t.Fatalf("unexpected record %s %v", scanner.Bytes(), err)
+		}
+	}
+	if n != 10 {
+		t.Fatalf("unexpected records %v", n)
+	}
+}
+
+// 队列满时不阻塞
+func TestFileFull(t *testing.T) {
+	var fallback int
+	f := newFile("full", 1, func(r *record) { fallback++ })
+	f.Write(&record{Id: 1})
+	f.Write(&record{Id: 2})
+	if f.Dropped() != 1 || fallback != 1 {
+		t.Fatalf("unexpected dropped %v fallback %v", f.Dropped(), fallback)
+	}
+}
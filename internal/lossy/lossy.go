@@ -0,0 +1,37 @@
+// 测试用的UDP连接, 随机丢弃发出的数据报
+package lossy
+
+import (
+	"math/rand"
+	"net"
+	"sync"
+	"testing"
+)
+
+type Conn struct {
+	net.PacketConn
+	loss float64
+
+	mutex sync.Mutex
+	rnd   *rand.Rand
+}
+
+// 监听本地的随机端口, 测试结束时关闭
+func Listen(t testing.TB, loss float64, seed int64) *Conn {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { pc.Close() })
+	return &Conn{PacketConn: pc, loss: loss, rnd: rand.New(rand.NewSource(seed))}
+}
+
+func (c *Conn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	c.mutex.Lock()
+	drop := c.rnd.Float64() < c.loss
+	c.mutex.Unlock()
+	if drop {
+		return len(b), nil
+	}
+	return c.PacketConn.WriteTo(b, addr)
+}
@@ -0,0 +1,281 @@
+package kcp
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+var ErrDeadLink = errors.New("kcp: dead link")
+
+// 基于UDP的可靠连接, 实现net.Conn
+type Conn struct {
+	kcp      *KCP
+	pc       net.PacketConn
+	raddr    net.Addr
+	listener *Listener // 为空时独占pc
+	start    time.Time
+
+	mutex         sync.Mutex
+	readDeadline  time.Time
+	writeDeadline time.Time
+	cookie        []byte // 客户端最后收到的cookie
+
+	readEvent  chan struct{}
+	writeEvent chan struct{}
+	die        chan struct{}
+	once       sync.Once
+}
+
+func newConn(conv uint32, pc net.PacketConn, raddr net.Addr, listener *Listener) *Conn {
+	c := &Conn{
+		pc:         pc,
+		raddr:      raddr,
+		listener:   listener,
+		start:      time.Now(),
+		readEvent:  make(chan struct{}, 1),
+		writeEvent: make(chan struct{}, 1),
+		die:        make(chan struct{}),
+	}
+	c.kcp = NewKCP(conv, c.output)
+	go c.update()
+	return c
+}
+
+// 使用已有的pc连接对端, pc由Conn关闭
+func NewConn(conv uint32, pc net.PacketConn, raddr net.Addr) *Conn {
+	c := newConn(conv, pc, raddr, nil)
+	go c.readLoop()
+	return c
+}
+
+func Dial(addr string) (*Conn, error) {
+	raddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	pc, err := net.ListenUDP("udp", nil)
+	if err != nil {
+		return nil, err
+	}
+	var b [4]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		pc.Close()
+		return nil, err
+	}
+	return NewConn(binary.LittleEndian.Uint32(b[:]), pc, raddr), nil
+}
+
+func (c *Conn) readLoop() {
+	buf := make([]byte, kMtu*2)
+	for {
+		n, addr, err := c.pc.ReadFrom(buf)
+		if err != nil {
+			c.Close()
+			return
+		}
+		if addr.String() != c.raddr.String() {
+			continue
+		}
+		c.input(buf[:n])
+	}
+}
+
+func (c *Conn) output(b []byte) {
+	c.pc.WriteTo(b, c.raddr)
+}
+
+func (c *Conn) current() uint32 {
+	return uint32(time.Since(c.start) / time.Millisecond)
+}
+
+func (c *Conn) input(b []byte) {
+	if len(b) < kOverhead {
+		return
+	}
+	if b[4] == cmdCookie {
+		// 客户端原样返回cookie, 收到新的cookie时重传被服务端丢弃的数据
+		if c.listener == nil {
+			c.output(b)
+			c.mutex.Lock()
+			if !bytes.Equal(c.cookie, b) {
+				c.cookie = append(c.cookie[:0], b...)
+				c.kcp.Resend()
+				c.kcp.current = c.current()
+				c.kcp.Flush()
+			}
+			c.mutex.Unlock()
+		}
+		return
+	}
+	c.mutex.Lock()
+	c.kcp.current = c.current()
+	c.kcp.Input(b)
+	readable := c.kcp.Readable()
+	writable := c.kcp.WaitSnd() < 2*kWndSnd
+	c.mutex.Unlock()
+	if readable {
+		notify(c.readEvent)
+	}
+	if writable {
+		notify(c.writeEvent)
+	}
+}
+
+func (c *Conn) update() {
+	ticker := time.NewTicker(kInterval * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.mutex.Lock()
+			c.kcp.Update(c.current())
+			dead := c.kcp.Dead()
+			c.mutex.Unlock()
+			if dead {
+				notify(c.readEvent)
+				notify(c.writeEvent)
+				return
+			}
+		case <-c.die:
+			return
+		}
+	}
+}
+
+func notify(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+// 等待事件, 返回超时或关闭的错误
+func (c *Conn) wait(event chan struct{}, deadline time.Time) error {
+	var timeout <-chan time.Time
+	if !deadline.IsZero() {
+		d := time.Until(deadline)
+		if d <= 0 {
+			return os.ErrDeadlineExceeded
+		}
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case <-event:
+		return nil
+	case <-timeout:
+		return os.ErrDeadlineExceeded
+	case <-c.die:
+		return net.ErrClosed
+	}
+}
+
+func (c *Conn) Read(b []byte) (int, error) {
+	for {
+		select {
+		case <-c.die:
+			return 0, net.ErrClosed
+		default:
+		}
+		c.mutex.Lock()
+		if c.kcp.Readable() {
+			n := c.kcp.Read(b)
+			c.mutex.Unlock()
+			return n, nil
+		}
+		dead := c.kcp.Dead()
+		deadline := c.readDeadline
+		c.mutex.Unlock()
+		if dead {
+			return 0, ErrDeadLink
+		}
+		if err := c.wait(c.readEvent, deadline); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// 放入发送队列后立即发送, 队列太长时等待对端确认
+func (c *Conn) Write(b []byte) (int, error) {
+	for {
+		select {
+		case <-c.die:
+			return 0, net.ErrClosed
+		default:
+		}
+		c.mutex.Lock()
+		if c.kcp.Dead() {
+			c.mutex.Unlock()
+			return 0, ErrDeadLink
+		}
+		if c.kcp.WaitSnd() < 2*kWndSnd {
+			c.kcp.Send(b)
+			c.kcp.current = c.current()
+			c.kcp.Flush()
+			c.mutex.Unlock()
+			return len(b), nil
+		}
+		deadline := c.writeDeadline
+		c.mutex.Unlock()
+		if err := c.wait(c.writeEvent, deadline); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// 没有断开握手, 对端依靠超时发现连接关闭
+func (c *Conn) Close() error {
+	err := net.ErrClosed
+	c.once.Do(func() {
+		err = nil
+		c.mutex.Lock()
+		c.kcp.current = c.current()
+		c.kcp.Flush()
+		c.mutex.Unlock()
+		close(c.die)
+		if c.listener != nil {
+			c.listener.remove(c)
+		} else {
+			c.pc.Close()
+		}
+	})
+	return err
+}
+
+func (c *Conn) LocalAddr() net.Addr {
+	return c.pc.LocalAddr()
+}
+
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.raddr
+}
+
+func (c *Conn) SetDeadline(t time.Time) error {
+	c.SetReadDeadline(t)
+	c.SetWriteDeadline(t)
+	return nil
+}
+
+// 修改deadline后唤醒等待的Read重新计算超时
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	c.mutex.Lock()
+	c.readDeadline = t
+	c.mutex.Unlock()
+	notify(c.readEvent)
+	return nil
+}
+
+func (c *Conn) SetWriteDeadline(t time.Time) error {
+	c.mutex.Lock()
+	c.writeDeadline = t
+	c.mutex.Unlock()
+	notify(c.writeEvent)
+	return nil
+}
@@ -0,0 +1,194 @@
+package kcp
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/iakud/plumeserver/internal/lossy"
+)
+
+func TestConnLoss(t *testing.T) {
+	ln := NewListener(lossy.Listen(t, 0.1, 1))
+	defer ln.Close()
+	client := NewConn(42, lossy.Listen(t, 0.1, 2), ln.Addr())
+	defer client.Close()
+
+	// 回显服务
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		io.Copy(conn, conn)
+	}()
+
+	data := make([]byte, 512*1024)
+	rand.New(rand.NewSource(3)).Read(data)
+	go client.Write(data)
+
+	client.SetReadDeadline(time.Now().Add(time.Second * 30))
+	received := make([]byte, len(data))
+	if _, err := io.ReadFull(client, received); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(received, data) {
+		t.Fatal("data mismatch")
+	}
+}
+
+func TestConnDeadline(t *testing.T) {
+	ln, err := Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	client, err := Dial(ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	if _, err := client.Write([]byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	buf := make([]byte, 16)
+	n, err := conn.Read(buf)
+	if err != nil || string(buf[:n]) != "hello" {
+		t.Fatalf("unexpected read: %q, %v", buf[:n], err)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(time.Millisecond * 100))
+	if _, err := conn.Read(buf); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	conn.Close()
+	if _, err := conn.Read(buf); !errors.Is(err, net.ErrClosed) {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+// 没有返回正确cookie的地址不会创建连接
+func TestListenerCookie(t *testing.T) {
+	ln, err := Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+
+	seg := &segment{conv: 7, cmd: cmdPush, data: []byte("hello")}
+	if _, err := pc.WriteTo(seg.encode(nil), ln.Addr()); err != nil {
+		t.Fatal(err)
+	}
+	pc.SetReadDeadline(time.Now().Add(time.Second))
+	buf := make([]byte, kMtu)
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cookie := buf[:n]
+	if n != kOverhead || cookie[4] != cmdCookie {
+		t.Fatalf("unexpected cookie %v", cookie)
+	}
+	ln.mutex.Lock()
+	conns := len(ln.conns)
+	ln.mutex.Unlock()
+	if conns != 0 || len(ln.accept) != 0 {
+		t.Fatalf("unexpected conns %v", conns)
+	}
+
+	// 错误的cookie被丢弃
+	forged := append([]byte(nil), cookie...)
+	forged[kOverhead-1]++
+	pc.WriteTo(forged, ln.Addr())
+	pc.WriteTo(cookie, ln.Addr())
+	var conn net.Conn
+	select {
+	case c := <-ln.accept:
+		conn = c
+	case <-time.After(time.Second):
+		t.Fatal("accept timeout")
+	}
+	defer conn.Close()
+	if conn.RemoteAddr().String() != pc.LocalAddr().String() || len(ln.accept) != 0 {
+		t.Fatalf("unexpected conn %v", conn.RemoteAddr())
+	}
+}
+
+// 不验证cookie时, 标准ikcp客户端的第一个报文直接创建连接
+func TestListenerWithoutCookie(t *testing.T) {
+	ln, err := Listen("127.0.0.1:0", WithoutCookie())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+
+	seg := &segment{conv: 7, cmd: cmdPush, wnd: kWndRcv, data: []byte("hello")}
+	if _, err := pc.WriteTo(seg.encode(nil), ln.Addr()); err != nil {
+		t.Fatal(err)
+	}
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	buf := make([]byte, 16)
+	n, err := conn.Read(buf)
+	if err != nil || string(buf[:n]) != "hello" {
+		t.Fatalf("unexpected read: %q, %v", buf[:n], err)
+	}
+}
+
+// 客户端收到过短的报文时丢弃
+func TestConnShortPacket(t *testing.T) {
+	server, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := NewConn(7, pc, server.LocalAddr())
+	defer client.Close()
+
+	for _, size := range []int{0, 1, 4, kOverhead - 1} {
+		if _, err := server.WriteTo(make([]byte, size), pc.LocalAddr()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 之后的cookie报文被原样返回, 说明过短的报文已经处理
+	cookie := make([]byte, kOverhead)
+	cookie[4] = cmdCookie
+	if _, err := server.WriteTo(cookie, pc.LocalAddr()); err != nil {
+		t.Fatal(err)
+	}
+	server.SetReadDeadline(time.Now().Add(time.Second))
+	buf := make([]byte, kMtu)
+	n, _, err := server.ReadFrom(buf)
+	if err != nil || !bytes.Equal(buf[:n], cookie) {
+		t.Fatalf("unexpected packet %v %v", buf[:n], err)
+	}
+}
@@ -0,0 +1,465 @@
+// KCP可靠传输, 报文与ikcp兼容, 使用流模式
+// Listener默认先回复cookie验证新连接的地址, cookie报文(cmdCookie)是本包的协议扩展,
+// 本包的Conn会原样返回, 标准的ikcp客户端需要服务端使用WithoutCookie
+package kcp
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// 协议与ikcp兼容, 使用流模式
+const (
+	cmdPush uint8 = 81 // 数据
+	cmdAck  uint8 = 82 // 确认
+	cmdWask uint8 = 83 // 询问窗口
+	cmdWins uint8 = 84 // 告知窗口
+
+	cmdCookie uint8 = 90 // 验证对端地址, 不属于ikcp, 由Listener和Conn处理
+
+	askSend = 1
+	askTell = 2
+
+	kOverhead   = 24 // conv(4) + cmd(1) + frg(1) + wnd(2) + ts(4) + sn(4) + una(4) + len(4)
+	kMtu        = 1400
+	kWndSnd     = 128
+	kWndRcv     = 128
+	kInterval   = 10
+	kRtoNoDelay = 30
+	kRtoDef     = 200
+	kRtoMax     = 60000
+	kFastResend = 2
+	kDeadLink   = 20
+	kProbeInit  = 7000
+	kProbeLimit = 120000
+)
+
+var (
+	ErrConv    = errors.New("kcp: conv mismatch")
+	ErrSegment = errors.New("kcp: segment error")
+)
+
+type segment struct {
+	conv     uint32
+	cmd      uint8
+	frg      uint8
+	wnd      uint16
+	ts       uint32
+	sn       uint32
+	una      uint32
+	resendts uint32
+	rto      uint32
+	fastack  uint32
+	xmit     uint32
+	data     []byte
+}
+
+func (seg *segment) encode(b []byte) []byte {
+	b = binary.LittleEndian.AppendUint32(b, seg.conv)
+	b = append(b, seg.cmd, seg.frg)
+	b = binary.LittleEndian.AppendUint16(b, seg.wnd)
+	b = binary.LittleEndian.AppendUint32(b, seg.ts)
+	b = binary.LittleEndian.AppendUint32(b, seg.sn)
+	b = binary.LittleEndian.AppendUint32(b, seg.una)
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(seg.data)))
+	return append(b, seg.data...)
+}
+
+type ackItem struct {
+	sn uint32
+	ts uint32
+}
+
+func timediff(later, earlier uint32) int32 {
+	return int32(later - earlier)
+}
+
+// KCP的ARQ状态机, 不处理IO, 非并发安全
+type KCP struct {
+	conv   uint32
+	mtu    int
+	mss    int
+	dead   bool
+	output func(b []byte)
+
+	sndUna uint32
+	sndNxt uint32
+	rcvNxt uint32
+
+	rxSrtt   int32
+	rxRttval int32
+	rxRto    int32
+	rxMinrto int32
+
+	sndWnd uint32
+	rcvWnd uint32
+	rmtWnd uint32
+	probe  uint32
+
+	current   uint32
+	tsFlush   uint32
+	tsProbe   uint32
+	probeWait uint32
+	updated   bool
+
+	sndQueue []*segment
+	rcvQueue []*segment
+	sndBuf   []*segment
+	rcvBuf   []*segment
+	acklist  []ackItem
+
+	buffer []byte
+}
+
+// 默认使用nodelay模式: 10ms间隔, 2次快速重传, 不做拥塞控制
+func NewKCP(conv uint32, output func(b []byte)) *KCP {
+	return &KCP{
+		conv:     conv,
+		mtu:      kMtu,
+		mss:      kMtu - kOverhead,
+		output:   output,
+		rxRto:    kRtoDef,
+		rxMinrto: kRtoNoDelay,
+		sndWnd:   kWndSnd,
+		rcvWnd:   kWndRcv,
+		rmtWnd:   kWndRcv,
+		buffer:   make([]byte, 0, kMtu),
+	}
+}
+
+// 发送次数超过上限, 认为连接已断开
+func (k *KCP) Dead() bool {
+	return k.dead
+}
+
+// 已经发送但没有确认的分片在下次Flush时立即重传
+func (k *KCP) Resend() {
+	for _, s := range k.sndBuf {
+		if s.xmit > 0 {
+			s.fastack = kFastResend
+		}
+	}
+}
+
+// 等待发送和确认的分片数
+func (k *KCP) WaitSnd() int {
+	return len(k.sndBuf) + len(k.sndQueue)
+}
+
+// 流模式, 先填满上一个分片
+func (k *KCP) Send(b []byte) {
+	if n := len(k.sndQueue); n > 0 {
+		last := k.sndQueue[n-1]
+		if len(last.data) < k.mss {
+			m := min(k.mss-len(last.data), len(b))
+			last.data = append(last.data, b[:m]...)
+			b = b[m:]
+		}
+	}
+	for len(b) > 0 {
+		m := min(k.mss, len(b))
+		data := make([]byte, m, k.mss)
+		copy(data, b)
+		k.sndQueue = append(k.sndQueue, &segment{data: data})
+		b = b[m:]
+	}
+}
+
+// 是否有数据可读
+func (k *KCP) Readable() bool {
+	return len(k.rcvQueue) > 0
+}
+
+// 读取按序到达的数据
+func (k *KCP) Read(b []byte) int {
+	full := len(k.rcvQueue) >= int(k.rcvWnd)
+	n := 0
+	for n < len(b) && len(k.rcvQueue) > 0 {
+		seg := k.rcvQueue[0]
+		m := copy(b[n:], seg.data)
+		n += m
+		if m < len(seg.data) {
+			seg.data = seg.data[m:]
+			break
+		}
+		k.rcvQueue[0] = nil
+		k.rcvQueue = k.rcvQueue[1:]
+	}
+	k.moveRcvBuf()
+	// 接收窗口从满恢复, 主动告知对端
+	if full && len(k.rcvQueue) < int(k.rcvWnd) {
+		k.probe |= askTell
+	}
+	return n
+}
+
+func (k *KCP) moveRcvBuf() {
+	for len(k.rcvBuf) > 0 {
+		seg := k.rcvBuf[0]
+		if seg.sn != k.rcvNxt || len(k.rcvQueue) >= int(k.rcvWnd) {
+			break
+		}
+		k.rcvBuf[0] = nil
+		k.rcvBuf = k.rcvBuf[1:]
+		k.rcvQueue = append(k.rcvQueue, seg)
+		k.rcvNxt++
+	}
+}
+
+// 处理收到的数据报
+func (k *KCP) Input(data []byte) error {
+	var maxack, latestTs uint32
+	var hasAck bool
+	for len(data) >= kOverhead {
+		conv := binary.LittleEndian.Uint32(data)
+		if conv != k.conv {
+			return ErrConv
+		}
+		cmd := data[4]
+		wnd := binary.LittleEndian.Uint16(data[6:])
+		ts := binary.LittleEndian.Uint32(data[8:])
+		sn := binary.LittleEndian.Uint32(data[12:])
+		una := binary.LittleEndian.Uint32(data[16:])
+		length := binary.LittleEndian.Uint32(data[20:])
+		data = data[kOverhead:]
+		if uint32(len(data)) < length {
+			return ErrSegment
+		}
+		if cmd != cmdPush && cmd != cmdAck && cmd != cmdWask && cmd != cmdWins {
+			return ErrSegment
+		}
+		k.rmtWnd = uint32(wnd)
+		k.parseUna(una)
+		k.shrinkBuf()
+		switch cmd {
+		case cmdAck:
+			if rtt := timediff(k.current, ts); rtt >= 0 {
+				k.updateAck(rtt)
+			}
+			k.parseAck(sn)
+			k.shrinkBuf()
+			if !hasAck || timediff(sn, maxack) > 0 {
+				hasAck = true
+				maxack = sn
+				latestTs = ts
+			}
+		case cmdPush:
+			if timediff(sn, k.rcvNxt+k.rcvWnd) < 0 {
+				k.acklist = append(k.acklist, ackItem{sn, ts})
+				if timediff(sn, k.rcvNxt) >= 0 {
+					k.parseData(&segment{sn: sn, data: append([]byte(nil), data[:length]...)})
+				}
+			}
+		case cmdWask:
+			k.probe |= askTell
+		}
+		data = data[length:]
+	}
+	if hasAck {
+		k.parseFastack(maxack, latestTs)
+	}
+	return nil
+}
+
+func (k *KCP) updateAck(rtt int32) {
+	if k.rxSrtt == 0 {
+		k.rxSrtt = rtt
+		k.rxRttval = rtt / 2
+	} else {
+		delta := rtt - k.rxSrtt
+		if delta < 0 {
+			delta = -delta
+		}
+		k.rxRttval = (3*k.rxRttval + delta) / 4
+		k.rxSrtt = max((7*k.rxSrtt+rtt)/8, 1)
+	}
+	rto := k.rxSrtt + max(kInterval, 4*k.rxRttval)
+	k.rxRto = min(max(rto, k.rxMinrto), kRtoMax)
+}
+
+func (k *KCP) shrinkBuf() {
+	if len(k.sndBuf) > 0 {
+		k.sndUna = k.sndBuf[0].sn
+	} else {
+		k.sndUna = k.sndNxt
+	}
+}
+
+func (k *KCP) parseAck(sn uint32) {
+	if timediff(sn, k.sndUna) < 0 || timediff(sn, k.sndNxt) >= 0 {
+		return
+	}
+	for i, seg := range k.sndBuf {
+		if seg.sn == sn {
+			k.sndBuf = append(k.sndBuf[:i], k.sndBuf[i+1:]...)
+			return
+		}
+		if timediff(sn, seg.sn) < 0 {
+			return
+		}
+	}
+}
+
+func (k *KCP) parseUna(una uint32) {
+	i := 0
+	for i < len(k.sndBuf) && timediff(una, k.sndBuf[i].sn) > 0 {
+		k.sndBuf[i] = nil
+		i++
+	}
+	k.sndBuf = k.sndBuf[i:]
+}
+
+func (k *KCP) parseFastack(sn, ts uint32) {
+	if timediff(sn, k.sndUna) < 0 || timediff(sn, k.sndNxt) >= 0 {
+		return
+	}
+	for _, seg := range k.sndBuf {
+		if timediff(sn, seg.sn) < 0 {
+			break
+		}
+		if sn != seg.sn && timediff(ts, seg.ts) >= 0 {
+			seg.fastack++
+		}
+	}
+}
+
+// 按序号插入接收缓存, 丢弃重复的分片
+func (k *KCP) parseData(newseg *segment) {
+	sn := newseg.sn
+	if timediff(sn, k.rcvNxt+k.rcvWnd) >= 0 || timediff(sn, k.rcvNxt) < 0 {
+		return
+	}
+	i := len(k.rcvBuf)
+	for i > 0 {
+		seg := k.rcvBuf[i-1]
+		if seg.sn == sn {
+			return
+		}
+		if timediff(sn, seg.sn) > 0 {
+			break
+		}
+		i--
+	}
+	k.rcvBuf = append(k.rcvBuf, nil)
+	copy(k.rcvBuf[i+1:], k.rcvBuf[i:])
+	k.rcvBuf[i] = newseg
+	k.moveRcvBuf()
+}
+
+func (k *KCP) wndUnused() uint16 {
+	if len(k.rcvQueue) < int(k.rcvWnd) {
+		return uint16(int(k.rcvWnd) - len(k.rcvQueue))
+	}
+	return 0
+}
+
+// 数据报不超过mtu, 放不下就先发出去
+func (k *KCP) emit(seg *segment) {
+	if len(k.buffer)+kOverhead+len(seg.data) > k.mtu {
+		k.output(k.buffer)
+		k.buffer = k.buffer[:0]
+	}
+	k.buffer = seg.encode(k.buffer)
+}
+
+// 发送确认, 窗口探测, 新数据和需要重传的数据
+func (k *KCP) Flush() {
+	current := k.current
+	seg := segment{conv: k.conv, cmd: cmdAck, wnd: k.wndUnused(), una: k.rcvNxt}
+	for _, ack := range k.acklist {
+		seg.sn, seg.ts = ack.sn, ack.ts
+		k.emit(&seg)
+	}
+	k.acklist = k.acklist[:0]
+
+	// 对端窗口为0时定期探测
+	if k.rmtWnd == 0 {
+		if k.probeWait == 0 {
+			k.probeWait = kProbeInit
+			k.tsProbe = current + k.probeWait
+		} else if timediff(current, k.tsProbe) >= 0 {
+			k.probeWait = min(k.probeWait+k.probeWait/2, kProbeLimit)
+			k.tsProbe = current + k.probeWait
+			k.probe |= askSend
+		}
+	} else {
+		k.tsProbe = 0
+		k.probeWait = 0
+	}
+	seg.sn, seg.ts = 0, 0
+	if k.probe&askSend != 0 {
+		seg.cmd = cmdWask
+		k.emit(&seg)
+	}
+	if k.probe&askTell != 0 {
+		seg.cmd = cmdWins
+		k.emit(&seg)
+	}
+	k.probe = 0
+
+	cwnd := min(k.sndWnd, k.rmtWnd)
+	for len(k.sndQueue) > 0 && timediff(k.sndNxt, k.sndUna+cwnd) < 0 {
+		newseg := k.sndQueue[0]
+		k.sndQueue[0] = nil
+		k.sndQueue = k.sndQueue[1:]
+		newseg.conv = k.conv
+		newseg.cmd = cmdPush
+		newseg.sn = k.sndNxt
+		k.sndNxt++
+		k.sndBuf = append(k.sndBuf, newseg)
+	}
+
+	for _, s := range k.sndBuf {
+		send := false
+		switch {
+		case s.xmit == 0:
+			send = true
+			s.rto = uint32(k.rxRto)
+			s.resendts = current + s.rto
+		case timediff(current, s.resendts) >= 0:
+			// 超时重传, nodelay模式rto只增加一半
+			send = true
+			s.rto += uint32(k.rxRto) / 2
+			s.resendts = current + s.rto
+		case s.fastack >= kFastResend:
+			send = true
+			s.fastack = 0
+			s.resendts = current + s.rto
+		}
+		if send {
+			s.xmit++
+			s.ts = current
+			s.wnd = seg.wnd
+			s.una = k.rcvNxt
+			k.emit(s)
+			if s.xmit >= kDeadLink {
+				k.dead = true
+			}
+		}
+	}
+	if len(k.buffer) > 0 {
+		k.output(k.buffer)
+		k.buffer = k.buffer[:0]
+	}
+}
+
+// 定时调用, current为毫秒时间
+func (k *KCP) Update(current uint32) {
+	k.current = current
+	if !k.updated {
+		k.updated = true
+		k.tsFlush = current
+	}
+	slap := timediff(current, k.tsFlush)
+	if slap >= 10000 || slap < -10000 {
+		k.tsFlush = current
+		slap = 0
+	}
+	if slap >= 0 {
+		k.tsFlush += kInterval
+		if timediff(current, k.tsFlush) >= 0 {
+			k.tsFlush = current + kInterval
+		}
+		k.Flush()
+	}
+}
@@ -0,0 +1,97 @@
+package kcp
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+// 内存中的丢包链路, 发出的数据报在下一次deliver时到达
+type testLink struct {
+	rnd     *rand.Rand
+	loss    float64
+	packets [][]byte
+}
+
+func (l *testLink) output(b []byte) {
+	if l.rnd.Float64() < l.loss {
+		return
+	}
+	l.packets = append(l.packets, append([]byte(nil), b...))
+}
+
+func (l *testLink) deliver(k *KCP) {
+	packets := l.packets
+	l.packets = nil
+	// 乱序到达
+	l.rnd.Shuffle(len(packets), func(i, j int) {
+		packets[i], packets[j] = packets[j], packets[i]
+	})
+	for _, b := range packets {
+		if err := k.Input(b); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func TestKCPLoss(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	ab := &testLink{rnd: rnd, loss: 0.2}
+	ba := &testLink{rnd: rnd, loss: 0.2}
+	a := NewKCP(1, ab.output)
+	b := NewKCP(1, ba.output)
+
+	data := make([]byte, 256*1024)
+	rnd.Read(data)
+	var received []byte
+	buf := make([]byte, 4096)
+	sent := 0
+	for current := uint32(0); current < 600000 && len(received) < len(data); current += kInterval {
+		// 每次最多写入4k, 模拟应用层写入
+		if sent < len(data) && a.WaitSnd() < 2*kWndSnd {
+			n := min(4096, len(data)-sent)
+			a.Send(data[sent : sent+n])
+			sent += n
+		}
+		a.Update(current)
+		b.Update(current)
+		ab.deliver(b)
+		ba.deliver(a)
+		for b.Readable() {
+			n := b.Read(buf)
+			received = append(received, buf[:n]...)
+		}
+	}
+	if !bytes.Equal(received, data) {
+		t.Fatalf("received %v bytes, expect %v", len(received), len(data))
+	}
+	if a.Dead() || b.Dead() {
+		t.Fatal("unexpected dead link")
+	}
+}
+
+func TestKCPDeadLink(t *testing.T) {
+	link := &testLink{rnd: rand.New(rand.NewSource(1)), loss: 1}
+	k := NewKCP(1, link.output)
+	k.Send([]byte("hello"))
+	for current := uint32(0); current < 600000 && !k.Dead(); current += kInterval {
+		k.Update(current)
+	}
+	if !k.Dead() {
+		t.Fatal("expect dead link")
+	}
+}
+
+func TestKCPConv(t *testing.T) {
+	var out []byte
+	a := NewKCP(1, func(b []byte) { out = append([]byte(nil), b...) })
+	a.Send([]byte("hello"))
+	a.Update(0)
+	b := NewKCP(2, func(b []byte) {})
+	if err := b.Input(out); err != ErrConv {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if err := b.Input(out[:kOverhead-1]); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
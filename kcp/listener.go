@@ -0,0 +1,195 @@
+package kcp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	kAcceptBacklog = 128
+	kCookieSize    = 16
+	kCookieEpoch   = 10 * time.Second // cookie在当前和上一个周期内有效
+)
+
+// 在一个UDP端口上按对端地址区分连接, 实现net.Listener
+// 新连接需要先返回cookie验证地址, 客户端使用本包的Conn
+type Listener struct {
+	pc     net.PacketConn
+	accept chan *Conn
+	secret []byte
+	verify bool // 新地址需要返回cookie才创建连接
+
+	mutex sync.Mutex
+	conns map[string]*Conn
+	die   chan struct{}
+	once  sync.Once
+}
+
+type ListenerOption func(l *Listener)
+
+// 不验证新连接的地址, 兼容标准的ikcp客户端, 但伪造源地址的报文也会创建连接
+func WithoutCookie() ListenerOption {
+	return func(l *Listener) {
+		l.verify = false
+	}
+}
+
+func Listen(addr string, o ...ListenerOption) (*Listener, error) {
+	pc, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return NewListener(pc, o...), nil
+}
+
+// 使用已有的pc监听, pc由Listener关闭
+func NewListener(pc net.PacketConn, o ...ListenerOption) *Listener {
+	secret := make([]byte, 32)
+	rand.Read(secret)
+	l := &Listener{
+		pc:     pc,
+		accept: make(chan *Conn, kAcceptBacklog),
+		secret: secret,
+		verify: true,
+		conns:  make(map[string]*Conn),
+		die:    make(chan struct{}),
+	}
+	for _, option := range o {
+		option(l)
+	}
+	go l.readLoop()
+	return l
+}
+
+func (l *Listener) readLoop() {
+	buf := make([]byte, kMtu*2)
+	for {
+		n, addr, err := l.pc.ReadFrom(buf)
+		if err != nil {
+			l.Close()
+			return
+		}
+		if n < kOverhead {
+			continue
+		}
+		c := l.getConn(addr, buf[:n])
+		if c != nil {
+			c.input(buf[:n])
+		}
+	}
+}
+
+// 新地址的数据报文只回复cookie, 不保存状态, 对端返回正确的cookie后才创建连接
+// 伪造源地址的报文收不到cookie, 不会占用连接和accept队列
+func (l *Listener) getConn(addr net.Addr, b []byte) *Conn {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	key := addr.String()
+	if c, ok := l.conns[key]; ok {
+		return c
+	}
+	if l.closed() {
+		return nil
+	}
+	conv := binary.LittleEndian.Uint32(b)
+	epoch := time.Now().Unix() / int64(kCookieEpoch/time.Second)
+	switch b[4] {
+	case cmdPush:
+		if l.verify {
+			l.pc.WriteTo(l.cookie(addr, conv, epoch), addr)
+			return nil
+		}
+	case cmdCookie:
+		if !hmac.Equal(b, l.cookie(addr, conv, epoch)) && !hmac.Equal(b, l.cookie(addr, conv, epoch-1)) {
+			return nil
+		}
+	default:
+		return nil
+	}
+	c := newConn(conv, l.pc, addr, l)
+	select {
+	case l.accept <- c:
+	default:
+		// 等待accept的连接太多
+		close(c.die)
+		return nil
+	}
+	l.conns[key] = c
+	return c
+}
+
+// cookie报文: conv(4) + cmd(1) + 保留(3) + cookie(16), 与kcp报文头一样长
+func (l *Listener) cookie(addr net.Addr, conv uint32, epoch int64) []byte {
+	b := make([]byte, 8, kOverhead)
+	binary.LittleEndian.PutUint32(b, conv)
+	b[4] = cmdCookie
+	mac := hmac.New(sha256.New, l.secret)
+	mac.Write([]byte(addr.String()))
+	mac.Write(b[:4])
+	mac.Write(binary.LittleEndian.AppendUint64(nil, uint64(epoch)))
+	return mac.Sum(b)[:8+kCookieSize]
+}
+
+func (l *Listener) remove(c *Conn) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	key := c.raddr.String()
+	if l.conns[key] == c {
+		delete(l.conns, key)
+	}
+	if l.closed() && len(l.conns) == 0 {
+		l.pc.Close()
+	}
+}
+
+func (l *Listener) closed() bool {
+	select {
+	case <-l.die:
+		return true
+	default:
+		return false
+	}
+}
+
+func (l *Listener) Accept() (net.Conn, error) {
+	select {
+	case c := <-l.accept:
+		return c, nil
+	case <-l.die:
+		return nil, net.ErrClosed
+	}
+}
+
+// 停止接受新连接, 已建立的连接全部关闭后再关闭pc
+func (l *Listener) Close() error {
+	err := net.ErrClosed
+	l.once.Do(func() {
+		err = nil
+		l.mutex.Lock()
+		close(l.die)
+		empty := len(l.conns) == 0
+		l.mutex.Unlock()
+		if empty {
+			l.pc.Close()
+		}
+		// 关闭还没有accept的连接
+		for {
+			select {
+			case c := <-l.accept:
+				c.Close()
+			default:
+				return
+			}
+		}
+	})
+	return err
+}
+
+func (l *Listener) Addr() net.Addr {
+	return l.pc.LocalAddr()
+}
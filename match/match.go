@@ -0,0 +1,274 @@
+package match
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/iakud/plumeserver/skiplist"
+)
+
+var (
+	ErrTicketExists = errors.New("match: ticket exists")
+	ErrPlayerQueued = errors.New("match: player already queued")
+	ErrPartySize    = errors.New("match: invalid party size")
+)
+
+// 匹配的单位, 单人或组队, 组队的成员分在同一队
+type Ticket struct {
+	Id      uint64
+	Players []uint64
+	Rating  int32     // 组队时为平均分
+	Time    time.Time // 加入的时间, Join时设置
+}
+
+// 匹配成功的对局
+type Match struct {
+	Teams [][]*Ticket
+}
+
+type options struct {
+	teams     int
+	window    int32
+	growth    float64
+	maxWindow int32
+	timeout   time.Duration
+	onTimeout func(t *Ticket)
+}
+
+type Option func(o *options)
+
+// 每局的队伍数量, 默认2
+func WithTeams(teams int) Option {
+	return func(o *options) {
+		o.teams = teams
+	}
+}
+
+// 分数范围从window开始, 每等待一秒扩大growth, 最大为max
+func WithWindow(window int32, growth float64, max int32) Option {
+	return func(o *options) {
+		o.window = window
+		o.growth = growth
+		o.maxWindow = max
+	}
+}
+
+// 等待超时后移出队列并回调
+func WithTimeout(timeout time.Duration, f func(t *Ticket)) Option {
+	return func(o *options) {
+		o.timeout = timeout
+		o.onTimeout = f
+	}
+}
+
+type entry struct {
+	ticket  *Ticket
+	seq     uint64
+	element *skiplist.Element
+}
+
+// 按分数排序, 同分先加入的在前
+func (e *entry) Less(other skiplist.Interface) bool {
+	o := other.(*entry)
+	if e.ticket.Rating != o.ticket.Rating {
+		return e.ticket.Rating < o.ticket.Rating
+	}
+	return e.seq < o.seq
+}
+
+// 匹配队列, 非并发安全, 只在逻辑线程使用
+type Matcher struct {
+	size    int
+	opts    options
+	seq     uint64
+	ratings *skiplist.SkipList
+	entries map[uint64]*entry
+	players map[uint64]uint64 // 玩家所在的ticket
+	queue   []*entry          // 按加入顺序, 离开的在Update时清理
+}
+
+// 每队size人
+func NewMatcher(size int, o ...Option) *Matcher {
+	opts := options{teams: 2, window: 100, growth: 10, maxWindow: 1000}
+	for _, option := range o {
+		option(&opts)
+	}
+	return &Matcher{
+		size:    size,
+		opts:    opts,
+		ratings: skiplist.New(),
+		entries: make(map[uint64]*entry),
+		players: make(map[uint64]uint64),
+	}
+}
+
+func (m *Matcher) Len() int {
+	return len(m.entries)
+}
+
+func (m *Matcher) Get(id uint64) *Ticket {
+	if e, ok := m.entries[id]; ok {
+		return e.ticket
+	}
+	return nil
+}
+
+func (m *Matcher) Join(t *Ticket, now time.Time) error {
+	if len(t.Players) == 0 || len(t.Players) > m.size {
+		return ErrPartySize
+	}
+	if _, ok := m.entries[t.Id]; ok {
+		return ErrTicketExists
+	}
+	for _, playerId := range t.Players {
+		if _, ok := m.players[playerId]; ok {
+			return ErrPlayerQueued
+		}
+	}
+	t.Time = now
+	m.seq++
+	e := &entry{ticket: t, seq: m.seq}
+	e.element = m.ratings.Insert(e)
+	m.entries[t.Id] = e
+	for _, playerId := range t.Players {
+		m.players[playerId] = t.Id
+	}
+	m.queue = append(m.queue, e)
+	return nil
+}
+
+func (m *Matcher) Leave(id uint64) bool {
+	e, ok := m.entries[id]
+	if !ok {
+		return false
+	}
+	m.remove(e)
+	return true
+}
+
+func (m *Matcher) remove(e *entry) {
+	m.ratings.Delete(e)
+	delete(m.entries, e.ticket.Id)
+	for _, playerId := range e.ticket.Players {
+		delete(m.players, playerId)
+	}
+}
+
+// 等待越久可接受的分数范围越大
+func (m *Matcher) window(e *entry, now time.Time) int32 {
+	w := float64(m.opts.window) + now.Sub(e.ticket.Time).Seconds()*m.opts.growth
+	return int32(min(w, float64(m.opts.maxWindow)))
+}
+
+func abs(x int32) int32 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// 处理超时, 从等待最久的开始匹配, 返回本次形成的对局
+func (m *Matcher) Update(now time.Time) []*Match {
+	queue := m.queue[:0]
+	for _, e := range m.queue {
+		if _, ok := m.entries[e.ticket.Id]; !ok {
+			continue
+		}
+		if m.opts.timeout > 0 && now.Sub(e.ticket.Time) >= m.opts.timeout {
+			m.remove(e)
+			if m.opts.onTimeout != nil {
+				m.opts.onTimeout(e.ticket)
+			}
+			continue
+		}
+		queue = append(queue, e)
+	}
+	m.queue = queue
+
+	var matches []*Match
+	for _, e := range m.queue {
+		if _, ok := m.entries[e.ticket.Id]; !ok {
+			continue
+		}
+		if match := m.match(e, now); match != nil {
+			matches = append(matches, match)
+		}
+	}
+	return matches
+}
+
+// 以e为中心向两侧选取分数最接近的, 双方的分数范围都要满足
+func (m *Matcher) match(e *entry, now time.Time) *Match {
+	w := m.window(e, now)
+	rating := e.ticket.Rating
+	need := m.size * m.opts.teams
+	selected := []*entry{e}
+	players := len(e.ticket.Players)
+	prev, next := e.element.Prev(), e.element.Next()
+	for players < need && (prev != nil || next != nil) {
+		var c *entry
+		if next == nil || prev != nil && rating-prev.Value.(*entry).ticket.Rating <= next.Value.(*entry).ticket.Rating-rating {
+			c, prev = prev.Value.(*entry), prev.Prev()
+		} else {
+			c, next = next.Value.(*entry), next.Next()
+		}
+		diff := abs(c.ticket.Rating - rating)
+		if diff > w {
+			// 这一侧已超出范围
+			if c.ticket.Rating < rating {
+				prev = nil
+			} else {
+				next = nil
+			}
+			continue
+		}
+		if diff > m.window(c, now) || players+len(c.ticket.Players) > need {
+			continue
+		}
+		if m.pack(append(selected, c)) == nil {
+			continue
+		}
+		selected = append(selected, c)
+		players += len(c.ticket.Players)
+	}
+	if players < need {
+		return nil
+	}
+	teams := m.pack(selected)
+	for _, c := range selected {
+		m.remove(c)
+	}
+	return &Match{Teams: teams}
+}
+
+// 组队人数多的先分, 每次分到空位最多的队伍, 空位相同时分到总分低的, 无法分配时返回nil
+func (m *Matcher) pack(entries []*entry) [][]*Ticket {
+	sorted := make([]*entry, len(entries))
+	copy(sorted, entries)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return len(sorted[i].ticket.Players) > len(sorted[j].ticket.Players)
+	})
+	teams := make([][]*Ticket, m.opts.teams)
+	counts := make([]int, m.opts.teams)
+	ratings := make([]int64, m.opts.teams)
+	for _, e := range sorted {
+		n := len(e.ticket.Players)
+		best := -1
+		for i := range teams {
+			if counts[i]+n > m.size {
+				continue
+			}
+			if best < 0 || counts[i] < counts[best] || counts[i] == counts[best] && ratings[i] < ratings[best] {
+				best = i
+			}
+		}
+		if best < 0 {
+			return nil
+		}
+		teams[best] = append(teams[best], e.ticket)
+		counts[best] += n
+		ratings[best] += int64(e.ticket.Rating) * int64(n)
+	}
+	return teams
+}
@@ -0,0 +1,210 @@
+package match
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// 模拟的时钟
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) advance(d time.Duration) time.Time {
+	c.now = c.now.Add(d)
+	return c.now
+}
+
+func solo(id uint64, rating int32) *Ticket {
+	return &Ticket{Id: id, Players: []uint64{id}, Rating: rating}
+}
+
+func TestWindow(t *testing.T) {
+	c := &clock{now: time.Unix(1700000000, 0)}
+	m := NewMatcher(1, WithWindow(50, 10, 200))
+	m.Join(solo(1, 1000), c.now)
+	m.Join(solo(2, 1100), c.now)
+	if matches := m.Update(c.now); len(matches) != 0 {
+		t.Fatalf("unexpected matches %v", matches)
+	}
+	// 4秒后范围为90, 还不够
+	if matches := m.Update(c.advance(time.Second * 4)); len(matches) != 0 {
+		t.Fatalf("unexpected matches %v", matches)
+	}
+	matches := m.Update(c.advance(time.Second))
+	if len(matches) != 1 || matches[0].Teams[0][0].Id != 1 || matches[0].Teams[1][0].Id != 2 || m.Len() != 0 {
+		t.Fatalf("unexpected matches %v", matches)
+	}
+
+	// 双方的范围都要满足, 新加入的范围较小
+	m.Join(solo(3, 1000), c.now)
+	m.Join(solo(4, 1150), c.advance(time.Second*10))
+	if matches := m.Update(c.now); len(matches) != 0 {
+		t.Fatalf("unexpected matches %v", matches)
+	}
+	if matches := m.Update(c.advance(time.Second * 10)); len(matches) != 1 {
+		t.Fatalf("unexpected matches %v", matches)
+	}
+	// 范围不超过最大值
+	m.Join(solo(5, 1000), c.now)
+	m.Join(solo(6, 1201), c.now)
+	if matches := m.Update(c.advance(time.Hour)); len(matches) != 0 {
+		t.Fatalf("unexpected matches %v", matches)
+	}
+}
+
+func TestClosest(t *testing.T) {
+	c := &clock{now: time.Unix(1700000000, 0)}
+	m := NewMatcher(2, WithWindow(100, 0, 100))
+	for i, rating := range []int32{1000, 1090, 1010, 950, 1030, 1200} {
+		m.Join(solo(uint64(i+1), rating), c.advance(time.Millisecond))
+	}
+	matches := m.Update(c.now)
+	if len(matches) != 1 {
+		t.Fatalf("unexpected matches %v", matches)
+	}
+	// 等待最久的1和最接近的3, 5, 4
+	ids := make(map[uint64]bool)
+	for _, team := range matches[0].Teams {
+		if len(team) != 2 {
+			t.Fatalf("unexpected team %v", team)
+		}
+		for _, ticket := range team {
+			ids[ticket.Id] = true
+		}
+	}
+	for _, id := range []uint64{1, 3, 4, 5} {
+		if !ids[id] {
+			t.Fatalf("unexpected matched %v", ids)
+		}
+	}
+	if m.Len() != 2 || m.Get(2) == nil || m.Get(6) == nil {
+		t.Fatalf("unexpected queue %v", m.Len())
+	}
+}
+
+func TestParty(t *testing.T) {
+	c := &clock{now: time.Unix(1700000000, 0)}
+	m := NewMatcher(3)
+	if err := m.Join(&Ticket{Id: 1, Players: []uint64{1, 2, 3, 4}}, c.now); err != ErrPartySize {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if err := m.Join(&Ticket{Id: 1, Players: []uint64{1, 2}, Rating: 1000}, c.now); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Join(&Ticket{Id: 1, Players: []uint64{5}}, c.now); err != ErrTicketExists {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if err := m.Join(&Ticket{Id: 2, Players: []uint64{2}}, c.now); err != ErrPlayerQueued {
+		t.Fatalf("unexpected error %v", err)
+	}
+	// 两个双人组不能分在同一队
+	m.Join(&Ticket{Id: 3, Players: []uint64{3, 4}, Rating: 1000}, c.now)
+	m.Join(&Ticket{Id: 5, Players: []uint64{5, 6}, Rating: 1000}, c.now)
+	m.Join(solo(7, 1000), c.now)
+	m.Join(solo(8, 1000), c.now)
+	matches := m.Update(c.now)
+	if len(matches) != 1 {
+		t.Fatalf("unexpected matches %v", matches)
+	}
+	for _, team := range matches[0].Teams {
+		if len(team) != 2 || len(team[0].Players)+len(team[1].Players) != 3 {
+			t.Fatalf("unexpected team %v", team)
+		}
+	}
+	if m.Len() != 1 || m.Get(5) == nil {
+		t.Fatalf("unexpected queue %v", m.Len())
+	}
+	if !m.Leave(5) || m.Leave(5) || m.Len() != 0 {
+		t.Fatal("leave failed")
+	}
+	if err := m.Join(solo(6, 1000), c.now); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	c := &clock{now: time.Unix(1700000000, 0)}
+	var timeouts []uint64
+	m := NewMatcher(1, WithWindow(10, 0, 10), WithTimeout(time.Minute, func(t *Ticket) {
+		timeouts = append(timeouts, t.Id)
+	}))
+	m.Join(solo(1, 1000), c.now)
+	m.Join(solo(2, 2000), c.advance(time.Second*30))
+	m.Update(c.advance(time.Second * 29))
+	if len(timeouts) != 0 {
+		t.Fatalf("unexpected timeouts %v", timeouts)
+	}
+	m.Update(c.advance(time.Second))
+	if len(timeouts) != 1 || timeouts[0] != 1 || m.Get(1) != nil {
+		t.Fatalf("unexpected timeouts %v", timeouts)
+	}
+	// 超时的玩家可以重新加入
+	m.Join(solo(1, 2005), c.now)
+	if matches := m.Update(c.now); len(matches) != 1 {
+		t.Fatalf("unexpected matches %v", matches)
+	}
+	m.Update(c.advance(time.Hour))
+	if len(timeouts) != 1 {
+		t.Fatalf("unexpected timeouts %v", timeouts)
+	}
+}
+
+// 随机加入, 检查对局人数和分数范围
+func TestRandom(t *testing.T) {
+	c := &clock{now: time.Unix(1700000000, 0)}
+	const size, window = 3, 100
+	m := NewMatcher(size, WithTeams(3), WithWindow(window, 20, 300))
+	r := rand.New(rand.NewSource(1))
+	var id uint64
+	matched := make(map[uint64]bool)
+	for i := 0; i < 1000; i++ {
+		id++
+		ticket := &Ticket{Id: id, Rating: int32(1000 + r.Intn(1000))}
+		for n := 1 + r.Intn(size); n > 0; n-- {
+			ticket.Players = append(ticket.Players, id*10+uint64(n))
+		}
+		if err := m.Join(ticket, c.now); err != nil {
+			t.Fatal(err)
+		}
+		for _, match := range m.Update(c.advance(time.Millisecond * 200)) {
+			var low, high int32 = 1 << 30, 0
+			for _, team := range match.Teams {
+				var players int
+				for _, ticket := range team {
+					if matched[ticket.Id] {
+						t.Fatalf("ticket %v matched twice", ticket.Id)
+					}
+					matched[ticket.Id] = true
+					players += len(ticket.Players)
+					low, high = min(low, ticket.Rating), max(high, ticket.Rating)
+				}
+				if players != size {
+					t.Fatalf("unexpected team %v", team)
+				}
+			}
+			if high-low > 2*300 {
+				t.Fatalf("unexpected ratings %v %v", low, high)
+			}
+		}
+	}
+	if len(matched)+m.Len() != int(id) {
+		t.Fatalf("unexpected tickets %v + %v", len(matched), m.Len())
+	}
+}
+
+func BenchmarkUpdate(b *testing.B) {
+	now := time.Unix(1700000000, 0)
+	m := NewMatcher(5, WithWindow(50, 0, 50))
+	r := rand.New(rand.NewSource(1))
+	var id uint64
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j := 0; j < 10; j++ {
+			id++
+			m.Join(solo(id, int32(r.Intn(3000))), now)
+		}
+		m.Update(now)
+	}
+}
@@ -0,0 +1,185 @@
+package rank
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/iakud/plumeserver/skiplist"
+)
+
+// 排行榜中的一项, Time为达到当前分数的时间, 单位毫秒
+type Entry struct {
+	Id    uint64 `json:"id"`
+	Score int64  `json:"score"`
+	Time  int64  `json:"time"`
+	Rank  int    `json:"-"`
+}
+
+// 分数高的在前, 同分先达到的在前, 最后按id保证顺序唯一
+func (e *Entry) Less(other skiplist.Interface) bool {
+	o := other.(*Entry)
+	if e.Score != o.Score {
+		return e.Score > o.Score
+	}
+	if e.Time != o.Time {
+		return e.Time < o.Time
+	}
+	return e.Id < o.Id
+}
+
+type options struct {
+	capacity int
+}
+
+type Option func(o *options)
+
+// 只保留前capacity名, 0为不限制
+func WithCapacity(capacity int) Option {
+	return func(o *options) {
+		o.capacity = capacity
+	}
+}
+
+// 排行榜, 非并发安全, 只在逻辑线程使用
+type Board struct {
+	name    string
+	opts    options
+	list    *skiplist.SkipList
+	members map[uint64]*Entry
+}
+
+func NewBoard(name string, o ...Option) *Board {
+	var opts options
+	for _, option := range o {
+		option(&opts)
+	}
+	return &Board{
+		name:    name,
+		opts:    opts,
+		list:    skiplist.New(),
+		members: make(map[uint64]*Entry),
+	}
+}
+
+func (b *Board) Name() string {
+	return b.name
+}
+
+func (b *Board) Len() int {
+	return b.list.Len()
+}
+
+// 更新分数, 分数不变时保留原来的达到时间, 返回新的排名, 未上榜返回0
+func (b *Board) Update(id uint64, score int64, now time.Time) int {
+	return b.update(id, score, now.UnixMilli())
+}
+
+func (b *Board) update(id uint64, score int64, reached int64) int {
+	if e, ok := b.members[id]; ok {
+		if e.Score == score {
+			return b.list.GetRank(e)
+		}
+		b.list.Delete(e)
+		delete(b.members, id)
+	}
+	e := &Entry{Id: id, Score: score, Time: reached}
+	if b.opts.capacity > 0 && b.list.Len() >= b.opts.capacity {
+		last := b.list.Back().Value.(*Entry)
+		if !e.Less(last) {
+			return 0
+		}
+		b.list.Delete(last)
+		delete(b.members, last.Id)
+	}
+	b.list.Insert(e)
+	b.members[id] = e
+	return b.list.GetRank(e)
+}
+
+func (b *Board) Remove(id uint64) bool {
+	e, ok := b.members[id]
+	if !ok {
+		return false
+	}
+	b.list.Delete(e)
+	delete(b.members, id)
+	return true
+}
+
+// 排名从1开始, 未上榜返回0
+func (b *Board) Rank(id uint64) int {
+	e, ok := b.members[id]
+	if !ok {
+		return 0
+	}
+	return b.list.GetRank(e)
+}
+
+func (b *Board) Get(id uint64) (Entry, bool) {
+	e, ok := b.members[id]
+	if !ok {
+		return Entry{}, false
+	}
+	entry := *e
+	entry.Rank = b.list.GetRank(e)
+	return entry, true
+}
+
+// 排名在[start, end]之间的项
+func (b *Board) Range(start, end int) []Entry {
+	start = max(start, 1)
+	end = min(end, b.list.Len())
+	if start > end {
+		return nil
+	}
+	entries := make([]Entry, 0, end-start+1)
+	rank := start
+	for el := b.list.GetElementByRank(start); el != nil && rank <= end; el = el.Next() {
+		entry := *el.Value.(*Entry)
+		entry.Rank = rank
+		entries = append(entries, entry)
+		rank++
+	}
+	return entries
+}
+
+// 前n名
+func (b *Board) Top(n int) []Entry {
+	return b.Range(1, n)
+}
+
+// 成员前后各n名, 未上榜返回nil
+func (b *Board) Around(id uint64, n int) []Entry {
+	rank := b.Rank(id)
+	if rank == 0 {
+		return nil
+	}
+	return b.Range(rank-n, rank+n)
+}
+
+func (b *Board) Clear() {
+	b.list = skiplist.New()
+	b.members = make(map[uint64]*Entry)
+}
+
+// 按排名顺序保存
+func (b *Board) Marshal() ([]byte, error) {
+	entries := make([]*Entry, 0, b.list.Len())
+	for el := b.list.Front(); el != nil; el = el.Next() {
+		entries = append(entries, el.Value.(*Entry))
+	}
+	return json.Marshal(entries)
+}
+
+// 替换为快照中的数据
+func (b *Board) Unmarshal(data []byte) error {
+	var entries []*Entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return err
+	}
+	b.Clear()
+	for _, e := range entries {
+		b.update(e.Id, e.Score, e.Time)
+	}
+	return nil
+}
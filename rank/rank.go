@@ -0,0 +1,95 @@
+package rank
+
+import (
+	"errors"
+
+	"github.com/iakud/plumeserver/store"
+)
+
+var ErrBoardExists = errors.New("rank: board exists")
+
+// 命名的排行榜, 快照保存在store中, key为"rank/<name>"
+type Ranks struct {
+	store  store.Store
+	boards map[string]*Board
+}
+
+func NewRanks(s store.Store) *Ranks {
+	return &Ranks{
+		store:  s,
+		boards: make(map[string]*Board),
+	}
+}
+
+func (r *Ranks) key(name string) string {
+	return "rank/" + name
+}
+
+func (r *Ranks) Board(name string) *Board {
+	return r.boards[name]
+}
+
+// 打开排行榜, 有快照时从快照恢复
+func (r *Ranks) Open(name string, o ...Option) (*Board, error) {
+	if _, ok := r.boards[name]; ok {
+		return nil, ErrBoardExists
+	}
+	b := NewBoard(name, o...)
+	data, _, err := r.store.Get(r.key(name))
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+	case err != nil:
+		return nil, err
+	default:
+		if err := b.Unmarshal(data); err != nil {
+			return nil, err
+		}
+	}
+	r.boards[name] = b
+	return b, nil
+}
+
+// 关闭排行榜, 不保存
+func (r *Ranks) Close(name string) {
+	delete(r.boards, name)
+}
+
+// 删除排行榜和快照
+func (r *Ranks) Delete(name string) error {
+	delete(r.boards, name)
+	err := r.store.Delete(r.key(name), store.AnyVersion)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+func (r *Ranks) Save(name string) error {
+	b, ok := r.boards[name]
+	if !ok {
+		return nil
+	}
+	data, err := b.Marshal()
+	if err != nil {
+		return err
+	}
+	_, err = r.store.Put(r.key(name), data, store.AnyVersion)
+	return err
+}
+
+// 所有排行榜在一个批量操作中保存
+func (r *Ranks) SaveAll() error {
+	ops := make([]store.Op, 0, len(r.boards))
+	for name, b := range r.boards {
+		data, err := b.Marshal()
+		if err != nil {
+			return err
+		}
+		ops = append(ops, store.Op{Key: r.key(name), Value: data, Version: store.AnyVersion})
+	}
+	if len(ops) == 0 {
+		return nil
+	}
+	_, err := r.store.Batch(ops)
+	return err
+}
@@ -0,0 +1,164 @@
+package rank
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/iakud/plumeserver/store"
+)
+
+func ids(entries []Entry) []uint64 {
+	var ids []uint64
+	for _, e := range entries {
+		ids = append(ids, e.Id)
+	}
+	return ids
+}
+
+func equal(a, b []uint64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestBoard(t *testing.T) {
+	b := NewBoard("level")
+	now := time.Unix(1000, 0)
+	b.Update(1, 10, now)
+	b.Update(2, 20, now)
+	b.Update(3, 10, now.Add(time.Second))
+	b.Update(4, 10, now.Add(-time.Second))
+	// 同分先达到的在前
+	if got := ids(b.Top(10)); !equal(got, []uint64{2, 4, 1, 3}) {
+		t.Fatalf("top %v", got)
+	}
+	// 分数不变不更新时间
+	if rank := b.Update(1, 10, now.Add(time.Hour)); rank != 3 {
+		t.Fatalf("rank %v", rank)
+	}
+	if rank := b.Update(3, 30, now); rank != 1 {
+		t.Fatalf("rank %v", rank)
+	}
+	if got := ids(b.Top(2)); !equal(got, []uint64{3, 2}) {
+		t.Fatalf("top %v", got)
+	}
+	around := b.Around(4, 1)
+	if got := ids(around); !equal(got, []uint64{2, 4, 1}) || around[0].Rank != 2 || around[2].Rank != 4 {
+		t.Fatalf("around %v", around)
+	}
+	if b.Around(5, 1) != nil || b.Rank(5) != 0 {
+		t.Fatal("not ranked")
+	}
+	if !b.Remove(2) || b.Rank(4) != 2 || b.Len() != 3 {
+		t.Fatalf("remove rank %v", b.Rank(4))
+	}
+	if e, ok := b.Get(1); !ok || e.Rank != 3 || e.Score != 10 || e.Time != now.UnixMilli() {
+		t.Fatalf("get %v", e)
+	}
+}
+
+func TestBoardCapacity(t *testing.T) {
+	b := NewBoard("power", WithCapacity(3))
+	now := time.Now()
+	for i := uint64(1); i <= 5; i++ {
+		b.Update(i, int64(i), now)
+	}
+	if got := ids(b.Top(10)); !equal(got, []uint64{5, 4, 3}) {
+		t.Fatalf("top %v", got)
+	}
+	if rank := b.Update(6, 1, now); rank != 0 || b.Len() != 3 {
+		t.Fatalf("rank %v", rank)
+	}
+	// 同分后达到的不能挤掉先达到的
+	if rank := b.Update(6, 3, now.Add(time.Second)); rank != 0 {
+		t.Fatalf("rank %v", rank)
+	}
+	if rank := b.Update(6, 4, now); rank != 3 || b.Rank(3) != 0 {
+		t.Fatalf("rank %v", rank)
+	}
+}
+
+func TestBoardRandom(t *testing.T) {
+	b := NewBoard("random")
+	scores := make(map[uint64]int64)
+	now := time.Unix(0, 0)
+	for i := 0; i < 10000; i++ {
+		id := uint64(rand.Intn(500))
+		score := rand.Int63n(100)
+		b.Update(id, score, now)
+		scores[id] = score
+	}
+	var expected []uint64
+	for id := range scores {
+		expected = append(expected, id)
+	}
+	sort.Slice(expected, func(i, j int) bool {
+		a, b := expected[i], expected[j]
+		if scores[a] != scores[b] {
+			return scores[a] > scores[b]
+		}
+		return a < b
+	})
+	if got := ids(b.Top(len(expected))); !equal(got, expected) {
+		t.Fatal("order")
+	}
+	for i, id := range expected {
+		if b.Rank(id) != i+1 {
+			t.Fatalf("rank of %v: %v, expected %v", id, b.Rank(id), i+1)
+		}
+	}
+	if got := ids(b.Range(100, 109)); !equal(got, expected[99:109]) {
+		t.Fatalf("range %v", got)
+	}
+}
+
+func TestRanksSnapshot(t *testing.T) {
+	s, err := store.OpenFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	ranks := NewRanks(s)
+	level, err := ranks.Open("level")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ranks.Open("level"); err != ErrBoardExists {
+		t.Fatal(err)
+	}
+	arena, _ := ranks.Open("arena")
+	now := time.Unix(1000, 0)
+	level.Update(1, 10, now)
+	level.Update(2, 10, now.Add(-time.Second))
+	arena.Update(3, 5, now)
+	if err := ranks.SaveAll(); err != nil {
+		t.Fatal(err)
+	}
+
+	ranks = NewRanks(s)
+	level, err = ranks.Open("level")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ids(level.Top(10)); !equal(got, []uint64{2, 1}) {
+		t.Fatalf("level %v", got)
+	}
+	if e, _ := level.Get(1); e.Time != now.UnixMilli() {
+		t.Fatalf("time %v", e.Time)
+	}
+	if err := ranks.Delete("arena"); err != nil {
+		t.Fatal(err)
+	}
+	arena, _ = ranks.Open("arena")
+	if arena.Len() != 0 {
+		t.Fatalf("arena %v", arena.Len())
+	}
+}
@@ -0,0 +1,102 @@
+package scene
+
+import (
+	"bufio"
+	"errors"
+	"image"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/iakud/plumeserver/astar"
+	"github.com/iakud/plumeserver/astar/jpsplus"
+)
+
+var ErrMapFormat = errors.New("scene: map format error")
+
+// 格子地图, '#'为障碍, 其余为可行走
+// 寻路图在加载时构建一次, 只读, 多个场景实例可以共享
+type Map struct {
+	name  string
+	size  image.Point
+	cells []bool
+	graph astar.Graph[image.Point]
+}
+
+func NewMap(name string, rows []string) (*Map, error) {
+	if len(rows) == 0 || len(rows[0]) == 0 {
+		return nil, ErrMapFormat
+	}
+	m := &Map{
+		name:  name,
+		size:  image.Pt(len(rows[0]), len(rows)),
+		cells: make([]bool, len(rows)*len(rows[0])),
+	}
+	for y, row := range rows {
+		if len(row) != m.size.X {
+			return nil, ErrMapFormat
+		}
+		for x := 0; x < len(row); x++ {
+			m.cells[y*m.size.X+x] = row[x] != '#'
+		}
+	}
+	m.graph = jpsplus.NewGraph(m, m.size)
+	return m, nil
+}
+
+// 文件每行为地图的一行, 地图名为文件名
+func LoadMap(filename string) (*Map, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var rows []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		rows = append(rows, strings.TrimRight(scanner.Text(), "\r"))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	name := strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+	return NewMap(name, rows)
+}
+
+func (m *Map) Name() string {
+	return m.name
+}
+
+func (m *Map) Size() image.Point {
+	return m.size
+}
+
+func (m *Map) IsFreeAt(p image.Point) bool {
+	if p.X < 0 || p.Y < 0 || p.X >= m.size.X || p.Y >= m.size.Y {
+		return false
+	}
+	return m.cells[p.Y*m.size.X+p.X]
+}
+
+// 坐标所在的格子, x对应列, z对应行
+func Cell(x, z float32) image.Point {
+	return image.Pt(int(math.Floor(float64(x))), int(math.Floor(float64(z))))
+}
+
+func (m *Map) Walkable(x, z float32) bool {
+	return m.IsFreeAt(Cell(x, z))
+}
+
+// 返回经过的跳点, 包括起点和终点, 不可达返回nil
+func (m *Map) FindPath(start, goal image.Point) []image.Point {
+	if !m.IsFreeAt(start) || !m.IsFreeAt(goal) {
+		return nil
+	}
+	return astar.FindPath(m.graph, start, goal, distance, distance)
+}
+
+func distance(p, q image.Point) float64 {
+	d := q.Sub(p)
+	return math.Sqrt(float64(d.X*d.X + d.Y*d.Y))
+}
@@ -0,0 +1,218 @@
+package scene
+
+import (
+	"errors"
+	"image"
+	"math"
+	"time"
+
+	"github.com/iakud/plumeserver/aoi"
+)
+
+var (
+	ErrEntityExists   = errors.New("scene: entity exists")
+	ErrEntityNotFound = errors.New("scene: entity not found")
+	ErrBlocked        = errors.New("scene: position blocked")
+	ErrNoPath         = errors.New("scene: no path")
+)
+
+type waypoint struct {
+	x, z float32
+}
+
+type Entity struct {
+	Id    uint64
+	X, Z  float32
+	Speed float32 // 每秒移动的距离, 单位为格子
+
+	path []waypoint // 剩余的路点
+}
+
+func (e *Entity) Moving() bool {
+	return len(e.path) > 0
+}
+
+// 移动的目标点
+func (e *Entity) Destination() (x, z float32, ok bool) {
+	if len(e.path) == 0 {
+		return e.X, e.Z, false
+	}
+	last := e.path[len(e.path)-1]
+	return last.x, last.z, true
+}
+
+type options struct {
+	aoi    aoi.AOI
+	arrive func(e *Entity)
+}
+
+type Option func(o *options)
+
+// 实体位置变化时同步到AOI
+func WithAOI(aoi aoi.AOI) Option {
+	return func(o *options) {
+		o.aoi = aoi
+	}
+}
+
+// 到达目标点的回调
+func WithArrive(f func(e *Entity)) Option {
+	return func(o *options) {
+		o.arrive = f
+	}
+}
+
+// 场景实例, 只在逻辑线程使用
+type Scene struct {
+	id       uint64
+	m        *Map
+	entities map[uint64]*Entity
+	opts     options
+}
+
+func NewScene(id uint64, m *Map, o ...Option) *Scene {
+	var opts options
+	for _, option := range o {
+		option(&opts)
+	}
+	return &Scene{
+		id:       id,
+		m:        m,
+		entities: make(map[uint64]*Entity),
+		opts:     opts,
+	}
+}
+
+func (s *Scene) Id() uint64 {
+	return s.id
+}
+
+func (s *Scene) Map() *Map {
+	return s.m
+}
+
+func (s *Scene) Entity(id uint64) *Entity {
+	return s.entities[id]
+}
+
+func (s *Scene) Len() int {
+	return len(s.entities)
+}
+
+func (s *Scene) Add(id uint64, x, z, speed float32) (*Entity, error) {
+	if _, ok := s.entities[id]; ok {
+		return nil, ErrEntityExists
+	}
+	if !s.m.Walkable(x, z) {
+		return nil, ErrBlocked
+	}
+	e := &Entity{Id: id, X: x, Z: z, Speed: speed}
+	s.entities[id] = e
+	if s.opts.aoi != nil {
+		s.opts.aoi.Enter(id, x, z)
+	}
+	return e, nil
+}
+
+func (s *Scene) Remove(id uint64) {
+	if _, ok := s.entities[id]; !ok {
+		return
+	}
+	delete(s.entities, id)
+	if s.opts.aoi != nil {
+		s.opts.aoi.Leave(id)
+	}
+}
+
+// 直接设置位置, 停止移动
+func (s *Scene) Teleport(id uint64, x, z float32) error {
+	e, ok := s.entities[id]
+	if !ok {
+		return ErrEntityNotFound
+	}
+	if !s.m.Walkable(x, z) {
+		return ErrBlocked
+	}
+	e.X, e.Z = x, z
+	e.path = nil
+	if s.opts.aoi != nil {
+		s.opts.aoi.Move(id, x, z)
+	}
+	return nil
+}
+
+// 寻路并开始移动, 返回经过的格子路径
+func (s *Scene) MoveTo(id uint64, x, z float32) ([]image.Point, error) {
+	e, ok := s.entities[id]
+	if !ok {
+		return nil, ErrEntityNotFound
+	}
+	if !s.m.Walkable(x, z) {
+		return nil, ErrBlocked
+	}
+	path := s.m.FindPath(Cell(e.X, e.Z), Cell(x, z))
+	if path == nil {
+		return nil, ErrNoPath
+	}
+	// 跳点之间按格子中心移动, 不在中心的起点和终点只在所在的格子内移动, 不会穿过拐角
+	var waypoints []waypoint
+	if len(path) > 1 {
+		waypoints = make([]waypoint, 0, len(path)+1)
+		if start := center(path[0]); start != (waypoint{e.X, e.Z}) {
+			waypoints = append(waypoints, start)
+		}
+		for i := 1; i < len(path); i++ {
+			waypoints = append(waypoints, center(path[i]))
+		}
+		if waypoints[len(waypoints)-1] == (waypoint{x, z}) {
+			waypoints = waypoints[:len(waypoints)-1]
+		}
+	}
+	e.path = append(waypoints, waypoint{x, z})
+	return path, nil
+}
+
+func center(p image.Point) waypoint {
+	return waypoint{float32(p.X) + 0.5, float32(p.Y) + 0.5}
+}
+
+func (s *Scene) Stop(id uint64) {
+	if e, ok := s.entities[id]; ok {
+		e.path = nil
+	}
+}
+
+// 按经过的时间推进所有移动中的实体
+func (s *Scene) Update(dt time.Duration) {
+	for _, e := range s.entities {
+		if len(e.path) == 0 {
+			continue
+		}
+		s.move(e, e.Speed*float32(dt.Seconds()))
+		if s.opts.aoi != nil {
+			s.opts.aoi.Move(e.Id, e.X, e.Z)
+		}
+		if len(e.path) == 0 && s.opts.arrive != nil {
+			s.opts.arrive(e)
+		}
+	}
+}
+
+func (s *Scene) move(e *Entity, distance float32) {
+	for distance > 0 && len(e.path) > 0 {
+		next := e.path[0]
+		dx, dz := next.x-e.X, next.z-e.Z
+		d := float32(math.Sqrt(float64(dx*dx + dz*dz)))
+		if d > distance {
+			e.X += dx / d * distance
+			e.Z += dz / d * distance
+			return
+		}
+		e.X, e.Z = next.x, next.z
+		e.path = e.path[1:]
+		distance -= d
+	}
+	if len(e.path) == 0 {
+		e.path = nil
+	}
+}
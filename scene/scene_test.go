@@ -0,0 +1,282 @@
+package scene
+
+import (
+	"image"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/iakud/plumeserver/aoi"
+)
+
+var testRows = []string{
+	"          ",
+	" ######## ",
+	"        # ",
+	"######  # ",
+	"        # ",
+	" ########.",
+	"          ",
+}
+
+func newTestMap(t *testing.T) *Map {
+	m, err := NewMap("test", testRows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestLoadMap(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "dungeon.txt")
+	var data []byte
+	for _, row := range testRows {
+		data = append(data, row+"\r\n"...)
+	}
+	if err := os.WriteFile(filename, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	m, err := LoadMap(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Name() != "dungeon" || m.Size() != image.Pt(10, 7) {
+		t.Fatalf("name %v size %v", m.Name(), m.Size())
+	}
+	if m.IsFreeAt(image.Pt(1, 1)) || !m.IsFreeAt(image.Pt(9, 5)) || m.IsFreeAt(image.Pt(10, 0)) {
+		t.Fatal("cells")
+	}
+	if _, err := NewMap("bad", []string{"   ", "  "}); err != ErrMapFormat {
+		t.Fatal(err)
+	}
+}
+
+func TestFindPath(t *testing.T) {
+	m := newTestMap(t)
+	start, goal := image.Pt(0, 2), image.Pt(0, 6)
+	path := m.FindPath(start, goal)
+	if len(path) < 2 || path[0] != start || path[len(path)-1] != goal {
+		t.Fatalf("path %v", path)
+	}
+	checkPath(t, m, path)
+	if m.FindPath(start, image.Pt(1, 1)) != nil {
+		t.Fatal("path to obstacle")
+	}
+}
+
+// 相邻跳点之间是直线或对角线, 经过的格子可行走且不穿过拐角
+func checkPath(t *testing.T, m *Map, path []image.Point) {
+	for i := 1; i < len(path); i++ {
+		d := path[i].Sub(path[i-1])
+		v := image.Pt(sign(d.X), sign(d.Y))
+		if d.X != 0 && d.Y != 0 && abs(d.X) != abs(d.Y) {
+			t.Fatalf("segment %v -> %v", path[i-1], path[i])
+		}
+		for p := path[i-1]; p != path[i]; p = p.Add(v) {
+			if !m.IsFreeAt(p.Add(v)) || !m.IsFreeAt(p.Add(image.Pt(v.X, 0))) || !m.IsFreeAt(p.Add(image.Pt(0, v.Y))) {
+				t.Fatalf("blocked %v in %v", p, path)
+			}
+		}
+	}
+}
+
+func sign(x int) int {
+	switch {
+	case x > 0:
+		return 1
+	case x < 0:
+		return -1
+	}
+	return 0
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+type countListener struct {
+	enter, leave int
+}
+
+func (l *countListener) EnterView(watcher, target uint64) { l.enter++ }
+func (l *countListener) LeaveView(watcher, target uint64) { l.leave++ }
+
+func TestSceneMove(t *testing.T) {
+	m := newTestMap(t)
+	listener := &countListener{}
+	var arrived []uint64
+	s := NewScene(1, m, WithAOI(aoi.NewGrid(3, listener)), WithArrive(func(e *Entity) {
+		arrived = append(arrived, e.Id)
+	}))
+	e, err := s.Add(1, 0.5, 2.5, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Add(1, 0.5, 2.5, 4); err != ErrEntityExists {
+		t.Fatal(err)
+	}
+	if _, err := s.Add(2, 1.5, 1.5, 4); err != ErrBlocked {
+		t.Fatal(err)
+	}
+	if _, err := s.Add(2, 0.5, 6.5, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.MoveTo(1, 1.5, 1.5); err != ErrBlocked {
+		t.Fatal(err)
+	}
+	path, err := s.MoveTo(1, 0.8, 6.2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !e.Moving() {
+		t.Fatal("not moving")
+	}
+	for i := 0; i < 100 && e.Moving(); i++ {
+		s.Update(100 * time.Millisecond)
+		if !m.Walkable(e.X, e.Z) {
+			t.Fatalf("entity at blocked %v,%v", e.X, e.Z)
+		}
+	}
+	if e.Moving() || e.X != 0.8 || e.Z != 6.2 {
+		t.Fatalf("entity at %v,%v path %v", e.X, e.Z, path)
+	}
+	if len(arrived) != 1 || arrived[0] != 1 {
+		t.Fatalf("arrived %v", arrived)
+	}
+	if listener.enter != 2 {
+		t.Fatalf("enter view %v", listener.enter)
+	}
+	s.Remove(2)
+	if listener.leave != 2 || s.Len() != 1 {
+		t.Fatalf("leave view %v", listener.leave)
+	}
+}
+
+// 多个副本共享同一个寻路图
+func TestSceneShareMap(t *testing.T) {
+	m := newTestMap(t)
+	scenes := []*Scene{NewScene(1, m), NewScene(2, m)}
+	for i, s := range scenes {
+		if _, err := s.Add(1, 0.5, 0.5, 2); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.MoveTo(1, 9.5, float32(i)+5.5); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 20; i++ {
+		for _, s := range scenes {
+			s.Update(time.Second)
+		}
+	}
+	for i, s := range scenes {
+		e := s.Entity(1)
+		if e.Moving() || e.X != 9.5 || e.Z != float32(i)+5.5 {
+			t.Fatalf("scene %v entity at %v,%v", s.Id(), e.X, e.Z)
+		}
+	}
+}
+
+// 随机地图上与广度优先搜索比较可达性
+func TestFindPathRandom(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	for n := 0; n < 20; n++ {
+		rows := make([]string, 20)
+		for y := range rows {
+			row := make([]byte, 30)
+			for x := range row {
+				row[x] = ' '
+				if random.Intn(4) == 0 {
+					row[x] = '#'
+				}
+			}
+			rows[y] = string(row)
+		}
+		m, err := NewMap("random", rows)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 50; i++ {
+			start := image.Pt(random.Intn(30), random.Intn(20))
+			goal := image.Pt(random.Intn(30), random.Intn(20))
+			if !m.IsFreeAt(start) || !m.IsFreeAt(goal) {
+				continue
+			}
+			path := m.FindPath(start, goal)
+			if reachable(m, start, goal) != (path != nil) {
+				t.Fatalf("map %v: %v -> %v path %v", n, start, goal, path)
+			}
+			checkPath(t, m, path)
+		}
+	}
+}
+
+func reachable(m *Map, start, goal image.Point) bool {
+	visited := map[image.Point]bool{start: true}
+	queue := []image.Point{start}
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		if p == goal {
+			return true
+		}
+		for _, v := range []image.Point{{1, 0}, {-1, 0}, {0, 1}, {0, -1}} {
+			if next := p.Add(v); m.IsFreeAt(next) && !visited[next] {
+				visited[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+	return false
+}
+
+// 随机地图上从格子内任意位置移动, 路线经过的位置都可行走
+func TestSceneMoveRandom(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	for n := 0; n < 20; n++ {
+		rows := make([]string, 20)
+		for y := range rows {
+			row := make([]byte, 30)
+			for x := range row {
+				row[x] = ' '
+				if random.Intn(4) == 0 {
+					row[x] = '#'
+				}
+			}
+			rows[y] = string(row)
+		}
+		m, err := NewMap("random", rows)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s := NewScene(1, m)
+		for i := 0; i < 50; i++ {
+			x, z := random.Float32()*30, random.Float32()*20
+			toX, toZ := random.Float32()*30, random.Float32()*20
+			if !m.Walkable(x, z) || !m.Walkable(toX, toZ) {
+				continue
+			}
+			e, err := s.Add(1, x, z, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := s.MoveTo(1, toX, toZ); err == nil {
+				fromX, fromZ := x, z
+				for _, w := range e.path {
+					for k := float32(0); k <= 64; k++ {
+						if px, pz := fromX+(w.x-fromX)*k/64, fromZ+(w.z-fromZ)*k/64; !m.Walkable(px, pz) {
+							t.Fatalf("map %v: %v,%v -> %v,%v crosses blocked %v,%v", n, x, z, toX, toZ, px, pz)
+						}
+					}
+					fromX, fromZ = w.x, w.z
+				}
+			}
+			s.Remove(1)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// gate与game之间的消息类型
+const (
+	BackendConnect    uint8 = iota + 1 // 客户端连接
+	BackendDisconnect                  // 客户端断开
+	BackendData                        // 消息转发
+	BackendKick                        // game要求断开客户端
+	BackendMulticast                   // 发送给多个客户端
+	BackendBroadcast                   // 发送给gate上的所有客户端
+	BackendGroupcast                   // 发送给组内的客户端, Session为组id
+	BackendJoinGroup                   // 客户端加入组, Payload为组id
+	BackendLeaveGroup                  // 客户端离开组, Payload为组id
+	BackendAccount                     // 客户端登录的账号, Payload为账号
+	BackendState                       // 客户端会话状态, Payload为状态
+)
+
+// 断开原因
+const (
+	ReasonClosed       uint8 = iota // 正常关闭
+	ReasonIdleTimeout               // 读超时
+	ReasonWriteTimeout              // 写超时
+	ReasonKicked                    // 被踢下线
+	ReasonError                     // 协议错误
+	ReasonShutdown                  // gate关闭
+)
+
+const kBackendHeaderSize = 1 + 8 + 2 // type + session + cmd
+const kBackendMaxSize = 16 << 20
+
+var ErrBackendPacket = errors.New("Backend packet error")
+
+type BackendPacket struct {
+	Type    uint8
+	Session uint64
+	Cmd     int16
+	Payload []byte
+}
+
+func (this *BackendPacket) Marshal() []byte {
+	b := make([]byte, kBackendHeaderSize+len(this.Payload))
+	b[0] = this.Type
+	binary.BigEndian.PutUint64(b[1:], this.Session)
+	binary.BigEndian.PutUint16(b[9:], uint16(this.Cmd))
+	copy(b[kBackendHeaderSize:], this.Payload)
+	return b
+}
+
+func (this *BackendPacket) Unmarshal(b []byte) error {
+	if len(b) < kBackendHeaderSize {
+		return ErrBackendPacket
+	}
+	this.Type = b[0]
+	this.Session = binary.BigEndian.Uint64(b[1:])
+	this.Cmd = int16(binary.BigEndian.Uint16(b[9:]))
+	this.Payload = b[kBackendHeaderSize:]
+	return nil
+}
+
+// 断开原因放在Payload中
+func (this *BackendPacket) Reason() uint8 {
+	if len(this.Payload) == 0 {
+		return ReasonClosed
+	}
+	return this.Payload[0]
+}
+
+// 连接: version(2) | build(4) | remoteAddr
+func NewConnectPacket(session uint64, version uint16, build uint32, remoteAddr string) *BackendPacket {
+	b := make([]byte, 6+len(remoteAddr))
+	binary.BigEndian.PutUint16(b, version)
+	binary.BigEndian.PutUint32(b[2:], build)
+	copy(b[6:], remoteAddr)
+	return &BackendPacket{Type: BackendConnect, Session: session, Payload: b}
+}
+
+func (this *BackendPacket) Connect() (uint16, uint32, string, error) {
+	if len(this.Payload) < 6 {
+		return 0, 0, "", ErrBackendPacket
+	}
+	version := binary.BigEndian.Uint16(this.Payload)
+	build := binary.BigEndian.Uint32(this.Payload[2:])
+	return version, build, string(this.Payload[6:]), nil
+}
+
+func NewDisconnectPacket(session uint64, reason uint8) *BackendPacket {
+	return &BackendPacket{Type: BackendDisconnect, Session: session, Payload: []byte{reason}}
+}
+
+// 多播: count(4) | sessions(8*count) | payload
+func NewMulticastPacket(sessions []uint64, cmd int16, payload []byte) *BackendPacket {
+	b := make([]byte, 4+8*len(sessions)+len(payload))
+	binary.BigEndian.PutUint32(b, uint32(len(sessions)))
+	for i, session := range sessions {
+		binary.BigEndian.PutUint64(b[4+8*i:], session)
+	}
+	copy(b[4+8*len(sessions):], payload)
+	return &BackendPacket{Type: BackendMulticast, Cmd: cmd, Payload: b}
+}
+
+func (this *BackendPacket) Multicast() ([]uint64, []byte, error) {
+	if len(this.Payload) < 4 {
+		return nil, nil, ErrBackendPacket
+	}
+	n := int(binary.BigEndian.Uint32(this.Payload))
+	if len(this.Payload) < 4+8*n {
+		return nil, nil, ErrBackendPacket
+	}
+	sessions := make([]uint64, n)
+	for i := range sessions {
+		sessions[i] = binary.BigEndian.Uint64(this.Payload[4+8*i:])
+	}
+	return sessions, this.Payload[4+8*n:], nil
+}
+
+func NewGroupPacket(packetType uint8, session uint64, group uint64) *BackendPacket {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, group)
+	return &BackendPacket{Type: packetType, Session: session, Payload: b}
+}
+
+func (this *BackendPacket) Group() (uint64, error) {
+	if len(this.Payload) < 8 {
+		return 0, ErrBackendPacket
+	}
+	return binary.BigEndian.Uint64(this.Payload), nil
+}
+
+type backendCodec struct {
+}
+
+// gate与game之间的编码: size(4) + packet
+var BackendCodec *backendCodec = &backendCodec{}
+
+func (this *backendCodec) Read(r io.Reader) ([]byte, error) {
+	h := make([]byte, 4)
+	if _, err := io.ReadFull(r, h); err != nil {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(h)
+	if n > kBackendMaxSize {
+		return nil, ErrBackendPacket
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (this *backendCodec) Write(w io.Writer, b []byte) error {
+	h := make([]byte, 4)
+	binary.BigEndian.PutUint32(h, uint32(len(b)))
+	if _, err := w.Write(h); err != nil {
+		return err
+	}
+	if _, err := w.Write(b); err != nil {
+		return err
+	}
+	return nil
+}
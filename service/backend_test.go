@@ -0,0 +1,54 @@
+package service
+
+import (
+	"testing"
+)
+
+func TestBackendPacket(t *testing.T) {
+	packet := &BackendPacket{Type: BackendData, Session: 1001, Cmd: cmd1, Payload: []byte("hello")}
+	var p BackendPacket
+	if err := p.Unmarshal(packet.Marshal()); err != nil {
+		t.Fatal(err)
+	}
+	if p.Type != packet.Type || p.Session != packet.Session || p.Cmd != packet.Cmd || string(p.Payload) != "hello" {
+		t.Fatalf("unexpected packet: %v", p)
+	}
+	if err := p.Unmarshal([]byte{BackendData}); err != ErrBackendPacket {
+		t.Fatal("short packet unmarshaled")
+	}
+}
+
+func TestMulticastPacket(t *testing.T) {
+	packet := NewMulticastPacket([]uint64{1, 2, 3}, cmd2, []byte("hello"))
+	var p BackendPacket
+	if err := p.Unmarshal(packet.Marshal()); err != nil {
+		t.Fatal(err)
+	}
+	sessions, payload, err := p.Multicast()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 3 || sessions[0] != 1 || sessions[2] != 3 || p.Cmd != cmd2 || string(payload) != "hello" {
+		t.Fatalf("unexpected multicast: %v, %v", sessions, payload)
+	}
+
+	packet = NewGroupPacket(BackendJoinGroup, 1, 42)
+	if group, err := packet.Group(); err != nil || group != 42 {
+		t.Fatalf("unexpected group: %v, %v", group, err)
+	}
+}
+
+func TestConnectPacket(t *testing.T) {
+	packet := NewConnectPacket(1001, 2, 12345, "127.0.0.1:5000")
+	var p BackendPacket
+	if err := p.Unmarshal(packet.Marshal()); err != nil {
+		t.Fatal(err)
+	}
+	version, build, remoteAddr, err := p.Connect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Session != 1001 || version != 2 || build != 12345 || remoteAddr != "127.0.0.1:5000" {
+		t.Fatalf("unexpected connect: %v, %v, %v", version, build, remoteAddr)
+	}
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+
+	"github.com/iakud/plumeserver/table"
+)
+
+// 会话状态, 由game通知gate
+const (
+	StateGuest  uint8 = iota // 未登录
+	StatePlayer              // 已登录
+	StateGM                  // GM
+)
+
+const kStateBits = 8 // 状态掩码为uint8, 最多8个状态
+
+var (
+	ErrCommandState = errors.New("Unknow command state")
+	ErrSessionState = errors.New("service: invalid session state")
+)
+
+// 状态是否可以用于掩码
+func ValidState(state uint8) bool {
+	return state < kStateBits
+}
+
+var stateNames = map[string]uint8{
+	"guest":  StateGuest,
+	"player": StatePlayer,
+	"gm":     StateGM,
+}
+
+// 命令目录中的一项, States为允许发送该命令的会话状态
+type Command struct {
+	Cmd    int16    `json:"cmd"`
+	Name   string   `json:"name"`
+	States []string `json:"states"`
+}
+
+func (this *Command) Key() int16 {
+	return this.Cmd
+}
+
+// 允许的状态掩码
+func (this *Command) StateMask() (uint8, error) {
+	var mask uint8
+	for _, name := range this.States {
+		state, ok := stateNames[name]
+		if !ok {
+			return 0, ErrCommandState
+		}
+		mask |= 1 << state
+	}
+	return mask, nil
+}
+
+// 从json文件加载命令目录
+func LoadCommands(filename string) (map[int16]*Command, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var commands []*Command
+	if err := json.Unmarshal(data, &commands); err != nil {
+		return nil, err
+	}
+	catalog := make(map[int16]*Command)
+	if err := table.SliceToMap(commands, catalog); err != nil {
+		return nil, err
+	}
+	return catalog, nil
+}
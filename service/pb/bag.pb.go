@@ -0,0 +1,445 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.33.0
+// 	protoc        (unknown)
+// source: bag.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type BagItem struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id    *int32 `protobuf:"varint,1,opt,name=id" json:"id,omitempty"`
+	Count *int64 `protobuf:"varint,2,opt,name=count" json:"count,omitempty"`
+}
+
+func (x *BagItem) Reset() {
+	*x = BagItem{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_bag_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BagItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BagItem) ProtoMessage() {}
+
+func (x *BagItem) ProtoReflect() protoreflect.Message {
+	mi := &file_bag_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BagItem.ProtoReflect.Descriptor instead.
+func (*BagItem) Descriptor() ([]byte, []int) {
+	return file_bag_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *BagItem) GetId() int32 {
+	if x != nil && x.Id != nil {
+		return *x.Id
+	}
+	return 0
+}
+
+func (x *BagItem) GetCount() int64 {
+	if x != nil && x.Count != nil {
+		return *x.Count
+	}
+	return 0
+}
+
+type BagListRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *BagListRequest) Reset() {
+	*x = BagListRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_bag_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BagListRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BagListRequest) ProtoMessage() {}
+
+func (x *BagListRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bag_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BagListRequest.ProtoReflect.Descriptor instead.
+func (*BagListRequest) Descriptor() ([]byte, []int) {
+	return file_bag_proto_rawDescGZIP(), []int{1}
+}
+
+type BagListResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Items      []*BagItem `protobuf:"bytes,1,rep,name=items" json:"items,omitempty"`
+	Currencies []*BagItem `protobuf:"bytes,2,rep,name=currencies" json:"currencies,omitempty"`
+	Capacity   *int32     `protobuf:"varint,3,opt,name=capacity" json:"capacity,omitempty"`
+}
+
+func (x *BagListResponse) Reset() {
+	*x = BagListResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_bag_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BagListResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BagListResponse) ProtoMessage() {}
+
+func (x *BagListResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bag_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BagListResponse.ProtoReflect.Descriptor instead.
+func (*BagListResponse) Descriptor() ([]byte, []int) {
+	return file_bag_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *BagListResponse) GetItems() []*BagItem {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+func (x *BagListResponse) GetCurrencies() []*BagItem {
+	if x != nil {
+		return x.Currencies
+	}
+	return nil
+}
+
+func (x *BagListResponse) GetCapacity() int32 {
+	if x != nil && x.Capacity != nil {
+		return *x.Capacity
+	}
+	return 0
+}
+
+type BagChange struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id    *int32 `protobuf:"varint,1,opt,name=id" json:"id,omitempty"`
+	Delta *int64 `protobuf:"varint,2,opt,name=delta" json:"delta,omitempty"`
+	Count *int64 `protobuf:"varint,3,opt,name=count" json:"count,omitempty"`
+}
+
+func (x *BagChange) Reset() {
+	*x = BagChange{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_bag_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BagChange) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BagChange) ProtoMessage() {}
+
+func (x *BagChange) ProtoReflect() protoreflect.Message {
+	mi := &file_bag_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BagChange.ProtoReflect.Descriptor instead.
+func (*BagChange) Descriptor() ([]byte, []int) {
+	return file_bag_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *BagChange) GetId() int32 {
+	if x != nil && x.Id != nil {
+		return *x.Id
+	}
+	return 0
+}
+
+func (x *BagChange) GetDelta() int64 {
+	if x != nil && x.Delta != nil {
+		return *x.Delta
+	}
+	return 0
+}
+
+func (x *BagChange) GetCount() int64 {
+	if x != nil && x.Count != nil {
+		return *x.Count
+	}
+	return 0
+}
+
+type BagChangeNotify struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Changes []*BagChange `protobuf:"bytes,1,rep,name=changes" json:"changes,omitempty"`
+	Reason  *string      `protobuf:"bytes,2,opt,name=reason" json:"reason,omitempty"`
+}
+
+func (x *BagChangeNotify) Reset() {
+	*x = BagChangeNotify{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_bag_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BagChangeNotify) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BagChangeNotify) ProtoMessage() {}
+
+func (x *BagChangeNotify) ProtoReflect() protoreflect.Message {
+	mi := &file_bag_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BagChangeNotify.ProtoReflect.Descriptor instead.
+func (*BagChangeNotify) Descriptor() ([]byte, []int) {
+	return file_bag_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *BagChangeNotify) GetChanges() []*BagChange {
+	if x != nil {
+		return x.Changes
+	}
+	return nil
+}
+
+func (x *BagChangeNotify) GetReason() string {
+	if x != nil && x.Reason != nil {
+		return *x.Reason
+	}
+	return ""
+}
+
+var File_bag_proto protoreflect.FileDescriptor
+
+var file_bag_proto_rawDesc = []byte{
+	0x0a, 0x09, 0x62, 0x61, 0x67, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x02, 0x70, 0x62, 0x22,
+	0x2f, 0x0a, 0x07, 0x42, 0x61, 0x67, 0x49, 0x74, 0x65, 0x6d, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x02, 0x69, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x6f,
+	0x75, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74,
+	0x22, 0x10, 0x0a, 0x0e, 0x42, 0x61, 0x67, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x22, 0x7d, 0x0a, 0x0f, 0x42, 0x61, 0x67, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x21, 0x0a, 0x05, 0x69, 0x74, 0x65, 0x6d, 0x73, 0x18, 0x01,
+	0x20, 0x03, 0x28, 0x0b, 0x32, 0x0b, 0x2e, 0x70, 0x62, 0x2e, 0x42, 0x61, 0x67, 0x49, 0x74, 0x65,
+	0x6d, 0x52, 0x05, 0x69, 0x74, 0x65, 0x6d, 0x73, 0x12, 0x2b, 0x0a, 0x0a, 0x63, 0x75, 0x72, 0x72,
+	0x65, 0x6e, 0x63, 0x69, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0b, 0x2e, 0x70,
+	0x62, 0x2e, 0x42, 0x61, 0x67, 0x49, 0x74, 0x65, 0x6d, 0x52, 0x0a, 0x63, 0x75, 0x72, 0x72, 0x65,
+	0x6e, 0x63, 0x69, 0x65, 0x73, 0x12, 0x1a, 0x0a, 0x08, 0x63, 0x61, 0x70, 0x61, 0x63, 0x69, 0x74,
+	0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x63, 0x61, 0x70, 0x61, 0x63, 0x69, 0x74,
+	0x79, 0x22, 0x47, 0x0a, 0x09, 0x42, 0x61, 0x67, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x12, 0x0e,
+	0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x02, 0x69, 0x64, 0x12, 0x14,
+	0x0a, 0x05, 0x64, 0x65, 0x6c, 0x74, 0x61, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x64,
+	0x65, 0x6c, 0x74, 0x61, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x22, 0x52, 0x0a, 0x0f, 0x42, 0x61,
+	0x67, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x4e, 0x6f, 0x74, 0x69, 0x66, 0x79, 0x12, 0x27, 0x0a,
+	0x07, 0x63, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0d,
+	0x2e, 0x70, 0x62, 0x2e, 0x42, 0x61, 0x67, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x52, 0x07, 0x63,
+	0x68, 0x61, 0x6e, 0x67, 0x65, 0x73, 0x12, 0x16, 0x0a, 0x06, 0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x42, 0x29,
+	0x5a, 0x27, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x69, 0x61, 0x6b,
+	0x75, 0x64, 0x2f, 0x70, 0x6c, 0x75, 0x6d, 0x65, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2f, 0x73,
+	0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2f, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x32,
+}
+
+var (
+	file_bag_proto_rawDescOnce sync.Once
+	file_bag_proto_rawDescData = file_bag_proto_rawDesc
+)
+
+func file_bag_proto_rawDescGZIP() []byte {
+	file_bag_proto_rawDescOnce.Do(func() {
+		file_bag_proto_rawDescData = protoimpl.X.CompressGZIP(file_bag_proto_rawDescData)
+	})
+	return file_bag_proto_rawDescData
+}
+
+var file_bag_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_bag_proto_goTypes = []interface{}{
+	(*BagItem)(nil),         // 0: pb.BagItem
+	(*BagListRequest)(nil),  // 1: pb.BagListRequest
+	(*BagListResponse)(nil), // 2: pb.BagListResponse
+	(*BagChange)(nil),       // 3: pb.BagChange
+	(*BagChangeNotify)(nil), // 4: pb.BagChangeNotify
+}
+var file_bag_proto_depIdxs = []int32{
+	0, // 0: pb.BagListResponse.items:type_name -> pb.BagItem
+	0, // 1: pb.BagListResponse.currencies:type_name -> pb.BagItem
+	3, // 2: pb.BagChangeNotify.changes:type_name -> pb.BagChange
+	3, // [3:3] is the sub-list for method output_type
+	3, // [3:3] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
+}
+
+func init() { file_bag_proto_init() }
+func file_bag_proto_init() {
+	if File_bag_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_bag_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BagItem); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_bag_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BagListRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_bag_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BagListResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_bag_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BagChange); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_bag_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BagChangeNotify); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_bag_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   5,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_bag_proto_goTypes,
+		DependencyIndexes: file_bag_proto_depIdxs,
+		MessageInfos:      file_bag_proto_msgTypes,
+	}.Build()
+	File_bag_proto = out.File
+	file_bag_proto_rawDesc = nil
+	file_bag_proto_goTypes = nil
+	file_bag_proto_depIdxs = nil
+}
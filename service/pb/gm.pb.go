@@ -0,0 +1,214 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.33.0
+// 	protoc        (unknown)
+// source: gm.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type GMRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Line *string `protobuf:"bytes,1,opt,name=line" json:"line,omitempty"`
+}
+
+func (x *GMRequest) Reset() {
+	*x = GMRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_gm_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GMRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GMRequest) ProtoMessage() {}
+
+func (x *GMRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gm_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GMRequest.ProtoReflect.Descriptor instead.
+func (*GMRequest) Descriptor() ([]byte, []int) {
+	return file_gm_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *GMRequest) GetLine() string {
+	if x != nil && x.Line != nil {
+		return *x.Line
+	}
+	return ""
+}
+
+type GMResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Result *int32  `protobuf:"varint,1,opt,name=result" json:"result,omitempty"`
+	Output *string `protobuf:"bytes,2,opt,name=output" json:"output,omitempty"`
+}
+
+func (x *GMResponse) Reset() {
+	*x = GMResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_gm_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GMResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GMResponse) ProtoMessage() {}
+
+func (x *GMResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_gm_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GMResponse.ProtoReflect.Descriptor instead.
+func (*GMResponse) Descriptor() ([]byte, []int) {
+	return file_gm_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *GMResponse) GetResult() int32 {
+	if x != nil && x.Result != nil {
+		return *x.Result
+	}
+	return 0
+}
+
+func (x *GMResponse) GetOutput() string {
+	if x != nil && x.Output != nil {
+		return *x.Output
+	}
+	return ""
+}
+
+var File_gm_proto protoreflect.FileDescriptor
+
+var file_gm_proto_rawDesc = []byte{
+	0x0a, 0x08, 0x67, 0x6d, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x02, 0x70, 0x62, 0x22, 0x1f,
+	0x0a, 0x09, 0x47, 0x4d, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x6c,
+	0x69, 0x6e, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6c, 0x69, 0x6e, 0x65, 0x22,
+	0x3c, 0x0a, 0x0a, 0x47, 0x4d, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x16, 0x0a,
+	0x06, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x06, 0x72,
+	0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x42, 0x29, 0x5a,
+	0x27, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x69, 0x61, 0x6b, 0x75,
+	0x64, 0x2f, 0x70, 0x6c, 0x75, 0x6d, 0x65, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2f, 0x73, 0x65,
+	0x72, 0x76, 0x69, 0x63, 0x65, 0x2f, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x32,
+}
+
+var (
+	file_gm_proto_rawDescOnce sync.Once
+	file_gm_proto_rawDescData = file_gm_proto_rawDesc
+)
+
+func file_gm_proto_rawDescGZIP() []byte {
+	file_gm_proto_rawDescOnce.Do(func() {
+		file_gm_proto_rawDescData = protoimpl.X.CompressGZIP(file_gm_proto_rawDescData)
+	})
+	return file_gm_proto_rawDescData
+}
+
+var file_gm_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_gm_proto_goTypes = []interface{}{
+	(*GMRequest)(nil),  // 0: pb.GMRequest
+	(*GMResponse)(nil), // 1: pb.GMResponse
+}
+var file_gm_proto_depIdxs = []int32{
+	0, // [0:0] is the sub-list for method output_type
+	0, // [0:0] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_gm_proto_init() }
+func file_gm_proto_init() {
+	if File_gm_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_gm_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GMRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_gm_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GMResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_gm_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_gm_proto_goTypes,
+		DependencyIndexes: file_gm_proto_depIdxs,
+		MessageInfos:      file_gm_proto_msgTypes,
+	}.Build()
+	File_gm_proto = out.File
+	file_gm_proto_rawDesc = nil
+	file_gm_proto_goTypes = nil
+	file_gm_proto_depIdxs = nil
+}
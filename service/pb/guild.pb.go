@@ -0,0 +1,1176 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.33.0
+// 	protoc        (unknown)
+// source: guild.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type GuildMember struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id       *uint64 `protobuf:"varint,1,opt,name=id" json:"id,omitempty"`
+	Name     *string `protobuf:"bytes,2,opt,name=name" json:"name,omitempty"`
+	Role     *int32  `protobuf:"varint,3,opt,name=role" json:"role,omitempty"`
+	JoinTime *int64  `protobuf:"varint,4,opt,name=join_time,json=joinTime" json:"join_time,omitempty"`
+}
+
+func (x *GuildMember) Reset() {
+	*x = GuildMember{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_guild_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GuildMember) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GuildMember) ProtoMessage() {}
+
+func (x *GuildMember) ProtoReflect() protoreflect.Message {
+	mi := &file_guild_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GuildMember.ProtoReflect.Descriptor instead.
+func (*GuildMember) Descriptor() ([]byte, []int) {
+	return file_guild_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *GuildMember) GetId() uint64 {
+	if x != nil && x.Id != nil {
+		return *x.Id
+	}
+	return 0
+}
+
+func (x *GuildMember) GetName() string {
+	if x != nil && x.Name != nil {
+		return *x.Name
+	}
+	return ""
+}
+
+func (x *GuildMember) GetRole() int32 {
+	if x != nil && x.Role != nil {
+		return *x.Role
+	}
+	return 0
+}
+
+func (x *GuildMember) GetJoinTime() int64 {
+	if x != nil && x.JoinTime != nil {
+		return *x.JoinTime
+	}
+	return 0
+}
+
+type GuildInfo struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id       *uint64        `protobuf:"varint,1,opt,name=id" json:"id,omitempty"`
+	Name     *string        `protobuf:"bytes,2,opt,name=name" json:"name,omitempty"`
+	Notice   *string        `protobuf:"bytes,3,opt,name=notice" json:"notice,omitempty"`
+	Members  []*GuildMember `protobuf:"bytes,4,rep,name=members" json:"members,omitempty"`
+	Requests []*GuildMember `protobuf:"bytes,5,rep,name=requests" json:"requests,omitempty"`
+}
+
+func (x *GuildInfo) Reset() {
+	*x = GuildInfo{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_guild_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GuildInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GuildInfo) ProtoMessage() {}
+
+func (x *GuildInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_guild_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GuildInfo.ProtoReflect.Descriptor instead.
+func (*GuildInfo) Descriptor() ([]byte, []int) {
+	return file_guild_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *GuildInfo) GetId() uint64 {
+	if x != nil && x.Id != nil {
+		return *x.Id
+	}
+	return 0
+}
+
+func (x *GuildInfo) GetName() string {
+	if x != nil && x.Name != nil {
+		return *x.Name
+	}
+	return ""
+}
+
+func (x *GuildInfo) GetNotice() string {
+	if x != nil && x.Notice != nil {
+		return *x.Notice
+	}
+	return ""
+}
+
+func (x *GuildInfo) GetMembers() []*GuildMember {
+	if x != nil {
+		return x.Members
+	}
+	return nil
+}
+
+func (x *GuildInfo) GetRequests() []*GuildMember {
+	if x != nil {
+		return x.Requests
+	}
+	return nil
+}
+
+type GuildResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Result *int32     `protobuf:"varint,1,opt,name=result" json:"result,omitempty"`
+	Guild  *GuildInfo `protobuf:"bytes,2,opt,name=guild" json:"guild,omitempty"`
+}
+
+func (x *GuildResponse) Reset() {
+	*x = GuildResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_guild_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GuildResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GuildResponse) ProtoMessage() {}
+
+func (x *GuildResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_guild_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GuildResponse.ProtoReflect.Descriptor instead.
+func (*GuildResponse) Descriptor() ([]byte, []int) {
+	return file_guild_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GuildResponse) GetResult() int32 {
+	if x != nil && x.Result != nil {
+		return *x.Result
+	}
+	return 0
+}
+
+func (x *GuildResponse) GetGuild() *GuildInfo {
+	if x != nil {
+		return x.Guild
+	}
+	return nil
+}
+
+type GuildCreateRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name *string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+}
+
+func (x *GuildCreateRequest) Reset() {
+	*x = GuildCreateRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_guild_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GuildCreateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GuildCreateRequest) ProtoMessage() {}
+
+func (x *GuildCreateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_guild_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GuildCreateRequest.ProtoReflect.Descriptor instead.
+func (*GuildCreateRequest) Descriptor() ([]byte, []int) {
+	return file_guild_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GuildCreateRequest) GetName() string {
+	if x != nil && x.Name != nil {
+		return *x.Name
+	}
+	return ""
+}
+
+type GuildInfoRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *GuildInfoRequest) Reset() {
+	*x = GuildInfoRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_guild_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GuildInfoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GuildInfoRequest) ProtoMessage() {}
+
+func (x *GuildInfoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_guild_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GuildInfoRequest.ProtoReflect.Descriptor instead.
+func (*GuildInfoRequest) Descriptor() ([]byte, []int) {
+	return file_guild_proto_rawDescGZIP(), []int{4}
+}
+
+type GuildApplyRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	GuildId *uint64 `protobuf:"varint,1,opt,name=guild_id,json=guildId" json:"guild_id,omitempty"`
+}
+
+func (x *GuildApplyRequest) Reset() {
+	*x = GuildApplyRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_guild_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GuildApplyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GuildApplyRequest) ProtoMessage() {}
+
+func (x *GuildApplyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_guild_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GuildApplyRequest.ProtoReflect.Descriptor instead.
+func (*GuildApplyRequest) Descriptor() ([]byte, []int) {
+	return file_guild_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GuildApplyRequest) GetGuildId() uint64 {
+	if x != nil && x.GuildId != nil {
+		return *x.GuildId
+	}
+	return 0
+}
+
+type GuildApproveRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	PlayerId *uint64 `protobuf:"varint,1,opt,name=player_id,json=playerId" json:"player_id,omitempty"`
+	Accept   *bool   `protobuf:"varint,2,opt,name=accept" json:"accept,omitempty"`
+}
+
+func (x *GuildApproveRequest) Reset() {
+	*x = GuildApproveRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_guild_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GuildApproveRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GuildApproveRequest) ProtoMessage() {}
+
+func (x *GuildApproveRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_guild_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GuildApproveRequest.ProtoReflect.Descriptor instead.
+func (*GuildApproveRequest) Descriptor() ([]byte, []int) {
+	return file_guild_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *GuildApproveRequest) GetPlayerId() uint64 {
+	if x != nil && x.PlayerId != nil {
+		return *x.PlayerId
+	}
+	return 0
+}
+
+func (x *GuildApproveRequest) GetAccept() bool {
+	if x != nil && x.Accept != nil {
+		return *x.Accept
+	}
+	return false
+}
+
+type GuildInviteRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	PlayerId *uint64 `protobuf:"varint,1,opt,name=player_id,json=playerId" json:"player_id,omitempty"`
+}
+
+func (x *GuildInviteRequest) Reset() {
+	*x = GuildInviteRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_guild_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GuildInviteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GuildInviteRequest) ProtoMessage() {}
+
+func (x *GuildInviteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_guild_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GuildInviteRequest.ProtoReflect.Descriptor instead.
+func (*GuildInviteRequest) Descriptor() ([]byte, []int) {
+	return file_guild_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *GuildInviteRequest) GetPlayerId() uint64 {
+	if x != nil && x.PlayerId != nil {
+		return *x.PlayerId
+	}
+	return 0
+}
+
+type GuildAcceptRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	GuildId *uint64 `protobuf:"varint,1,opt,name=guild_id,json=guildId" json:"guild_id,omitempty"`
+}
+
+func (x *GuildAcceptRequest) Reset() {
+	*x = GuildAcceptRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_guild_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GuildAcceptRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GuildAcceptRequest) ProtoMessage() {}
+
+func (x *GuildAcceptRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_guild_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GuildAcceptRequest.ProtoReflect.Descriptor instead.
+func (*GuildAcceptRequest) Descriptor() ([]byte, []int) {
+	return file_guild_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *GuildAcceptRequest) GetGuildId() uint64 {
+	if x != nil && x.GuildId != nil {
+		return *x.GuildId
+	}
+	return 0
+}
+
+type GuildLeaveRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *GuildLeaveRequest) Reset() {
+	*x = GuildLeaveRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_guild_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GuildLeaveRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GuildLeaveRequest) ProtoMessage() {}
+
+func (x *GuildLeaveRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_guild_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GuildLeaveRequest.ProtoReflect.Descriptor instead.
+func (*GuildLeaveRequest) Descriptor() ([]byte, []int) {
+	return file_guild_proto_rawDescGZIP(), []int{9}
+}
+
+type GuildKickRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	PlayerId *uint64 `protobuf:"varint,1,opt,name=player_id,json=playerId" json:"player_id,omitempty"`
+}
+
+func (x *GuildKickRequest) Reset() {
+	*x = GuildKickRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_guild_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GuildKickRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GuildKickRequest) ProtoMessage() {}
+
+func (x *GuildKickRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_guild_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GuildKickRequest.ProtoReflect.Descriptor instead.
+func (*GuildKickRequest) Descriptor() ([]byte, []int) {
+	return file_guild_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *GuildKickRequest) GetPlayerId() uint64 {
+	if x != nil && x.PlayerId != nil {
+		return *x.PlayerId
+	}
+	return 0
+}
+
+type GuildSetRoleRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	PlayerId *uint64 `protobuf:"varint,1,opt,name=player_id,json=playerId" json:"player_id,omitempty"`
+	Role     *int32  `protobuf:"varint,2,opt,name=role" json:"role,omitempty"`
+}
+
+func (x *GuildSetRoleRequest) Reset() {
+	*x = GuildSetRoleRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_guild_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GuildSetRoleRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GuildSetRoleRequest) ProtoMessage() {}
+
+func (x *GuildSetRoleRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_guild_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GuildSetRoleRequest.ProtoReflect.Descriptor instead.
+func (*GuildSetRoleRequest) Descriptor() ([]byte, []int) {
+	return file_guild_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *GuildSetRoleRequest) GetPlayerId() uint64 {
+	if x != nil && x.PlayerId != nil {
+		return *x.PlayerId
+	}
+	return 0
+}
+
+func (x *GuildSetRoleRequest) GetRole() int32 {
+	if x != nil && x.Role != nil {
+		return *x.Role
+	}
+	return 0
+}
+
+type GuildNoticeRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Notice *string `protobuf:"bytes,1,opt,name=notice" json:"notice,omitempty"`
+}
+
+func (x *GuildNoticeRequest) Reset() {
+	*x = GuildNoticeRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_guild_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GuildNoticeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GuildNoticeRequest) ProtoMessage() {}
+
+func (x *GuildNoticeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_guild_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GuildNoticeRequest.ProtoReflect.Descriptor instead.
+func (*GuildNoticeRequest) Descriptor() ([]byte, []int) {
+	return file_guild_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *GuildNoticeRequest) GetNotice() string {
+	if x != nil && x.Notice != nil {
+		return *x.Notice
+	}
+	return ""
+}
+
+type GuildDisbandRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *GuildDisbandRequest) Reset() {
+	*x = GuildDisbandRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_guild_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GuildDisbandRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GuildDisbandRequest) ProtoMessage() {}
+
+func (x *GuildDisbandRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_guild_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GuildDisbandRequest.ProtoReflect.Descriptor instead.
+func (*GuildDisbandRequest) Descriptor() ([]byte, []int) {
+	return file_guild_proto_rawDescGZIP(), []int{13}
+}
+
+type GuildInviteNotify struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	GuildId   *uint64 `protobuf:"varint,1,opt,name=guild_id,json=guildId" json:"guild_id,omitempty"`
+	GuildName *string `protobuf:"bytes,2,opt,name=guild_name,json=guildName" json:"guild_name,omitempty"`
+	Inviter   *uint64 `protobuf:"varint,3,opt,name=inviter" json:"inviter,omitempty"`
+}
+
+func (x *GuildInviteNotify) Reset() {
+	*x = GuildInviteNotify{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_guild_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GuildInviteNotify) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GuildInviteNotify) ProtoMessage() {}
+
+func (x *GuildInviteNotify) ProtoReflect() protoreflect.Message {
+	mi := &file_guild_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GuildInviteNotify.ProtoReflect.Descriptor instead.
+func (*GuildInviteNotify) Descriptor() ([]byte, []int) {
+	return file_guild_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *GuildInviteNotify) GetGuildId() uint64 {
+	if x != nil && x.GuildId != nil {
+		return *x.GuildId
+	}
+	return 0
+}
+
+func (x *GuildInviteNotify) GetGuildName() string {
+	if x != nil && x.GuildName != nil {
+		return *x.GuildName
+	}
+	return ""
+}
+
+func (x *GuildInviteNotify) GetInviter() uint64 {
+	if x != nil && x.Inviter != nil {
+		return *x.Inviter
+	}
+	return 0
+}
+
+type GuildNotify struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Guild *GuildInfo `protobuf:"bytes,1,opt,name=guild" json:"guild,omitempty"`
+}
+
+func (x *GuildNotify) Reset() {
+	*x = GuildNotify{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_guild_proto_msgTypes[15]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GuildNotify) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GuildNotify) ProtoMessage() {}
+
+func (x *GuildNotify) ProtoReflect() protoreflect.Message {
+	mi := &file_guild_proto_msgTypes[15]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GuildNotify.ProtoReflect.Descriptor instead.
+func (*GuildNotify) Descriptor() ([]byte, []int) {
+	return file_guild_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *GuildNotify) GetGuild() *GuildInfo {
+	if x != nil {
+		return x.Guild
+	}
+	return nil
+}
+
+var File_guild_proto protoreflect.FileDescriptor
+
+var file_guild_proto_rawDesc = []byte{
+	0x0a, 0x0b, 0x67, 0x75, 0x69, 0x6c, 0x64, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x02, 0x70,
+	0x62, 0x22, 0x62, 0x0a, 0x0b, 0x47, 0x75, 0x69, 0x6c, 0x64, 0x4d, 0x65, 0x6d, 0x62, 0x65, 0x72,
+	0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x02, 0x69, 0x64,
+	0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04,
+	0x6e, 0x61, 0x6d, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x72, 0x6f, 0x6c, 0x65, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x05, 0x52, 0x04, 0x72, 0x6f, 0x6c, 0x65, 0x12, 0x1b, 0x0a, 0x09, 0x6a, 0x6f, 0x69, 0x6e,
+	0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x6a, 0x6f, 0x69,
+	0x6e, 0x54, 0x69, 0x6d, 0x65, 0x22, 0x9f, 0x01, 0x0a, 0x09, 0x47, 0x75, 0x69, 0x6c, 0x64, 0x49,
+	0x6e, 0x66, 0x6f, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52,
+	0x02, 0x69, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x6e, 0x6f, 0x74, 0x69, 0x63,
+	0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x6e, 0x6f, 0x74, 0x69, 0x63, 0x65, 0x12,
+	0x29, 0x0a, 0x07, 0x6d, 0x65, 0x6d, 0x62, 0x65, 0x72, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0b,
+	0x32, 0x0f, 0x2e, 0x70, 0x62, 0x2e, 0x47, 0x75, 0x69, 0x6c, 0x64, 0x4d, 0x65, 0x6d, 0x62, 0x65,
+	0x72, 0x52, 0x07, 0x6d, 0x65, 0x6d, 0x62, 0x65, 0x72, 0x73, 0x12, 0x2b, 0x0a, 0x08, 0x72, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x70,
+	0x62, 0x2e, 0x47, 0x75, 0x69, 0x6c, 0x64, 0x4d, 0x65, 0x6d, 0x62, 0x65, 0x72, 0x52, 0x08, 0x72,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x73, 0x22, 0x4c, 0x0a, 0x0d, 0x47, 0x75, 0x69, 0x6c, 0x64,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x72, 0x65, 0x73, 0x75,
+	0x6c, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x06, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74,
+	0x12, 0x23, 0x0a, 0x05, 0x67, 0x75, 0x69, 0x6c, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x0d, 0x2e, 0x70, 0x62, 0x2e, 0x47, 0x75, 0x69, 0x6c, 0x64, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x05,
+	0x67, 0x75, 0x69, 0x6c, 0x64, 0x22, 0x28, 0x0a, 0x12, 0x47, 0x75, 0x69, 0x6c, 0x64, 0x43, 0x72,
+	0x65, 0x61, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x6e,
+	0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x22,
+	0x12, 0x0a, 0x10, 0x47, 0x75, 0x69, 0x6c, 0x64, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x22, 0x2e, 0x0a, 0x11, 0x47, 0x75, 0x69, 0x6c, 0x64, 0x41, 0x70, 0x70, 0x6c,
+	0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x19, 0x0a, 0x08, 0x67, 0x75, 0x69, 0x6c,
+	0x64, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x67, 0x75, 0x69, 0x6c,
+	0x64, 0x49, 0x64, 0x22, 0x4a, 0x0a, 0x13, 0x47, 0x75, 0x69, 0x6c, 0x64, 0x41, 0x70, 0x70, 0x72,
+	0x6f, 0x76, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1b, 0x0a, 0x09, 0x70, 0x6c,
+	0x61, 0x79, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x08, 0x70,
+	0x6c, 0x61, 0x79, 0x65, 0x72, 0x49, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x61, 0x63, 0x63, 0x65, 0x70,
+	0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x61, 0x63, 0x63, 0x65, 0x70, 0x74, 0x22,
+	0x31, 0x0a, 0x12, 0x47, 0x75, 0x69, 0x6c, 0x64, 0x49, 0x6e, 0x76, 0x69, 0x74, 0x65, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1b, 0x0a, 0x09, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x5f,
+	0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x08, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72,
+	0x49, 0x64, 0x22, 0x2f, 0x0a, 0x12, 0x47, 0x75, 0x69, 0x6c, 0x64, 0x41, 0x63, 0x63, 0x65, 0x70,
+	0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x19, 0x0a, 0x08, 0x67, 0x75, 0x69, 0x6c,
+	0x64, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x67, 0x75, 0x69, 0x6c,
+	0x64, 0x49, 0x64, 0x22, 0x13, 0x0a, 0x11, 0x47, 0x75, 0x69, 0x6c, 0x64, 0x4c, 0x65, 0x61, 0x76,
+	0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x2f, 0x0a, 0x10, 0x47, 0x75, 0x69, 0x6c,
+	0x64, 0x4b, 0x69, 0x63, 0x6b, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1b, 0x0a, 0x09,
+	0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52,
+	0x08, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x49, 0x64, 0x22, 0x46, 0x0a, 0x13, 0x47, 0x75, 0x69,
+	0x6c, 0x64, 0x53, 0x65, 0x74, 0x52, 0x6f, 0x6c, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x12, 0x1b, 0x0a, 0x09, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x04, 0x52, 0x08, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x49, 0x64, 0x12, 0x12, 0x0a,
+	0x04, 0x72, 0x6f, 0x6c, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x72, 0x6f, 0x6c,
+	0x65, 0x22, 0x2c, 0x0a, 0x12, 0x47, 0x75, 0x69, 0x6c, 0x64, 0x4e, 0x6f, 0x74, 0x69, 0x63, 0x65,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x6e, 0x6f, 0x74, 0x69, 0x63,
+	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x6e, 0x6f, 0x74, 0x69, 0x63, 0x65, 0x22,
+	0x15, 0x0a, 0x13, 0x47, 0x75, 0x69, 0x6c, 0x64, 0x44, 0x69, 0x73, 0x62, 0x61, 0x6e, 0x64, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x67, 0x0a, 0x11, 0x47, 0x75, 0x69, 0x6c, 0x64, 0x49,
+	0x6e, 0x76, 0x69, 0x74, 0x65, 0x4e, 0x6f, 0x74, 0x69, 0x66, 0x79, 0x12, 0x19, 0x0a, 0x08, 0x67,
+	0x75, 0x69, 0x6c, 0x64, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x67,
+	0x75, 0x69, 0x6c, 0x64, 0x49, 0x64, 0x12, 0x1d, 0x0a, 0x0a, 0x67, 0x75, 0x69, 0x6c, 0x64, 0x5f,
+	0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x67, 0x75, 0x69, 0x6c,
+	0x64, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x69, 0x6e, 0x76, 0x69, 0x74, 0x65, 0x72,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x69, 0x6e, 0x76, 0x69, 0x74, 0x65, 0x72, 0x22,
+	0x32, 0x0a, 0x0b, 0x47, 0x75, 0x69, 0x6c, 0x64, 0x4e, 0x6f, 0x74, 0x69, 0x66, 0x79, 0x12, 0x23,
+	0x0a, 0x05, 0x67, 0x75, 0x69, 0x6c, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0d, 0x2e,
+	0x70, 0x62, 0x2e, 0x47, 0x75, 0x69, 0x6c, 0x64, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x05, 0x67, 0x75,
+	0x69, 0x6c, 0x64, 0x42, 0x29, 0x5a, 0x27, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f,
+	0x6d, 0x2f, 0x69, 0x61, 0x6b, 0x75, 0x64, 0x2f, 0x70, 0x6c, 0x75, 0x6d, 0x65, 0x73, 0x65, 0x72,
+	0x76, 0x65, 0x72, 0x2f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2f, 0x70, 0x62, 0x62, 0x06,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x32,
+}
+
+var (
+	file_guild_proto_rawDescOnce sync.Once
+	file_guild_proto_rawDescData = file_guild_proto_rawDesc
+)
+
+func file_guild_proto_rawDescGZIP() []byte {
+	file_guild_proto_rawDescOnce.Do(func() {
+		file_guild_proto_rawDescData = protoimpl.X.CompressGZIP(file_guild_proto_rawDescData)
+	})
+	return file_guild_proto_rawDescData
+}
+
+var file_guild_proto_msgTypes = make([]protoimpl.MessageInfo, 16)
+var file_guild_proto_goTypes = []interface{}{
+	(*GuildMember)(nil),         // 0: pb.GuildMember
+	(*GuildInfo)(nil),           // 1: pb.GuildInfo
+	(*GuildResponse)(nil),       // 2: pb.GuildResponse
+	(*GuildCreateRequest)(nil),  // 3: pb.GuildCreateRequest
+	(*GuildInfoRequest)(nil),    // 4: pb.GuildInfoRequest
+	(*GuildApplyRequest)(nil),   // 5: pb.GuildApplyRequest
+	(*GuildApproveRequest)(nil), // 6: pb.GuildApproveRequest
+	(*GuildInviteRequest)(nil),  // 7: pb.GuildInviteRequest
+	(*GuildAcceptRequest)(nil),  // 8: pb.GuildAcceptRequest
+	(*GuildLeaveRequest)(nil),   // 9: pb.GuildLeaveRequest
+	(*GuildKickRequest)(nil),    // 10: pb.GuildKickRequest
+	(*GuildSetRoleRequest)(nil), // 11: pb.GuildSetRoleRequest
+	(*GuildNoticeRequest)(nil),  // 12: pb.GuildNoticeRequest
+	(*GuildDisbandRequest)(nil), // 13: pb.GuildDisbandRequest
+	(*GuildInviteNotify)(nil),   // 14: pb.GuildInviteNotify
+	(*GuildNotify)(nil),         // 15: pb.GuildNotify
+}
+var file_guild_proto_depIdxs = []int32{
+	0, // 0: pb.GuildInfo.members:type_name -> pb.GuildMember
+	0, // 1: pb.GuildInfo.requests:type_name -> pb.GuildMember
+	1, // 2: pb.GuildResponse.guild:type_name -> pb.GuildInfo
+	1, // 3: pb.GuildNotify.guild:type_name -> pb.GuildInfo
+	4, // [4:4] is the sub-list for method output_type
+	4, // [4:4] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_guild_proto_init() }
+func file_guild_proto_init() {
+	if File_guild_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_guild_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GuildMember); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_guild_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GuildInfo); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_guild_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GuildResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_guild_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GuildCreateRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_guild_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GuildInfoRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_guild_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GuildApplyRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_guild_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GuildApproveRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_guild_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GuildInviteRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_guild_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GuildAcceptRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_guild_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GuildLeaveRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_guild_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GuildKickRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_guild_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GuildSetRoleRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_guild_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GuildNoticeRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_guild_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GuildDisbandRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_guild_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GuildInviteNotify); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_guild_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GuildNotify); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_guild_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   16,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_guild_proto_goTypes,
+		DependencyIndexes: file_guild_proto_depIdxs,
+		MessageInfos:      file_guild_proto_msgTypes,
+	}.Build()
+	File_guild_proto = out.File
+	file_guild_proto_rawDesc = nil
+	file_guild_proto_goTypes = nil
+	file_guild_proto_depIdxs = nil
+}
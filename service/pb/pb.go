@@ -0,0 +1,4 @@
+// 客户端协议, 修改proto后重新生成, 需要protoc和protoc-gen-go v1.33.0
+package pb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative test.proto player.proto mail.proto bag.proto guild.proto chat.proto gm.proto
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/iakud/plume/network"
+)
+
+// game侧的客户端会话, 通过gate转发消息
+type Session struct {
+	Id         uint64
+	Version    uint16 // 握手协商的协议版本, handler可以根据版本处理
+	Build      uint32 // 客户端构建号
+	RemoteAddr string
+
+	conn *network.TCPConnection
+}
+
+func NewSession(id uint64, version uint16, build uint32, remoteAddr string, conn *network.TCPConnection) *Session {
+	return &Session{Id: id, Version: version, Build: build, RemoteAddr: remoteAddr, conn: conn}
+}
+
+func (this *Session) Send(cmd int16, message proto.Message) error {
+	buf, err := proto.Marshal(message)
+	if err != nil {
+		return err
+	}
+	packet := &BackendPacket{Type: BackendData, Session: this.Id, Cmd: cmd, Payload: buf}
+	return this.conn.Send(packet.Marshal())
+}
+
+func (this *Session) Kick() error {
+	packet := &BackendPacket{Type: BackendKick, Session: this.Id}
+	return this.conn.Send(packet.Marshal())
+}
+
+// 登录成功后通知gate账号, 用于管理后台查询
+func (this *Session) SetAccount(account string) error {
+	packet := &BackendPacket{Type: BackendAccount, Session: this.Id, Payload: []byte(account)}
+	return this.conn.Send(packet.Marshal())
+}
+
+// 登录或获得GM权限后通知gate切换状态
+func (this *Session) SetState(state uint8) error {
+	if !ValidState(state) {
+		return ErrSessionState
+	}
+	packet := &BackendPacket{Type: BackendState, Session: this.Id, Payload: []byte{state}}
+	return this.conn.Send(packet.Marshal())
+}
+
+func (this *Session) JoinGroup(group uint64) error {
+	return this.conn.Send(NewGroupPacket(BackendJoinGroup, this.Id, group).Marshal())
+}
+
+func (this *Session) LeaveGroup(group uint64) error {
+	return this.conn.Send(NewGroupPacket(BackendLeaveGroup, this.Id, group).Marshal())
+}
+
+// 发送给多个会话, 同一个gate上的会话只发送一次
+func Multicast(sessions []*Session, cmd int16, message proto.Message) error {
+	buf, err := proto.Marshal(message)
+	if err != nil {
+		return err
+	}
+	gates := make(map[*network.TCPConnection][]uint64)
+	for _, session := range sessions {
+		gates[session.conn] = append(gates[session.conn], session.Id)
+	}
+	for conn, ids := range gates {
+		if err := conn.Send(NewMulticastPacket(ids, cmd, buf).Marshal()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type sessionKey struct{}
+
+func NewSessionContext(ctx context.Context, s *Session) context.Context {
+	return context.WithValue(ctx, sessionKey{}, s)
+}
+
+func FromSessionContext(ctx context.Context) (*Session, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	s, ok := ctx.Value(sessionKey{}).(*Session)
+	return s, ok
+}
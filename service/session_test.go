@@ -0,0 +1,14 @@
+package service
+
+import "testing"
+
+func TestSessionState(t *testing.T) {
+	// 超出掩码范围的状态不发送给gate
+	session := NewSession(1, 1, 0, "", nil)
+	if err := session.SetState(8); err != ErrSessionState {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if !ValidState(StateGM) || ValidState(kStateBits) {
+		t.Fatal("unexpected valid state")
+	}
+}
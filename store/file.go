@@ -0,0 +1,501 @@
+package store
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	kRecordHeaderSize   = 8 // crc(4) + size(4)
+	kMaxRecordSize      = 256 << 20
+	kDefaultMaxFileSize = 64 << 20
+	kCompactRecordSize  = 1 << 20
+
+	opPut    uint8 = 1
+	opDelete uint8 = 2
+)
+
+var ErrCorrupt = errors.New("store: corrupt log")
+
+type options struct {
+	maxFileSize  int64
+	sync         bool
+	compactRatio float64
+	compactMin   int64
+}
+
+type Option func(o *options)
+
+// 数据文件超过大小后写入新文件
+func WithMaxFileSize(size int64) Option {
+	return func(o *options) {
+		o.maxFileSize = size
+	}
+}
+
+// 每次写入后fsync
+func WithSync(sync bool) Option {
+	return func(o *options) {
+		o.sync = sync
+	}
+}
+
+// 可回收数据超过min字节且超过有效数据的ratio倍时, 在写入后自动压缩, ratio不大于零时关闭
+func WithCompaction(ratio float64, min int64) Option {
+	return func(o *options) {
+		o.compactRatio = ratio
+		o.compactMin = min
+	}
+}
+
+type indexEntry struct {
+	file    uint32
+	offset  int64 // value在文件中的位置
+	size    uint32
+	version uint64
+}
+
+// 基于追加日志的本地存储, 内存中保存key到文件位置的索引, 打开时回放日志重建索引
+// 每次写入是一条带crc的记录, 批量操作在同一条记录中, 保证原子性
+type FileStore struct {
+	dir  string
+	opts options
+
+	mutex      sync.RWMutex
+	index      map[string]*indexEntry
+	files      map[uint32]*os.File
+	active     uint32
+	activeSize int64
+	live       int64 // 有效value的字节数
+	garbage    int64 // 被覆盖或删除的value字节数
+	compactAt  int64 // 自动压缩失败后, 可回收数据再增长后重试
+	closed     bool
+}
+
+func OpenFileStore(dir string, o ...Option) (*FileStore, error) {
+	opts := options{maxFileSize: kDefaultMaxFileSize, compactRatio: 1, compactMin: kDefaultMaxFileSize}
+	for _, option := range o {
+		option(&opts)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	s := &FileStore{
+		dir:   dir,
+		opts:  opts,
+		index: make(map[string]*indexEntry),
+		files: make(map[uint32]*os.File),
+	}
+	ids, err := s.fileIds()
+	if err != nil {
+		return nil, err
+	}
+	for i, id := range ids {
+		f, err := os.OpenFile(s.filename(id), os.O_RDWR, 0644)
+		if err != nil {
+			s.closeFiles()
+			return nil, err
+		}
+		s.files[id] = f
+		size, err := s.replay(id, f)
+		if err != nil {
+			// 只允许最后一个文件有未写完的记录
+			if !errors.Is(err, ErrCorrupt) || i != len(ids)-1 {
+				s.closeFiles()
+				return nil, fmt.Errorf("%w: %v", err, s.filename(id))
+			}
+			if err := f.Truncate(size); err != nil {
+				s.closeFiles()
+				return nil, err
+			}
+		}
+		s.active = id
+		s.activeSize = size
+	}
+	if len(ids) == 0 {
+		if err := s.roll(1); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func (s *FileStore) filename(id uint32) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%08d.log", id))
+}
+
+func (s *FileStore) fileIds() ([]uint32, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	var ids []uint32
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".log") {
+			continue
+		}
+		var id uint32
+		if _, err := fmt.Sscanf(name, "%08d.log", &id); err != nil {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
+
+// 回放日志, 返回最后一条完整记录的结尾位置
+func (s *FileStore) replay(id uint32, f *os.File) (int64, error) {
+	r := bufio.NewReader(f)
+	var offset int64
+	header := make([]byte, kRecordHeaderSize)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			if err == io.EOF {
+				return offset, nil
+			}
+			return offset, ErrCorrupt
+		}
+		sum := binary.BigEndian.Uint32(header)
+		size := binary.BigEndian.Uint32(header[4:])
+		if size > kMaxRecordSize {
+			return offset, ErrCorrupt
+		}
+		body := make([]byte, size)
+		if _, err := io.ReadFull(r, body); err != nil {
+			return offset, ErrCorrupt
+		}
+		if crc32.ChecksumIEEE(body) != sum {
+			return offset, ErrCorrupt
+		}
+		ops, positions, err := decodeRecord(body)
+		if err != nil {
+			return offset, err
+		}
+		for i := range ops {
+			s.apply(&ops[i], id, offset+kRecordHeaderSize+positions[i])
+		}
+		offset += kRecordHeaderSize + int64(size)
+	}
+}
+
+// 记录: count(4) | op(1) version(8) keylen(4) key valuelen(4) value ...
+func encodeRecord(ops []Op) ([]byte, []int64) {
+	size := kRecordHeaderSize + 4
+	for i := range ops {
+		size += 1 + 8 + 4 + len(ops[i].Key) + 4 + len(ops[i].Value)
+	}
+	b := make([]byte, kRecordHeaderSize, size)
+	b = binary.BigEndian.AppendUint32(b, uint32(len(ops)))
+	positions := make([]int64, len(ops))
+	for i := range ops {
+		op := &ops[i]
+		if op.Delete {
+			b = append(b, opDelete)
+		} else {
+			b = append(b, opPut)
+		}
+		b = binary.BigEndian.AppendUint64(b, op.Version)
+		b = binary.BigEndian.AppendUint32(b, uint32(len(op.Key)))
+		b = append(b, op.Key...)
+		b = binary.BigEndian.AppendUint32(b, uint32(len(op.Value)))
+		positions[i] = int64(len(b) - kRecordHeaderSize)
+		b = append(b, op.Value...)
+	}
+	body := b[kRecordHeaderSize:]
+	binary.BigEndian.PutUint32(b, crc32.ChecksumIEEE(body))
+	binary.BigEndian.PutUint32(b[4:], uint32(len(body)))
+	return b, positions
+}
+
+// 返回的Op中Version为写入后的版本, positions为value在body中的位置
+func decodeRecord(body []byte) ([]Op, []int64, error) {
+	if len(body) < 4 {
+		return nil, nil, ErrCorrupt
+	}
+	n := int(binary.BigEndian.Uint32(body))
+	ops := make([]Op, 0, min(n, len(body)))
+	var positions []int64
+	pos := 4
+	for i := 0; i < n; i++ {
+		if len(body) < pos+13 {
+			return nil, nil, ErrCorrupt
+		}
+		var op Op
+		op.Delete = body[pos] == opDelete
+		op.Version = binary.BigEndian.Uint64(body[pos+1:])
+		keylen := int(binary.BigEndian.Uint32(body[pos+9:]))
+		pos += 13
+		if len(body) < pos+keylen+4 {
+			return nil, nil, ErrCorrupt
+		}
+		op.Key = string(body[pos : pos+keylen])
+		pos += keylen
+		valuelen := int(binary.BigEndian.Uint32(body[pos:]))
+		pos += 4
+		if len(body) < pos+valuelen {
+			return nil, nil, ErrCorrupt
+		}
+		positions = append(positions, int64(pos))
+		op.Value = body[pos : pos+valuelen]
+		pos += valuelen
+		ops = append(ops, op)
+	}
+	return ops, positions, nil
+}
+
+func (s *FileStore) apply(op *Op, file uint32, offset int64) {
+	if old, ok := s.index[op.Key]; ok {
+		s.live -= int64(old.size)
+		s.garbage += int64(old.size)
+	}
+	if op.Delete {
+		delete(s.index, op.Key)
+		return
+	}
+	s.index[op.Key] = &indexEntry{file: file, offset: offset, size: uint32(len(op.Value)), version: op.Version}
+	s.live += int64(len(op.Value))
+}
+
+// 切换到新的数据文件
+func (s *FileStore) roll(id uint32) error {
+	f, err := os.OpenFile(s.filename(id), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	s.files[id] = f
+	s.active = id
+	s.activeSize = 0
+	return nil
+}
+
+// 写入一条记录并更新索引, ops中的Version为写入后的版本
+func (s *FileStore) write(ops []Op) error {
+	b, positions := encodeRecord(ops)
+	if s.activeSize > 0 && s.activeSize+int64(len(b)) > s.opts.maxFileSize {
+		if err := s.roll(s.active + 1); err != nil {
+			return err
+		}
+	}
+	f := s.files[s.active]
+	if _, err := f.WriteAt(b, s.activeSize); err != nil {
+		return err
+	}
+	if s.opts.sync {
+		if err := f.Sync(); err != nil {
+			return err
+		}
+	}
+	offset := s.activeSize + kRecordHeaderSize
+	s.activeSize += int64(len(b))
+	for i := range ops {
+		s.apply(&ops[i], s.active, offset+positions[i])
+	}
+	return nil
+}
+
+func (s *FileStore) Get(key string) ([]byte, uint64, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	if s.closed {
+		return nil, 0, ErrClosed
+	}
+	entry, ok := s.index[key]
+	if !ok {
+		return nil, 0, ErrNotFound
+	}
+	value := make([]byte, entry.size)
+	if _, err := s.files[entry.file].ReadAt(value, entry.offset); err != nil {
+		return nil, 0, err
+	}
+	return value, entry.version, nil
+}
+
+func (s *FileStore) Put(key string, value []byte, version uint64) (uint64, error) {
+	versions, err := s.Batch([]Op{{Key: key, Value: value, Version: version}})
+	if err != nil {
+		return 0, err
+	}
+	return versions[0], nil
+}
+
+func (s *FileStore) Delete(key string, version uint64) error {
+	_, err := s.Batch([]Op{{Key: key, Version: version, Delete: true}})
+	return err
+}
+
+func (s *FileStore) Batch(ops []Op) ([]uint64, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.closed {
+		return nil, ErrClosed
+	}
+	// 同一批次中的key按顺序计算版本
+	versions := make(map[string]uint64, len(ops))
+	writes := make([]Op, len(ops))
+	result := make([]uint64, len(ops))
+	for i, op := range ops {
+		current, ok := versions[op.Key]
+		if !ok {
+			if entry, ok := s.index[op.Key]; ok {
+				current = entry.version
+			}
+		}
+		if op.Version != AnyVersion && op.Version != current {
+			return nil, fmt.Errorf("%w: %v", ErrVersionConflict, op.Key)
+		}
+		if op.Delete {
+			if current == 0 {
+				return nil, fmt.Errorf("%w: %v", ErrNotFound, op.Key)
+			}
+			versions[op.Key] = 0
+			writes[i] = Op{Key: op.Key, Delete: true}
+			continue
+		}
+		versions[op.Key] = current + 1
+		writes[i] = Op{Key: op.Key, Value: op.Value, Version: current + 1}
+		result[i] = current + 1
+	}
+	if err := s.write(writes); err != nil {
+		return nil, err
+	}
+	// 写入已经成功, 压缩失败时保留旧文件, 不影响本次结果
+	if s.needCompact() {
+		if err := s.compact(); err != nil {
+			s.compactAt = s.garbage + s.opts.compactMin
+		}
+	}
+	return result, nil
+}
+
+func (s *FileStore) needCompact() bool {
+	if s.opts.compactRatio <= 0 {
+		return false
+	}
+	return s.garbage >= max(s.opts.compactMin, s.compactAt) && float64(s.garbage) > float64(s.live)*s.opts.compactRatio
+}
+
+// 有效数据和可回收数据的字节数
+func (s *FileStore) Size() (int64, int64) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.live, s.garbage
+}
+
+// 把有效数据写入新文件后删除旧文件, 压缩期间阻塞读写
+// 中途失败时旧文件仍然保留, 重新打开时回放结果一致
+func (s *FileStore) Compact() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.closed {
+		return ErrClosed
+	}
+	return s.compact()
+}
+
+func (s *FileStore) compact() error {
+	old := s.files
+	keys := make([]string, 0, len(s.index))
+	for key := range s.index {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	index, live, garbage := s.index, s.live, s.garbage
+	active, activeSize := s.active, s.activeSize
+	s.files = make(map[uint32]*os.File)
+	s.index = make(map[string]*indexEntry, len(keys))
+	s.live, s.garbage = 0, 0
+	err := s.roll(active + 1)
+	var ops []Op
+	var size int
+	for i := 0; err == nil && i < len(keys); i++ {
+		entry := index[keys[i]]
+		value := make([]byte, entry.size)
+		if _, err = old[entry.file].ReadAt(value, entry.offset); err != nil {
+			break
+		}
+		ops = append(ops, Op{Key: keys[i], Value: value, Version: entry.version})
+		size += len(value)
+		// 合并成较大的记录写入
+		if size >= kCompactRecordSize || i == len(keys)-1 {
+			err = s.write(ops)
+			ops, size = nil, 0
+		}
+	}
+	if err == nil {
+		err = s.syncFiles()
+	}
+	// 新文件在目录中持久化之后才能删除旧文件
+	if err == nil {
+		err = s.syncDir()
+	}
+	if err != nil {
+		// 恢复到压缩前
+		s.closeFiles()
+		for id := range s.files {
+			os.Remove(s.filename(id))
+		}
+		s.files, s.index, s.live, s.garbage = old, index, live, garbage
+		s.active, s.activeSize = active, activeSize
+		return err
+	}
+	// 从最早的文件开始删除, 中途崩溃时剩下的是日志的后缀, 删除记录不会丢失
+	ids := make([]uint32, 0, len(old))
+	for id := range old {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for _, id := range ids {
+		old[id].Close()
+		os.Remove(s.filename(id))
+	}
+	s.compactAt = 0
+	return s.syncDir()
+}
+
+func (s *FileStore) syncDir() error {
+	d, err := os.Open(s.dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+func (s *FileStore) syncFiles() error {
+	for _, f := range s.files {
+		if err := f.Sync(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *FileStore) closeFiles() {
+	for _, f := range s.files {
+		f.Close()
+	}
+}
+
+func (s *FileStore) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.closed {
+		return ErrClosed
+	}
+	s.closed = true
+	err := s.syncFiles()
+	s.closeFiles()
+	return err
+}
@@ -0,0 +1,175 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func openTestStore(t *testing.T, dir string, o ...Option) *FileStore {
+	s, err := OpenFileStore(dir, o...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func expectValue(t *testing.T, s Store, key string, value string, version uint64) {
+	t.Helper()
+	b, v, err := s.Get(key)
+	if err != nil {
+		t.Fatalf("get %v: %v", key, err)
+	}
+	if string(b) != value || v != version {
+		t.Fatalf("get %v: %q version %v, expect %q version %v", key, b, v, value, version)
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	dir := t.TempDir()
+	s := openTestStore(t, dir)
+	if _, _, err := s.Get("a"); err != ErrNotFound {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if v, err := s.Put("a", []byte("1"), 0); err != nil || v != 1 {
+		t.Fatalf("put: %v, %v", v, err)
+	}
+	// 版本不匹配
+	if _, err := s.Put("a", []byte("2"), 0); !errors.Is(err, ErrVersionConflict) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if v, err := s.Put("a", []byte("2"), 1); err != nil || v != 2 {
+		t.Fatalf("put: %v, %v", v, err)
+	}
+	if v, err := s.Put("a", []byte("3"), AnyVersion); err != nil || v != 3 {
+		t.Fatalf("put: %v, %v", v, err)
+	}
+	expectValue(t, s, "a", "3", 3)
+
+	// 批量操作中一项冲突, 全部不写入
+	_, err := s.Batch([]Op{
+		{Key: "b", Value: []byte("1")},
+		{Key: "a", Value: []byte("4"), Version: 2},
+	})
+	if !errors.Is(err, ErrVersionConflict) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if _, _, err := s.Get("b"); err != ErrNotFound {
+		t.Fatalf("unexpected error %v", err)
+	}
+	versions, err := s.Batch([]Op{
+		{Key: "b", Value: []byte("1")},
+		{Key: "b", Value: []byte("2"), Version: 1},
+		{Key: "a", Version: 3, Delete: true},
+	})
+	if err != nil || versions[0] != 1 || versions[1] != 2 || versions[2] != 0 {
+		t.Fatalf("batch: %v, %v", versions, err)
+	}
+	if err := s.Delete("a", AnyVersion); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	s.Close()
+
+	// 重新打开回放日志
+	s = openTestStore(t, dir)
+	defer s.Close()
+	expectValue(t, s, "b", "2", 2)
+	if _, _, err := s.Get("a"); err != ErrNotFound {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestFileStoreCompact(t *testing.T) {
+	dir := t.TempDir()
+	s := openTestStore(t, dir, WithMaxFileSize(4096))
+	for i := 0; i < 100; i++ {
+		for j := 0; j < 10; j++ {
+			key := fmt.Sprintf("key%03d", j)
+			if _, err := s.Put(key, []byte(fmt.Sprintf("value%03d", i)), AnyVersion); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := s.Delete("key000", AnyVersion); err != nil {
+		t.Fatal(err)
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*.log"))
+	if len(files) < 2 {
+		t.Fatalf("expect multiple log files, got %v", len(files))
+	}
+	if err := s.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	live, garbage := s.Size()
+	if live != 9*8 || garbage != 0 {
+		t.Fatalf("unexpected size: live=%v, garbage=%v", live, garbage)
+	}
+	expectValue(t, s, "key001", "value099", 100)
+	if _, err := s.Put("key002", []byte("new"), 100); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	compacted, _ := filepath.Glob(filepath.Join(dir, "*.log"))
+	if len(compacted) != 1 {
+		t.Fatalf("unexpected log files %v", compacted)
+	}
+	s = openTestStore(t, dir)
+	defer s.Close()
+	expectValue(t, s, "key002", "new", 101)
+	expectValue(t, s, "key009", "value099", 100)
+	if _, _, err := s.Get("key000"); err != ErrNotFound {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestFileStoreAutoCompact(t *testing.T) {
+	dir := t.TempDir()
+	s := openTestStore(t, dir, WithMaxFileSize(4096), WithCompaction(1, 1024))
+	defer s.Close()
+	for i := 0; i < 1000; i++ {
+		if _, err := s.Put(fmt.Sprintf("key%03d", i%10), []byte(fmt.Sprintf("value%03d", i)), AnyVersion); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 可回收数据不超过阈值
+	live, garbage := s.Size()
+	if live != 10*8 || garbage >= 1024 {
+		t.Fatalf("unexpected size: live=%v, garbage=%v", live, garbage)
+	}
+	expectValue(t, s, "key009", "value999", 100)
+	files, _ := filepath.Glob(filepath.Join(dir, "*.log"))
+	if len(files) > 2 {
+		t.Fatalf("unexpected log files %v", files)
+	}
+}
+
+func TestFileStoreTruncated(t *testing.T) {
+	dir := t.TempDir()
+	s := openTestStore(t, dir)
+	s.Put("a", []byte("1"), 0)
+	s.Put("b", []byte("1"), 0)
+	s.Close()
+
+	// 模拟写到一半崩溃
+	filename := filepath.Join(dir, "00000001.log")
+	info, err := os.Stat(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Truncate(filename, info.Size()-1); err != nil {
+		t.Fatal(err)
+	}
+	s = openTestStore(t, dir)
+	defer s.Close()
+	expectValue(t, s, "a", "1", 1)
+	if _, _, err := s.Get("b"); err != ErrNotFound {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if _, err := s.Put("b", []byte("2"), 0); err != nil {
+		t.Fatal(err)
+	}
+	expectValue(t, s, "b", "2", 1)
+}
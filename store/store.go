@@ -0,0 +1,32 @@
+package store
+
+import (
+	"errors"
+)
+
+// Put和Delete不检查版本
+const AnyVersion uint64 = ^uint64(0)
+
+var (
+	ErrNotFound        = errors.New("store: not found")
+	ErrVersionConflict = errors.New("store: version conflict")
+	ErrClosed          = errors.New("store: closed")
+)
+
+// 批量操作中的一项, Version为期望的当前版本, 0表示key不存在
+type Op struct {
+	Key     string
+	Value   []byte
+	Version uint64
+	Delete  bool
+}
+
+// 带乐观版本的key-value存储, 每次写入版本加1
+type Store interface {
+	Get(key string) ([]byte, uint64, error)
+	Put(key string, value []byte, version uint64) (uint64, error)
+	Delete(key string, version uint64) error
+	// 所有版本检查通过才写入, 返回每一项的新版本, 删除的项为0
+	Batch(ops []Op) ([]uint64, error)
+	Close() error
+}
@@ -0,0 +1,65 @@
+package timer
+
+import (
+	"time"
+)
+
+// 重复执行的时间表
+type Schedule interface {
+	// 严格晚于t的下一次执行时间
+	Next(t time.Time) time.Time
+}
+
+type every time.Duration
+
+// 固定间隔, 按上一次的到期时间计算, 不会累积误差
+func Every(d time.Duration) Schedule {
+	if d <= 0 {
+		panic("timer: non-positive interval")
+	}
+	return every(d)
+}
+
+func (e every) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(e))
+}
+
+type daily struct {
+	hour, min, sec int
+	location       *time.Location
+}
+
+// 每天的固定时间, 夏令时切换时按time.Date规范化
+func Daily(hour, min, sec int, location *time.Location) Schedule {
+	return &daily{hour, min, sec, location}
+}
+
+func (d *daily) Next(t time.Time) time.Time {
+	lt := t.In(d.location)
+	next := time.Date(lt.Year(), lt.Month(), lt.Day(), d.hour, d.min, d.sec, 0, d.location)
+	if !next.After(t) {
+		next = time.Date(lt.Year(), lt.Month(), lt.Day()+1, d.hour, d.min, d.sec, 0, d.location)
+	}
+	return next
+}
+
+type weekly struct {
+	weekday        time.Weekday
+	hour, min, sec int
+	location       *time.Location
+}
+
+// 每周的固定时间
+func Weekly(weekday time.Weekday, hour, min, sec int, location *time.Location) Schedule {
+	return &weekly{weekday, hour, min, sec, location}
+}
+
+func (w *weekly) Next(t time.Time) time.Time {
+	lt := t.In(w.location)
+	days := (int(w.weekday) - int(lt.Weekday()) + 7) % 7
+	next := time.Date(lt.Year(), lt.Month(), lt.Day()+days, w.hour, w.min, w.sec, 0, w.location)
+	if !next.After(t) {
+		next = time.Date(lt.Year(), lt.Month(), lt.Day()+days+7, w.hour, w.min, w.sec, 0, w.location)
+	}
+	return next
+}
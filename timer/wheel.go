@@ -0,0 +1,311 @@
+package timer
+
+import (
+	"time"
+)
+
+// 分层时间轮, 第1层256个槽, 其余4层各64个槽, 覆盖2^32个tick
+const (
+	kRootBits  = 8
+	kLevelBits = 6
+	kRootSize  = 1 << kRootBits
+	kLevelSize = 1 << kLevelBits
+	kRootMask  = kRootSize - 1
+	kLevelMask = kLevelSize - 1
+	kLevels    = 4
+	kMaxTicks  = 1<<(kRootBits+kLevels*kLevelBits) - 1
+)
+
+// 定时器链表, head为哨兵
+type timerList struct {
+	head Timer
+}
+
+func (l *timerList) init() {
+	l.head.prev = &l.head
+	l.head.next = &l.head
+}
+
+func (l *timerList) empty() bool {
+	return l.head.next == &l.head
+}
+
+func (l *timerList) push(t *Timer) {
+	t.list = l
+	t.prev = l.head.prev
+	t.next = &l.head
+	l.head.prev.next = t
+	l.head.prev = t
+}
+
+// 把所有定时器移动到dst
+func (l *timerList) moveTo(dst *timerList) {
+	dst.init()
+	if l.empty() {
+		return
+	}
+	for t := l.head.next; t != &l.head; t = t.next {
+		t.list = dst
+	}
+	dst.head.next = l.head.next
+	dst.head.prev = l.head.prev
+	dst.head.next.prev = &dst.head
+	dst.head.prev.next = &dst.head
+	l.init()
+}
+
+// 取出所有定时器, 链表置空
+func (l *timerList) take() *Timer {
+	if l.empty() {
+		return nil
+	}
+	first := l.head.next
+	l.head.prev.next = nil
+	l.init()
+	return first
+}
+
+type Timer struct {
+	wheel    *Wheel
+	f        func()
+	schedule Schedule // 重复执行的定时器
+	deadline time.Time
+	expires  uint64 // 到期的tick
+
+	prev, next *Timer
+	list       *timerList
+}
+
+func (t *Timer) remove() {
+	t.prev.next = t.next
+	t.next.prev = t.prev
+	t.prev, t.next, t.list = nil, nil, nil
+}
+
+// 到期时间, 重复执行的定时器为下一次执行的时间
+func (t *Timer) Deadline() time.Time {
+	return t.deadline
+}
+
+// 取消定时器, 已经执行或取消返回false
+func (t *Timer) Stop() bool {
+	t.schedule = nil
+	if t.list == nil {
+		return false
+	}
+	t.remove()
+	t.wheel.count--
+	return true
+}
+
+// 重新设置到期时间, 返回定时器之前是否在等待执行
+func (t *Timer) Reset(d time.Duration) bool {
+	active := t.list != nil
+	if active {
+		t.remove()
+		t.wheel.count--
+	}
+	t.deadline = t.wheel.now.Add(d)
+	t.wheel.add(t)
+	return active
+}
+
+type options struct {
+	location *time.Location
+}
+
+type Option func(o *options)
+
+// 每日和每周定时使用的时区, 默认为服务器本地时区
+func WithLocation(location *time.Location) Option {
+	return func(o *options) {
+		o.location = location
+	}
+}
+
+// 由调用者推进时间, 定时器在Advance中执行, 非并发安全
+// 通常只在逻辑线程使用
+type Wheel struct {
+	tick     time.Duration
+	start    time.Time
+	now      time.Time
+	current  uint64 // 下一个要处理的tick
+	count    int
+	location *time.Location
+
+	root   [kRootSize]timerList
+	levels [kLevels][kLevelSize]timerList
+}
+
+func NewWheel(tick time.Duration, now time.Time, o ...Option) *Wheel {
+	opts := options{location: time.Local}
+	for _, option := range o {
+		option(&opts)
+	}
+	w := &Wheel{
+		tick:     tick,
+		start:    now,
+		now:      now,
+		location: opts.location,
+	}
+	for i := range w.root {
+		w.root[i].init()
+	}
+	for i := range w.levels {
+		for j := range w.levels[i] {
+			w.levels[i][j].init()
+		}
+	}
+	return w
+}
+
+// 最后一次推进到的时间
+func (w *Wheel) Now() time.Time {
+	return w.now
+}
+
+// 等待执行的定时器数量
+func (w *Wheel) Len() int {
+	return w.count
+}
+
+func (w *Wheel) Location() *time.Location {
+	return w.location
+}
+
+// d之后执行一次
+func (w *Wheel) AfterFunc(d time.Duration, f func()) *Timer {
+	t := &Timer{wheel: w, f: f, deadline: w.now.Add(d)}
+	w.add(t)
+	return t
+}
+
+// 按schedule重复执行, 直到Stop
+func (w *Wheel) ScheduleFunc(schedule Schedule, f func()) *Timer {
+	t := &Timer{wheel: w, f: f, schedule: schedule, deadline: schedule.Next(w.now)}
+	w.add(t)
+	return t
+}
+
+// 每隔d执行一次
+func (w *Wheel) EveryFunc(d time.Duration, f func()) *Timer {
+	return w.ScheduleFunc(Every(d), f)
+}
+
+// 每天的固定时间执行
+func (w *Wheel) DailyFunc(hour, min, sec int, f func()) *Timer {
+	return w.ScheduleFunc(Daily(hour, min, sec, w.location), f)
+}
+
+// 每周的固定时间执行
+func (w *Wheel) WeeklyFunc(weekday time.Weekday, hour, min, sec int, f func()) *Timer {
+	return w.ScheduleFunc(Weekly(weekday, hour, min, sec, w.location), f)
+}
+
+// 到期的tick向上取整, 保证不会提前执行
+func (w *Wheel) ticks(deadline time.Time) uint64 {
+	d := deadline.Sub(w.start)
+	if d <= 0 {
+		return 0
+	}
+	return uint64((d + w.tick - 1) / w.tick)
+}
+
+func (w *Wheel) add(t *Timer) {
+	t.expires = w.ticks(t.deadline)
+	w.count++
+	w.place(t)
+}
+
+func (w *Wheel) place(t *Timer) {
+	expires := t.expires
+	if expires < w.current {
+		// 已经到期, 下一个tick执行
+		expires = w.current
+	}
+	idx := expires - w.current
+	if idx < kRootSize {
+		w.root[expires&kRootMask].push(t)
+		return
+	}
+	if idx > kMaxTicks {
+		// 超出范围, 先放在最高层, 降级时重新计算
+		expires = w.current + kMaxTicks
+		idx = kMaxTicks
+	}
+	for level := 0; level < kLevels; level++ {
+		if idx < 1<<(kRootBits+(level+1)*kLevelBits) {
+			shift := kRootBits + level*kLevelBits
+			w.levels[level][(expires>>shift)&kLevelMask].push(t)
+			return
+		}
+	}
+}
+
+// 高层的槽到期时, 把其中的定时器重新放到低层
+func (w *Wheel) cascade(level int) uint64 {
+	shift := kRootBits + level*kLevelBits
+	index := (w.current >> shift) & kLevelMask
+	for t := w.levels[level][index].take(); t != nil; {
+		next := t.next
+		t.prev, t.next, t.list = nil, nil, nil
+		w.place(t)
+		t = next
+	}
+	return index
+}
+
+// 推进到now, 执行所有到期的定时器
+func (w *Wheel) Advance(now time.Time) {
+	if now.Before(w.now) {
+		return
+	}
+	w.now = now
+	target := uint64(0)
+	if d := now.Sub(w.start); d > 0 {
+		target = uint64(d / w.tick)
+	}
+	for w.current <= target {
+		if w.count == 0 {
+			// 没有定时器时直接跳到目标tick
+			w.current = target + 1
+			return
+		}
+		w.step()
+	}
+}
+
+func (w *Wheel) step() {
+	index := w.current & kRootMask
+	if index == 0 {
+		for level := 0; level < kLevels; level++ {
+			if w.cascade(level) != 0 {
+				break
+			}
+		}
+	}
+	// 先推进tick, 回调中添加的已到期定时器在下一个tick执行
+	w.current++
+	// 回调中可能取消同一个槽中的其他定时器
+	var work timerList
+	w.root[index].moveTo(&work)
+	for !work.empty() {
+		t := work.head.next
+		t.remove()
+		w.count--
+		w.run(t)
+	}
+}
+
+func (w *Wheel) run(t *Timer) {
+	t.f()
+	// 回调中可能Stop或Reset
+	if t.schedule != nil && t.list == nil {
+		t.deadline = t.schedule.Next(t.deadline)
+		if !t.deadline.After(w.now) {
+			// 错过的执行不再补上
+			t.deadline = t.schedule.Next(w.now)
+		}
+		w.add(t)
+	}
+}
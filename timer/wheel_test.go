@@ -0,0 +1,149 @@
+package timer
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// 测试用的时钟, 推进时驱动时间轮
+type fakeClock struct {
+	now   time.Time
+	wheel *Wheel
+}
+
+func newFakeClock(tick time.Duration, o ...Option) *fakeClock {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	return &fakeClock{now: now, wheel: NewWheel(tick, now, o...)}
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+	c.wheel.Advance(c.now)
+}
+
+func TestAfterFunc(t *testing.T) {
+	clock := newFakeClock(time.Millisecond * 100)
+	w := clock.wheel
+	rnd := rand.New(rand.NewSource(1))
+	type record struct {
+		deadline time.Time
+		fired    time.Time
+	}
+	records := make([]*record, 10000)
+	for i := range records {
+		r := &record{}
+		// 覆盖所有层级
+		d := time.Duration(rnd.Int63n(int64(time.Hour * 24 * 4)))
+		r.deadline = clock.now.Add(d)
+		w.AfterFunc(d, func() { r.fired = clock.now })
+		records[i] = r
+	}
+	for w.Len() > 0 {
+		clock.Advance(time.Minute)
+	}
+	for _, r := range records {
+		// 不会提前, 最多晚一次推进
+		if r.fired.Before(r.deadline) || r.fired.Sub(r.deadline) > time.Minute {
+			t.Fatalf("deadline %v fired %v", r.deadline, r.fired)
+		}
+	}
+}
+
+func TestStopReset(t *testing.T) {
+	clock := newFakeClock(time.Millisecond * 10)
+	w := clock.wheel
+	var fired []int
+	t1 := w.AfterFunc(time.Second, func() { fired = append(fired, 1) })
+	t2 := w.AfterFunc(time.Second, func() { fired = append(fired, 2) })
+	t3 := w.AfterFunc(time.Second, func() { fired = append(fired, 3) })
+	// 同一个槽中取消其他定时器
+	w.AfterFunc(time.Millisecond*500, func() {
+		if !t2.Stop() {
+			t.Error("stop failed")
+		}
+	})
+	clock.Advance(time.Millisecond * 500)
+	if !t3.Reset(time.Minute) {
+		t.Fatal("reset inactive timer")
+	}
+	clock.Advance(time.Millisecond * 500)
+	if len(fired) != 1 || fired[0] != 1 || t1.Stop() {
+		t.Fatalf("unexpected fired %v", fired)
+	}
+	clock.Advance(time.Minute)
+	if len(fired) != 2 || fired[1] != 3 || w.Len() != 0 {
+		t.Fatalf("unexpected fired %v", fired)
+	}
+}
+
+func TestEveryFunc(t *testing.T) {
+	clock := newFakeClock(time.Millisecond * 50)
+	w := clock.wheel
+	count := 0
+	var every *Timer
+	every = w.EveryFunc(time.Second, func() {
+		count++
+		if count == 5 {
+			every.Stop()
+		}
+	})
+	for i := 0; i < 100; i++ {
+		clock.Advance(time.Millisecond * 100)
+	}
+	if count != 5 || w.Len() != 0 {
+		t.Fatalf("unexpected count %v", count)
+	}
+}
+
+func TestDailyWeekly(t *testing.T) {
+	location := time.FixedZone("UTC+8", 8*3600)
+	clock := newFakeClock(time.Second, WithLocation(location))
+	w := clock.wheel
+	var daily, weekly []time.Time
+	w.DailyFunc(5, 0, 0, func() { daily = append(daily, clock.now.In(location)) })
+	w.WeeklyFunc(time.Monday, 0, 0, 0, func() { weekly = append(weekly, clock.now.In(location)) })
+	for i := 0; i < 24*14; i++ {
+		clock.Advance(time.Hour)
+	}
+	if len(daily) != 14 || len(weekly) != 2 {
+		t.Fatalf("unexpected daily %v, weekly %v", len(daily), len(weekly))
+	}
+	for _, at := range daily {
+		if at.Hour() != 5 || at.Minute() != 0 {
+			t.Fatalf("unexpected daily %v", at)
+		}
+	}
+	for _, at := range weekly {
+		if at.Weekday() != time.Monday || at.Hour() != 0 {
+			t.Fatalf("unexpected weekly %v", at)
+		}
+	}
+}
+
+func TestSchedule(t *testing.T) {
+	location := time.UTC
+	now := time.Date(2024, 1, 1, 5, 0, 0, 0, location) // 星期一
+	if next := Daily(5, 0, 0, location).Next(now); !next.Equal(now.AddDate(0, 0, 1)) {
+		t.Fatalf("unexpected daily %v", next)
+	}
+	if next := Daily(6, 0, 0, location).Next(now); !next.Equal(now.Add(time.Hour)) {
+		t.Fatalf("unexpected daily %v", next)
+	}
+	if next := Weekly(time.Monday, 5, 0, 0, location).Next(now); !next.Equal(now.AddDate(0, 0, 7)) {
+		t.Fatalf("unexpected weekly %v", next)
+	}
+	if next := Weekly(time.Sunday, 0, 0, 0, location).Next(now); !next.Equal(time.Date(2024, 1, 7, 0, 0, 0, 0, location)) {
+		t.Fatalf("unexpected weekly %v", next)
+	}
+}
+
+func BenchmarkAfterFunc(b *testing.B) {
+	clock := newFakeClock(time.Millisecond * 10)
+	w := clock.wheel
+	f := func() {}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		w.AfterFunc(time.Duration(i%100000)*time.Millisecond, f).Stop()
+	}
+}